@@ -3,15 +3,27 @@ package app
 import (
 	"context"
 	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"torn_oc_items/internal/clock"
+	"torn_oc_items/internal/config"
+	"torn_oc_items/internal/currency"
 	"torn_oc_items/internal/env"
+	"torn_oc_items/internal/events"
 	"torn_oc_items/internal/log"
 	"torn_oc_items/internal/notifications"
+	"torn_oc_items/internal/processing"
+	"torn_oc_items/internal/resolution"
 	"torn_oc_items/internal/sheets"
+	"torn_oc_items/internal/status"
 	"torn_oc_items/internal/torn"
+	"torn_oc_items/internal/tracking"
 )
 
 // SetupEnvironment loads .env file and configures logging.
@@ -28,6 +40,20 @@ func SetupEnvironment() {
 	} else {
 		slog.Debug("No .env file found or error loading .env file; proceeding with existing environment variables.")
 	}
+
+	if err := config.LoadResilienceFromEnv(); err != nil {
+		slog.Warn("Invalid retry setting, keeping the default", "error", err)
+	}
+	if err := config.DefaultResilienceConfig.Validate(); err != nil {
+		slog.Warn("Invalid retry configuration", "error", err)
+	}
+
+	if fixedNow, err := clock.ParseFixed(os.Getenv("FIXED_NOW")); err != nil {
+		slog.Warn("Invalid FIXED_NOW, using the real clock", "fixed_now", os.Getenv("FIXED_NOW"), "error", err)
+	} else if !fixedNow.IsZero() {
+		clock.SetFixed(fixedNow)
+		slog.Warn("Clock fixed by FIXED_NOW; meant for reproducible test runs only", "now", fixedNow)
+	}
 }
 
 // GetRequiredEnv fetches a required environment variable or exits if not set.
@@ -49,27 +75,217 @@ func GetEnvWithDefault(key, defaultValue string) string {
 	return value
 }
 
+// BuildUserAgent returns the User-Agent sent on outgoing requests, including the build
+// version and an optional operator contact from USER_AGENT_CONTACT
+func BuildUserAgent(version string) string {
+	userAgent := "torn-oc-items/" + version
+	if contact := os.Getenv("USER_AGENT_CONTACT"); contact != "" {
+		userAgent += " (+" + contact + ")"
+	}
+	return userAgent
+}
+
+// InitializeItemCatalog creates the item cache shared by the faction and provider clients
+func InitializeItemCatalog() *torn.Catalog {
+	ttl := time.Duration(parseIntWithDefault("ITEM_CACHE_TTL_MIN", 60)) * time.Minute
+	slog.Debug("Initializing shared item catalog", "ttl", ttl)
+	return torn.NewCatalog(ttl)
+}
+
 // InitializeClients creates and returns the Torn API client and Google Sheets client
-func InitializeClients(ctx context.Context) (*torn.Client, *sheets.Client) {
+func InitializeClients(ctx context.Context, userAgent string, catalog *torn.Catalog) (torn.TornAPI, *sheets.Client) {
+	setFallbackFormats()
+
+	if MonitorOnly() {
+		slog.Info("MONITOR_ONLY enabled; the sheet is read but never written and no notifications are sent")
+	}
+
+	if MockModeEnabled() {
+		tornClient, sheetsClient := initializeMockClients()
+		sheetsClient.SetReadOnly(MonitorOnly())
+		return tornClient, sheetsClient
+	}
+
 	slog.Debug("Initializing clients")
+	tornClient := newTornClient(userAgent, catalog)
+	sheetsClient, err := sheets.NewClient(ctx, CredentialsFile())
+	if err != nil {
+		slog.Error("Failed to create sheets client", "error", err)
+		os.Exit(1)
+	}
+	sheetsClient.EnableReadCache(sheetReadCacheEnabled())
+	sheetsClient.EnableEditCheck(sheets.EditCheckEnabled())
+	if err := sheets.ResolveSheetGID(ctx, sheetsClient); err != nil {
+		slog.Error("Failed to resolve SPREADSHEET_GID to a sheet name", "error", err)
+		os.Exit(1)
+	}
+	sheetsClient.SetReadOnly(MonitorOnly())
+
+	slog.Debug("Clients initialized successfully")
+	return tornClient, sheetsClient
+}
+
+// InitializeTornClient creates the Torn API client alone, for modes that never touch the
+// sheet and so need no Google credentials
+func InitializeTornClient(userAgent string, catalog *torn.Catalog) torn.TornAPI {
+	setFallbackFormats()
+	if MockModeEnabled() {
+		slog.Warn("MOCK_MODE enabled; using fixtures instead of the Torn API", "mock_data_dir", MockDataDir())
+		return newMockTornClient(MockDataDir())
+	}
+	return newTornClient(userAgent, catalog)
+}
+
+// setFallbackFormats applies ITEM_FALLBACK_FORMAT and USER_FALLBACK_FORMAT, exiting when
+// either is invalid
+func setFallbackFormats() {
+	if err := resolution.SetFallbackFormats(os.Getenv("ITEM_FALLBACK_FORMAT"), os.Getenv("USER_FALLBACK_FORMAT")); err != nil {
+		slog.Error("Invalid fallback name format", "error", err)
+		os.Exit(1)
+	}
+}
+
+// newTornClient creates the Torn API client from the environment
+func newTornClient(userAgent string, catalog *torn.Catalog) *torn.Client {
 	apiKey := GetRequiredEnv("TORN_API_KEY")
 	factionApiKey := GetRequiredEnv("TORN_FACTION_API_KEY")
-	credsFile := "credentials.json"
 
-	tornClient := torn.NewClient(apiKey, factionApiKey)
-	sheetsClient, err := sheets.NewClient(ctx, credsFile)
+	if codes := os.Getenv("TORN_RETRY_STATUS_CODES"); codes != "" {
+		config.DefaultResilienceConfig.RetryableStatusCodes = parseIntList("TORN_RETRY_STATUS_CODES", codes, config.DefaultResilienceConfig.RetryableStatusCodes)
+	}
+
+	torn.SetBodyPreviewLength(parseIntWithDefault("TORN_BODY_PREVIEW_CHARS", 0))
+	extraHeaders, err := torn.ParseExtraHeaders(os.Getenv("TORN_EXTRA_HEADERS"))
 	if err != nil {
-		slog.Error("Failed to create sheets client", "error", err)
+		slog.Error("Invalid TORN_EXTRA_HEADERS", "error", err)
 		os.Exit(1)
 	}
+	if len(extraHeaders) > 0 {
+		// Names only; values may be gateway credentials
+		slog.Info("Adding extra headers to Torn API requests", "headers", slices.Sorted(maps.Keys(extraHeaders)))
+	}
+	torn.SetExtraHeaders(extraHeaders)
+	torn.SetClockSkewThreshold(time.Duration(parseIntWithDefault("CLOCK_SKEW_WARN_SEC", int(torn.DefaultClockSkewThreshold/time.Second))) * time.Second)
+	tornClient := torn.NewClient(apiKey, factionApiKey, userAgent, catalog)
+	tornClient.SetUserStatusTTL(userStatusTTL())
+
+	allowlist := parseIntList("ITEM_ALLOWLIST", os.Getenv("ITEM_ALLOWLIST"), nil)
+	blocklist := parseIntList("ITEM_BLOCKLIST", os.Getenv("ITEM_BLOCKLIST"), nil)
+	if len(allowlist) > 0 || len(blocklist) > 0 {
+		slog.Info("Item filters configured", "allowlist", allowlist, "blocklist", blocklist)
+	}
+	tornClient.SetItemFilters(allowlist, blocklist)
+	tornClient.SetCrimeCategories(parseStringList(os.Getenv("CRIME_CATEGORIES")))
+	tornClient.SetTrackUnassigned(trackUnassignedEnabled())
+	tornClient.SetAPICallBudget(int64(parseIntWithDefault("MAX_API_CALLS_PER_LOOP", 0)))
+	return tornClient
+}
+
+// userStatusTTL returns USER_STATUS_TTL, a Go duration such as "30s" for which a user's
+// status is reused; names stay cached for an hour regardless
+func userStatusTTL() time.Duration {
+	value := os.Getenv("USER_STATUS_TTL")
+	if value == "" {
+		return torn.DefaultUserStatusTTL
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		slog.Warn("Invalid USER_STATUS_TTL, using default", "user_status_ttl", value, "default", torn.DefaultUserStatusTTL)
+		return torn.DefaultUserStatusTTL
+	}
+	return ttl
+}
+
+// sheetReadCacheEnabled reports whether phases in a loop share one sheet read until the
+// next write, set by SHEET_READ_CACHE
+func sheetReadCacheEnabled() bool {
+	return GetEnvWithDefault("SHEET_READ_CACHE", "true") == "true"
+}
+
+// MockModeEnabled reports whether MOCK_MODE replaces the Torn API and spreadsheet with
+// fixtures and an in-memory sheet
+func MockModeEnabled() bool {
+	return os.Getenv("MOCK_MODE") == "true"
+}
+
+// MonitorOnly reports whether MONITOR_ONLY=true: every phase runs and the status
+// endpoints fill in, but nothing is written to the sheet and no notifications are sent
+func MonitorOnly() bool {
+	return os.Getenv("MONITOR_ONLY") == "true"
+}
+
+// MockDataDir returns the fixture directory used in MOCK_MODE
+func MockDataDir() string {
+	return GetEnvWithDefault("MOCK_DATA_DIR", "test/testdata/mock")
+}
+
+// newMockTornClient creates a fixture-backed Torn client for MOCK_MODE
+func newMockTornClient(dir string) *torn.MockClient {
+	tornClient := torn.NewMockClient(dir, "MockFaction", "")
+	tornClient.SetItemFilters(
+		parseIntList("ITEM_ALLOWLIST", os.Getenv("ITEM_ALLOWLIST"), nil),
+		parseIntList("ITEM_BLOCKLIST", os.Getenv("ITEM_BLOCKLIST"), nil),
+	)
+	tornClient.SetCrimeCategories(parseStringList(os.Getenv("CRIME_CATEGORIES")))
+	tornClient.SetTrackUnassigned(trackUnassignedEnabled())
+	tornClient.SetAPICallBudget(int64(parseIntWithDefault("MAX_API_CALLS_PER_LOOP", 0)))
+	return tornClient
+}
+
+// initializeMockClients creates fixture-backed clients for MOCK_MODE. The in-memory sheet
+// is seeded from sheet.json in the fixture directory when present.
+func initializeMockClients() (torn.TornAPI, *sheets.Client) {
+	dir := MockDataDir()
+	slog.Warn("MOCK_MODE enabled; using fixtures instead of the Torn API and an in-memory sheet", "mock_data_dir", dir)
+
+	tornClient := newMockTornClient(dir)
+
+	seedFile := filepath.Join(dir, "sheet.json")
+	if _, err := os.Stat(seedFile); err != nil {
+		seedFile = ""
+	}
+	sheetsClient, err := sheets.NewMockClient(seedFile)
+	if err != nil {
+		slog.Error("Failed to create mock sheets client", "error", err)
+		os.Exit(1)
+	}
+	sheetsClient.EnableReadCache(sheetReadCacheEnabled())
+	sheetsClient.EnableEditCheck(sheets.EditCheckEnabled())
 
-	slog.Debug("Clients initialized successfully")
 	return tornClient, sheetsClient
 }
 
+// InitializeEventSink creates the sink for supplied item and provided match events from
+// EVENT_SINK ("stdout" or "webhook" with EVENT_WEBHOOK_URL); the default and MONITOR_ONLY
+// discard events
+func InitializeEventSink(userAgent string) events.EventSink {
+	if MonitorOnly() {
+		slog.Info("Event publishing disabled by MONITOR_ONLY")
+		return events.NopSink{}
+	}
+	switch sink := GetEnvWithDefault("EVENT_SINK", "none"); sink {
+	case "none":
+		return events.NopSink{}
+	case "stdout":
+		slog.Info("Publishing events to stdout")
+		return events.NewStreamSink(os.Stdout)
+	case "webhook":
+		url := GetRequiredEnv("EVENT_WEBHOOK_URL")
+		slog.Info("Publishing events to webhook")
+		return events.NewWebhookSink(url, userAgent)
+	default:
+		slog.Warn("Unknown EVENT_SINK, not publishing events", "event_sink", sink)
+		return events.NopSink{}
+	}
+}
+
 // InitializeNotificationClient creates and returns the notification client
-func InitializeNotificationClient() *notifications.Client {
+func InitializeNotificationClient(userAgent string) *notifications.Client {
 	enabled := GetEnvWithDefault("NTFY_ENABLED", "false") == "true"
+	if enabled && MonitorOnly() {
+		slog.Info("Notifications disabled by MONITOR_ONLY")
+		enabled = false
+	}
 	baseURL := GetEnvWithDefault("NTFY_URL", "https://ntfy.sh")
 	topic := GetEnvWithDefault("NTFY_TOPIC", "torn-oc-items")
 	batchMode := GetEnvWithDefault("NTFY_BATCH_MODE", "true") == "true"
@@ -79,6 +295,9 @@ func InitializeNotificationClient() *notifications.Client {
 	maxRetries := parseIntWithDefault("NTFY_MAX_RETRIES", 3)
 	baseDelayMs := parseIntWithDefault("NTFY_BASE_DELAY_MS", 1000)
 	maxDelayMs := parseIntWithDefault("NTFY_MAX_DELAY_MS", 30000)
+	timeoutMs := parseIntWithDefault("NTFY_TIMEOUT_MS", int(notifications.DefaultAttemptTimeout/time.Millisecond))
+	minItemValue := parseFloatWithDefault("NTFY_MIN_ITEM_VALUE", 0)
+	crimeComplete := GetEnvWithDefault("NTFY_CRIME_COMPLETE", "false") == "true"
 
 	baseDelay := time.Duration(baseDelayMs) * time.Millisecond
 	maxDelay := time.Duration(maxDelayMs) * time.Millisecond
@@ -92,9 +311,48 @@ func InitializeNotificationClient() *notifications.Client {
 		"max_retries", maxRetries,
 		"base_delay", baseDelay,
 		"max_delay", maxDelay,
+		"timeout_ms", timeoutMs,
+		"min_item_value", minItemValue,
+		"crime_complete", crimeComplete,
 	)
 
-	client := notifications.NewClient(baseURL, topic, enabled, batchMode, priority, maxRetries, baseDelay, maxDelay)
+	client := notifications.NewClient(baseURL, topic, enabled, batchMode, priority, maxRetries, baseDelay, maxDelay, minItemValue, crimeComplete, userAgent)
+	client.SetAttemptTimeout(time.Duration(timeoutMs) * time.Millisecond)
+	client.SetMaxIndividual(parseIntWithDefault("NTFY_MAX_INDIVIDUAL", 0))
+	client.SetGroupByCrime(GetEnvWithDefault("NTFY_GROUP_BY_CRIME", "false") == "true")
+	client.SetGroupSameItem(GetEnvWithDefault("NTFY_GROUP_SAME_ITEM", "false") == "true")
+	client.SetShowCrimeName(GetEnvWithDefault("SHOW_CRIME_NAME", "false") == "true")
+	if urgentPassRate := parseIntWithDefault("NTFY_URGENT_PASS_RATE", 0); urgentPassRate > 0 {
+		client.SetUrgentPassRate(urgentPassRate, GetEnvWithDefault("NTFY_URGENT_PRIORITY", "high"))
+	}
+	if window := os.Getenv("NTFY_BATCH_WINDOW"); window != "" {
+		if duration, err := time.ParseDuration(window); err != nil || duration < 0 {
+			slog.Warn("Invalid NTFY_BATCH_WINDOW, notifying each loop", "ntfy_batch_window", window)
+		} else if !batchMode {
+			slog.Warn("NTFY_BATCH_WINDOW only applies to batch mode, notifying each loop")
+		} else {
+			client.SetBatchWindow(duration)
+		}
+	}
+	tlsConfig, err := notifications.LoadTLSConfig(DataPath(os.Getenv("NTFY_CLIENT_CERT")), DataPath(os.Getenv("NTFY_CLIENT_KEY")), DataPath(os.Getenv("NTFY_CA_CERT")))
+	if err != nil {
+		slog.Error("Invalid ntfy TLS configuration", "error", err)
+		os.Exit(1)
+	}
+	client.SetTLSConfig(tlsConfig)
+	auditFile := DataPath(os.Getenv("NTFY_AUDIT_FILE"))
+	if err := CheckWritablePaths(map[string]string{"NTFY_AUDIT_FILE": auditFile}); err != nil {
+		slog.Error("Notification audit file unusable", "error", err)
+		os.Exit(1)
+	}
+	client.SetBreakerAlerts(auditFile, os.Getenv("NTFY_FALLBACK_TOPIC"))
+	if format, ok := currency.ParseFormat(os.Getenv("CURRENCY_FORMAT")); ok {
+		client.SetCurrencyFormat(format)
+	} else {
+		slog.Warn("Unknown CURRENCY_FORMAT, using message defaults", "currency_format", os.Getenv("CURRENCY_FORMAT"))
+	}
+	routes := notifications.ParseRoutes(os.Environ())
+	client.SetRoutes(routes)
 
 	if enabled {
 		mode := "batch"
@@ -106,6 +364,7 @@ func InitializeNotificationClient() *notifications.Client {
 			"mode", mode,
 			"priority", priority,
 			"max_retries", maxRetries,
+			"routes", len(routes),
 		)
 	} else {
 		slog.Debug("Notifications disabled")
@@ -114,6 +373,95 @@ func InitializeNotificationClient() *notifications.Client {
 	return client
 }
 
+// InitializeStatusServer creates the HTTP status server if STATUS_ADDR is set, otherwise returns nil
+func InitializeStatusServer() *status.Server {
+	addr := os.Getenv("STATUS_ADDR")
+	if addr == "" {
+		slog.Debug("Status server disabled")
+		return nil
+	}
+	return status.NewServer(addr)
+}
+
+// InitializePanicTracker creates the tracker that stops the process when the same panic
+// recurs more than PANIC_MAX_REPEATS times within PANIC_WINDOW_MIN minutes
+func InitializePanicTracker() *tracking.PanicTracker {
+	maxRepeats := parseIntWithDefault("PANIC_MAX_REPEATS", 5)
+	window := time.Duration(parseIntWithDefault("PANIC_WINDOW_MIN", 15)) * time.Minute
+	slog.Debug("Initializing panic tracker", "max_repeats", maxRepeats, "window", window)
+	return tracking.NewPanicTracker(maxRepeats, window)
+}
+
+// InitializeAppendBuffer creates the buffer that coalesces new sheet rows for
+// APPEND_COALESCE_SEC seconds, or returns nil when coalescing is disabled
+func InitializeAppendBuffer() *sheets.AppendBuffer {
+	seconds := parseIntWithDefault("APPEND_COALESCE_SEC", 0)
+	if seconds <= 0 {
+		slog.Debug("Append coalescing disabled")
+		return nil
+	}
+	window := time.Duration(seconds) * time.Second
+	slog.Info("Coalescing sheet appends", "window", window)
+	return sheets.NewAppendBuffer(window)
+}
+
+// InitializeSupplyConfirmer creates the confirmer that holds back supplied items until
+// they have been seen in SUPPLY_CONFIRM_LOOPS consecutive loops, or returns nil when one
+// sighting is enough
+func InitializeSupplyConfirmer() *processing.SupplyConfirmer {
+	loops := parseIntWithDefault("SUPPLY_CONFIRM_LOOPS", 1)
+	if loops <= 1 {
+		return nil
+	}
+	slog.Info("Confirming supplied items before adding them", "loops", loops)
+	return processing.NewSupplyConfirmer(loops)
+}
+
+// trackUnassignedEnabled reports whether TRACK_UNASSIGNED_NEEDS=true
+func trackUnassignedEnabled() bool {
+	return os.Getenv("TRACK_UNASSIGNED_NEEDS") == "true"
+}
+
+// InitializeUnassignedTracker creates the tracker announcing items needed by crime slots
+// that have no member yet, or returns nil unless TRACK_UNASSIGNED_NEEDS is set
+func InitializeUnassignedTracker() *processing.UnassignedTracker {
+	if !trackUnassignedEnabled() {
+		return nil
+	}
+	slog.Info("Tracking items needed by unassigned crime slots")
+	return processing.NewUnassignedTracker()
+}
+
+// InitializeNeedReminder creates the reminder for rows still needed after RENOTIFY_INTERVAL,
+// a Go duration such as "6h", or returns nil when it is unset or invalid
+func InitializeNeedReminder() *processing.NeedReminder {
+	value := os.Getenv("RENOTIFY_INTERVAL")
+	if value == "" {
+		return nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		slog.Warn("Invalid RENOTIFY_INTERVAL, not sending reminders", "renotify_interval", value)
+		return nil
+	}
+	slog.Info("Reminding of items still needed", "interval", interval)
+	return processing.NewNeedReminder(interval)
+}
+
+// GetRetryMode returns RETRY_MODE, falling back to infinite with a warning when it is invalid
+func GetRetryMode() config.RetryMode {
+	mode, err := config.ParseRetryMode(os.Getenv("RETRY_MODE"))
+	if err != nil {
+		slog.Warn("Invalid retry mode, retrying forever", "error", err)
+	}
+	return mode
+}
+
+// GetProviderHealthInterval returns how often provider health is logged; zero disables the report
+func GetProviderHealthInterval() time.Duration {
+	return time.Duration(parseIntWithDefault("PROVIDER_HEALTH_INTERVAL_MIN", 15)) * time.Minute
+}
+
 // parseIntWithDefault parses an environment variable as int with fallback
 func parseIntWithDefault(key string, defaultValue int) int {
 	str := os.Getenv(key)
@@ -133,3 +481,56 @@ func parseIntWithDefault(key string, defaultValue int) int {
 
 	return defaultValue
 }
+
+// parseFloatWithDefault parses an environment variable as float64 with fallback
+func parseFloatWithDefault(key string, defaultValue float64) float64 {
+	str := os.Getenv(key)
+	if str == "" {
+		return defaultValue
+	}
+
+	if val, err := strconv.ParseFloat(str, 64); err == nil {
+		return val
+	}
+
+	slog.Warn("Invalid float value, using default",
+		"key", key,
+		"value", str,
+		"default", defaultValue,
+	)
+
+	return defaultValue
+}
+
+// parseStringList splits a comma-separated list, trimming whitespace and dropping empty entries
+func parseStringList(value string) []string {
+	var values []string
+	for _, raw := range strings.Split(value, ",") {
+		if raw = strings.TrimSpace(raw); raw != "" {
+			values = append(values, raw)
+		}
+	}
+	return values
+}
+
+// parseIntList parses a comma-separated list of integers with fallback
+func parseIntList(key, value string, defaultValue []int) []int {
+	var values []int
+	for _, raw := range strings.Split(value, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		val, err := strconv.Atoi(raw)
+		if err != nil {
+			slog.Warn("Invalid integer list value, using default",
+				"key", key,
+				"value", value,
+				"default", defaultValue,
+			)
+			return defaultValue
+		}
+		values = append(values, val)
+	}
+	return values
+}
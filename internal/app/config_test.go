@@ -0,0 +1,19 @@
+package app
+
+import (
+	"testing"
+
+	"torn_oc_items/internal/events"
+)
+
+func TestInitializeEventSinkMonitorOnly(t *testing.T) {
+	t.Setenv("EVENT_SINK", "stdout")
+	if _, ok := InitializeEventSink("test").(events.NopSink); ok {
+		t.Fatal("Expected EVENT_SINK=stdout to publish events")
+	}
+
+	t.Setenv("MONITOR_ONLY", "true")
+	if _, ok := InitializeEventSink("test").(events.NopSink); !ok {
+		t.Error("Expected MONITOR_ONLY to disable event publishing")
+	}
+}
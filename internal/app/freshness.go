@@ -0,0 +1,87 @@
+package app
+
+import (
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// FreshnessMonitor tracks when the process loop last succeeded, so a loop that has stopped
+// succeeding, whether failing or wedged, is reported rather than going unnoticed. The
+// timestamp is atomic since the loop records it while a separate ticker and the status
+// server read it.
+type FreshnessMonitor struct {
+	threshold   time.Duration
+	lastSuccess atomic.Int64 // Unix nanoseconds
+	stale       atomic.Bool
+}
+
+// NewFreshnessMonitor creates a monitor counting from start, so a first loop that never
+// succeeds is reported threshold after startup
+func NewFreshnessMonitor(threshold time.Duration, start time.Time) *FreshnessMonitor {
+	m := &FreshnessMonitor{threshold: threshold}
+	m.lastSuccess.Store(start.UnixNano())
+	return m
+}
+
+// InitializeFreshnessMonitor creates the monitor from LOOP_STALE_AFTER, a Go duration
+// such as "10m", or returns nil when it is unset or invalid
+func InitializeFreshnessMonitor() *FreshnessMonitor {
+	value := os.Getenv("LOOP_STALE_AFTER")
+	if value == "" {
+		return nil
+	}
+	threshold, err := time.ParseDuration(value)
+	if err != nil || threshold <= 0 {
+		slog.Warn("Invalid LOOP_STALE_AFTER, not checking loop freshness", "loop_stale_after", value)
+		return nil
+	}
+	slog.Info("Alerting when no loop succeeds in time", "threshold", threshold)
+	return NewFreshnessMonitor(threshold, time.Now())
+}
+
+// RecordSuccess notes a successful loop finishing at t
+func (m *FreshnessMonitor) RecordSuccess(t time.Time) {
+	m.lastSuccess.Store(t.UnixNano())
+}
+
+// LastSuccess returns when the last loop succeeded, or the start time before any has
+func (m *FreshnessMonitor) LastSuccess() time.Time {
+	return time.Unix(0, m.lastSuccess.Load())
+}
+
+// Fresh reports whether a loop has succeeded within the threshold of now
+func (m *FreshnessMonitor) Fresh(now time.Time) bool {
+	return now.Sub(m.LastSuccess()) <= m.threshold
+}
+
+// Check evaluates freshness at now and reports a change since the previous check: became
+// is true for the check that first finds the loop stale, recovered for the first fresh
+// check after that. Each stale episode is reported once.
+func (m *FreshnessMonitor) Check(now time.Time) (became, recovered bool) {
+	fresh := m.Fresh(now)
+	wasStale := m.stale.Swap(!fresh)
+	return !fresh && !wasStale, fresh && wasStale
+}
+
+// Threshold returns how long the loop may go without succeeding
+func (m *FreshnessMonitor) Threshold() time.Duration {
+	return m.threshold
+}
+
+// Readiness is the /readyz response body
+type Readiness struct {
+	Ready       bool      `json:"ready"`
+	LastSuccess time.Time `json:"last_success"`
+	Threshold   string    `json:"threshold"`
+}
+
+// Readiness describes the monitor's state at now for /readyz
+func (m *FreshnessMonitor) Readiness(now time.Time) Readiness {
+	return Readiness{
+		Ready:       m.Fresh(now),
+		LastSuccess: m.LastSuccess(),
+		Threshold:   m.threshold.String(),
+	}
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreshnessMonitorReportsEachStaleEpisodeOnce(t *testing.T) {
+	start := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	m := NewFreshnessMonitor(10*time.Minute, start)
+
+	if became, recovered := m.Check(start.Add(5 * time.Minute)); became || recovered {
+		t.Errorf("Expected no change within the threshold, got became=%v recovered=%v", became, recovered)
+	}
+	if became, _ := m.Check(start.Add(11 * time.Minute)); !became {
+		t.Error("Expected the loop to become stale after the threshold")
+	}
+	if became, _ := m.Check(start.Add(12 * time.Minute)); became {
+		t.Error("Expected a stale loop to be reported only once")
+	}
+	if m.Readiness(start.Add(12 * time.Minute)).Ready {
+		t.Error("Expected a stale loop not to be ready")
+	}
+
+	m.RecordSuccess(start.Add(13 * time.Minute))
+	if _, recovered := m.Check(start.Add(14 * time.Minute)); !recovered {
+		t.Error("Expected recovery after a successful loop")
+	}
+	if became, recovered := m.Check(start.Add(15 * time.Minute)); became || recovered {
+		t.Errorf("Expected no change once recovered, got became=%v recovered=%v", became, recovered)
+	}
+	if !m.Readiness(start.Add(15 * time.Minute)).Ready {
+		t.Error("Expected a fresh loop to be ready")
+	}
+}
+
+func TestInitializeFreshnessMonitor(t *testing.T) {
+	t.Setenv("LOOP_STALE_AFTER", "")
+	if m := InitializeFreshnessMonitor(); m != nil {
+		t.Error("Expected no monitor when LOOP_STALE_AFTER is unset")
+	}
+	t.Setenv("LOOP_STALE_AFTER", "ten minutes")
+	if m := InitializeFreshnessMonitor(); m != nil {
+		t.Error("Expected no monitor for an invalid LOOP_STALE_AFTER")
+	}
+	t.Setenv("LOOP_STALE_AFTER", "10m")
+	if m := InitializeFreshnessMonitor(); m == nil || m.Threshold() != 10*time.Minute {
+		t.Errorf("Expected a 10m threshold, got %+v", m)
+	}
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"torn_oc_items/internal/currency"
+	"torn_oc_items/internal/notifications"
+	"torn_oc_items/internal/processing"
+	"torn_oc_items/internal/torn"
+)
+
+// ListNeeded prints the items the faction's crimes need right now, without reading or
+// writing the sheet or sending notifications. ITEM_ALLOWLIST and ITEM_BLOCKLIST apply as
+// they do to the sheet, and NTFY_MIN_ITEM_VALUE as it does to notifications.
+func ListNeeded(ctx context.Context, w io.Writer, tornClient torn.TornAPI) error {
+	suppliedItems, err := processing.GetSuppliedItems(ctx, tornClient)
+	if err != nil {
+		return err
+	}
+	// Nothing counts as already on the sheet, so every current need is listed
+	_, items := processing.ProcessSuppliedItems(ctx, tornClient, suppliedItems, map[string]bool{})
+
+	format, _ := currency.ParseFormat(os.Getenv("CURRENCY_FORMAT"))
+	PrintNeededItems(w, filterNeededByValue(items, parseFloatWithDefault("NTFY_MIN_ITEM_VALUE", 0)), format)
+	return nil
+}
+
+// filterNeededByValue drops items below minValue; items with no known value go too, as
+// they do from notifications
+func filterNeededByValue(items []notifications.ItemInfo, minValue float64) []notifications.ItemInfo {
+	if minValue <= 0 {
+		return items
+	}
+	var kept []notifications.ItemInfo
+	for _, item := range items {
+		if item.MarketValue >= minValue {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// PrintNeededItems writes needed items as a table of item, user, crime and market value
+func PrintNeededItems(w io.Writer, items []notifications.ItemInfo, format currency.Format) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ITEM\tUSER\tCRIME\tVALUE")
+	for _, item := range items {
+		crime := fmt.Sprintf("#%d", item.CrimeID)
+		if item.CrimeName != "" {
+			crime = fmt.Sprintf("%s #%d", item.CrimeName, item.CrimeID)
+		}
+		value := "-"
+		if item.MarketValue > 0 {
+			value = format.String(item.MarketValue)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", item.ItemName, item.UserName, crime, value)
+	}
+	_ = tw.Flush()
+	if len(items) == 1 {
+		_, _ = fmt.Fprintln(w, "1 item needed")
+	} else {
+		_, _ = fmt.Fprintf(w, "%d items needed\n", len(items))
+	}
+}
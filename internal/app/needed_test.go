@@ -0,0 +1,35 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"torn_oc_items/internal/torn"
+)
+
+func TestListNeeded(t *testing.T) {
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+
+	var out bytes.Buffer
+	if err := ListNeeded(context.Background(), &out, tornClient); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "ITEM") || lines[3] != "2 items needed" {
+		t.Fatalf("Unexpected listing:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "Binoculars Alice Mob Mentality #101 $1,200,000" {
+		t.Errorf("Unexpected row %q", lines[1])
+	}
+
+	t.Setenv("NTFY_MIN_ITEM_VALUE", "1000000")
+	out.Reset()
+	if err := ListNeeded(context.Background(), &out, tornClient); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if strings.Contains(out.String(), "Bolt Cutters") || !strings.Contains(out.String(), "1 item needed") {
+		t.Errorf("Expected items below NTFY_MIN_ITEM_VALUE to be left out:\n%s", out.String())
+	}
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// DataDir returns DATA_DIR, the directory relative file paths are resolved against. On a
+// read-only root filesystem point it at the writable volume. Defaults to the working
+// directory.
+func DataDir() string {
+	return GetEnvWithDefault("DATA_DIR", ".")
+}
+
+// DataPath resolves a configured file path: absolute paths are kept, relative ones are
+// placed under DataDir, and empty stays empty (feature disabled)
+func DataPath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(DataDir(), path)
+}
+
+// CredentialsFile returns the Google service account credentials path from
+// CREDENTIALS_FILE, resolved against DATA_DIR
+func CredentialsFile() string {
+	return DataPath(GetEnvWithDefault("CREDENTIALS_FILE", "credentials.json"))
+}
+
+// CheckWritablePaths verifies at startup that every file the process appends to can be
+// written, so a read-only mount is reported up front instead of failing mid-run. Files
+// are created if missing and never truncated.
+func CheckWritablePaths(paths map[string]string) error {
+	for setting, path := range paths {
+		if path == "" {
+			continue
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("%s %q is not writable; set DATA_DIR or %s to a writable location: %w", setting, path, setting, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("%s %q is not writable: %w", setting, path, err)
+		}
+		slog.Debug("Checked file is writable", "setting", setting, "path", path)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDataPath(t *testing.T) {
+	t.Setenv("DATA_DIR", "/data")
+	cases := map[string]string{
+		"":                  "",
+		"audit.jsonl":       "/data/audit.jsonl",
+		"logs/audit.jsonl":  "/data/logs/audit.jsonl",
+		"/var/run/pause":    "/var/run/pause",
+		"../etc/creds.json": "/etc/creds.json",
+	}
+	for path, want := range cases {
+		if got := DataPath(path); got != want {
+			t.Errorf("DataPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	t.Setenv("DATA_DIR", "")
+	if got := CredentialsFile(); got != "credentials.json" {
+		t.Errorf("Expected the working directory by default, got %q", got)
+	}
+}
+
+func TestCheckWritablePaths(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "audit.jsonl")
+	if err := os.WriteFile(existing, []byte("kept\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckWritablePaths(map[string]string{"NTFY_AUDIT_FILE": existing, "UNSET": ""}); err != nil {
+		t.Fatalf("Expected a writable file to pass, got %v", err)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "kept\n" {
+		t.Errorf("Expected the check not to truncate the file, got %q", data)
+	}
+
+	missingDir := filepath.Join(dir, "missing", "audit.jsonl")
+	if err := CheckWritablePaths(map[string]string{"NTFY_AUDIT_FILE": missingDir}); err == nil {
+		t.Error("Expected a path in a missing directory to fail")
+	}
+}
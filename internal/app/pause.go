@@ -0,0 +1,62 @@
+package app
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sync/atomic"
+)
+
+// PauseController decides whether the process loop should skip its work. Processing is
+// paused while the PAUSE_FILE exists or after a pause signal toggles it on.
+type PauseController struct {
+	pauseFile     string
+	signalPaused  atomic.Bool
+	reportedPause atomic.Bool
+}
+
+// InitializePauseController creates the pause controller from PAUSE_FILE and starts
+// listening for the toggle signal where supported
+func InitializePauseController() *PauseController {
+	pc := &PauseController{pauseFile: DataPath(os.Getenv("PAUSE_FILE"))}
+	if pc.pauseFile != "" {
+		slog.Info("Pause file configured; processing pauses while it exists", "pause_file", pc.pauseFile)
+	}
+	pc.watchSignals()
+	return pc
+}
+
+// Toggle flips the signal-driven pause state and returns the new state
+func (pc *PauseController) Toggle() bool {
+	for {
+		current := pc.signalPaused.Load()
+		if pc.signalPaused.CompareAndSwap(current, !current) {
+			return !current
+		}
+	}
+}
+
+// Paused reports whether processing should be skipped, logging on pause/resume edges
+func (pc *PauseController) Paused() bool {
+	paused := pc.signalPaused.Load() || pc.pauseFileExists()
+	if paused != pc.reportedPause.Swap(paused) {
+		if paused {
+			slog.Warn("Processing paused")
+		} else {
+			slog.Warn("Processing resumed")
+		}
+	}
+	return paused
+}
+
+func (pc *PauseController) pauseFileExists() bool {
+	if pc.pauseFile == "" {
+		return false
+	}
+	_, err := os.Stat(pc.pauseFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("Failed to check pause file", "pause_file", pc.pauseFile, "error", err)
+	}
+	return err == nil
+}
@@ -0,0 +1,6 @@
+//go:build !unix
+
+package app
+
+// watchSignals is a no-op where SIGUSR1 is unavailable; use PAUSE_FILE instead
+func (pc *PauseController) watchSignals() {}
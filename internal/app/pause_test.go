@@ -0,0 +1,39 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPauseControllerPauseFile(t *testing.T) {
+	pauseFile := filepath.Join(t.TempDir(), "pause")
+	pc := &PauseController{pauseFile: pauseFile}
+
+	if pc.Paused() {
+		t.Fatal("Expected not paused without pause file")
+	}
+	if err := os.WriteFile(pauseFile, nil, 0o600); err != nil {
+		t.Fatalf("Failed to create pause file: %v", err)
+	}
+	if !pc.Paused() {
+		t.Error("Expected paused while pause file exists")
+	}
+	if err := os.Remove(pauseFile); err != nil {
+		t.Fatalf("Failed to remove pause file: %v", err)
+	}
+	if pc.Paused() {
+		t.Error("Expected resumed after pause file removed")
+	}
+}
+
+func TestPauseControllerToggle(t *testing.T) {
+	pc := &PauseController{}
+
+	if !pc.Toggle() || !pc.Paused() {
+		t.Error("Expected first toggle to pause")
+	}
+	if pc.Toggle() || pc.Paused() {
+		t.Error("Expected second toggle to resume")
+	}
+}
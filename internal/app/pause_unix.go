@@ -0,0 +1,22 @@
+//go:build unix
+
+package app
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchSignals toggles the pause state each time SIGUSR1 is received
+func (pc *PauseController) watchSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	go func() {
+		for range signals {
+			paused := pc.Toggle()
+			slog.Warn("Received SIGUSR1, toggling pause", "paused", paused)
+		}
+	}()
+}
@@ -0,0 +1,248 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"torn_oc_items/internal/config"
+	"torn_oc_items/internal/notifications"
+	"torn_oc_items/internal/retry"
+)
+
+// redacted replaces secret values in the config dump
+const redacted = "[redacted]"
+
+// configKeys lists the settings included in the dump's environment section when set.
+// Keys starting with one of configPrefixes are included too.
+var configKeys = []string{
+	"APPEND_COALESCE_SEC", "ARMORY_LOG_TYPE", "AUDIT_SHEET_RANGE", "CLOCK_SKEW_WARN_SEC",
+	"CREDENTIALS_FILE", "CRIME_CATEGORIES", "CURRENCY_FORMAT", "DATA_DIR",
+	"DEDUPE_PROVIDER_LOGS", "DEDUPE_WINDOW", "ENV", "EVENT_SINK", "EVENT_WEBHOOK_URL",
+	"FACTION_ID", "FIXED_NOW", "ITEM_ALLOWLIST", "ITEM_BLOCKLIST", "ITEM_CACHE_TTL_MIN",
+	"ITEM_FALLBACK_FORMAT", "LOGLEVEL", "LOG_FORMAT", "LOG_SAMPLE_RATE", "LOOP_BACKOFF_AFTER",
+	"LOOP_BACKOFF_MAX_MIN", "LOOP_DEADLINE", "LOOP_STALE_AFTER", "MATCH_AFTER_ROW_ADDED", "MATCH_ARMORY", "MATCH_DIAGNOSTICS",
+	"MATCH_MESSAGE_PATTERN", "MATCH_STRATEGY", "MAX_API_CALLS_PER_LOOP",
+	"MARKET_VALUE_UNAVAILABLE", "MAX_COMBINED_LOG_ENTRIES", "MOCK_DATA_DIR", "MOCK_MODE", "MONITOR_ONLY",
+	"NON_TRADEABLE_ITEMS", "PANIC_MAX_REPEATS", "PANIC_WINDOW_MIN", "PAUSE_FILE",
+	"PROVIDER_HEALTH_INTERVAL_MIN", "PROVIDER_KEYS", "PROVIDER_LABELS",
+	"PROVIDED_INTERVAL", "PROVIDER_MATCH_MAX_AGE", "PROVIDER_RESOLVE_ATTEMPTS", "RARE_ITEM_CIRCULATION",
+	"RECENT_EVENTS", "RELAY_MATCH_WINDOW", "RELAY_PLAYER_ID", "RENOTIFY_INTERVAL", "RERESOLVE_FALLBACKS", "RESOLVED_STATUS",
+	"RESOLVE_AVAILABLE_ITEMS", "RETRY_MODE", "SELFCHECK_CELL", "SHEET_EDIT_CHECK", "SHEET_RATE_LIMIT_BACKOFF", "SHEET_ROW_WRITES",
+	"SHEET_INSERT", "SHEET_READ_CACHE", "SHOW_CRIME_NAME", "SKIP_MARKET_VALUE", "SPREADSHEET_GID",
+	"SPREADSHEET_ID", "SPREADSHEET_RANGE", "STARTUP_SPLAY", "STATUS_ADDR",
+	"SUPPLIED_INTERVAL", "SUPPLY_CONFIRM_LOOPS", "TORN_API_KEY", "TORN_BODY_PREVIEW_CHARS", "TORN_EXTRA_HEADERS", "TORN_FACTION_API_KEY",
+	"TORN_RETRY_STATUS_CODES", "TRACK_UNASSIGNED_NEEDS", "USER_AGENT_CONTACT",
+	"USER_FALLBACK_FORMAT", "USER_STATUS_TTL",
+}
+
+var configPrefixes = []string{
+	"NTFY_", notifications.RouteEnvPrefix,
+	"PROCESS_LOOP_", "TORN_API_", "SHEET_READ_", "STATE_TRACKING_",
+}
+
+// secretKeys hold API keys and ntfy topics, which anyone can subscribe to by name, and
+// are never printed
+var secretKeys = []string{"TORN_API_KEY", "TORN_FACTION_API_KEY", "PROVIDER_KEYS", "NTFY_TOPIC", "NTFY_FALLBACK_TOPIC"}
+
+// urlKeys may carry credentials in their user info or query string
+var urlKeys = []string{"NTFY_URL", "EVENT_WEBHOOK_URL"}
+
+// effectiveConfig is the resolved configuration printed by --print-config
+type effectiveConfig struct {
+	Version       string               `json:"version"`
+	Sheet         sheetSettings        `json:"sheet"`
+	Providers     providerSettings     `json:"providers"`
+	Notifications notificationSettings `json:"notifications"`
+	Resilience    resilienceSettings   `json:"resilience"`
+	RetryMode     config.RetryMode     `json:"retry_mode"`
+	Environment   map[string]string    `json:"environment"`
+}
+
+type sheetSettings struct {
+	SpreadsheetID   string `json:"spreadsheet_id"`
+	Range           string `json:"range"`
+	GID             string `json:"gid,omitempty"`
+	CredentialsFile string `json:"credentials_file"`
+	MonitorOnly     bool   `json:"monitor_only"`
+	MockMode        bool   `json:"mock_mode"`
+}
+
+type providerSettings struct {
+	Count  int    `json:"count"`
+	Labels int    `json:"labels"`
+	MaxAge string `json:"match_max_age,omitempty"`
+}
+
+type notificationSettings struct {
+	Enabled       bool              `json:"enabled"`
+	URL           string            `json:"url"`
+	Topic         string            `json:"topic"`
+	BatchMode     bool              `json:"batch_mode"`
+	Priority      string            `json:"priority"`
+	MaxIndividual int               `json:"max_individual"`
+	MinItemValue  float64           `json:"min_item_value"`
+	CrimeComplete bool              `json:"crime_complete"`
+	Retry         retrySettings     `json:"retry"`
+	Routes        map[string]string `json:"routes,omitempty"`
+}
+
+type resilienceSettings struct {
+	ProcessLoop          retrySettings `json:"process_loop"`
+	APIRequest           retrySettings `json:"torn_api"`
+	SheetRead            retrySettings `json:"sheet_read"`
+	StateTracking        retrySettings `json:"state_tracking"`
+	RetryableStatusCodes []int         `json:"retryable_status_codes"`
+}
+
+// retrySettings is a retry.Config with durations written as Go duration strings
+type retrySettings struct {
+	MaxRetries int    `json:"max_retries"`
+	BaseDelay  string `json:"base_delay"`
+	MaxDelay   string `json:"max_delay"`
+	Timeout    string `json:"timeout"`
+}
+
+func newRetrySettings(cfg retry.Config) retrySettings {
+	return retrySettings{
+		MaxRetries: cfg.MaxRetries,
+		BaseDelay:  cfg.BaseDelay.String(),
+		MaxDelay:   cfg.MaxDelay.String(),
+		Timeout:    cfg.Timeout.String(),
+	}
+}
+
+// buildEffectiveConfig resolves the configuration from the environment, with API keys and
+// URL credentials redacted
+func buildEffectiveConfig(version string) effectiveConfig {
+	resilience := config.DefaultResilienceConfig
+	// InitializeClients applies the status codes, which hasn't run yet
+	if codes := os.Getenv("TORN_RETRY_STATUS_CODES"); codes != "" {
+		resilience.RetryableStatusCodes = parseIntList("TORN_RETRY_STATUS_CODES", codes, resilience.RetryableStatusCodes)
+	}
+	return effectiveConfig{
+		Version: version,
+		Sheet: sheetSettings{
+			SpreadsheetID:   os.Getenv("SPREADSHEET_ID"),
+			Range:           GetEnvWithDefault("SPREADSHEET_RANGE", "Test Sheet!A1"),
+			GID:             os.Getenv("SPREADSHEET_GID"),
+			CredentialsFile: CredentialsFile(),
+			MonitorOnly:     MonitorOnly(),
+			MockMode:        MockModeEnabled(),
+		},
+		Providers: providerSettings{
+			Count:  len(parseStringList(os.Getenv("PROVIDER_KEYS"))),
+			Labels: len(parseStringList(os.Getenv("PROVIDER_LABELS"))),
+			MaxAge: os.Getenv("PROVIDER_MATCH_MAX_AGE"),
+		},
+		Notifications: notificationSettings{
+			Enabled:       GetEnvWithDefault("NTFY_ENABLED", "false") == "true" && !MonitorOnly(),
+			URL:           redactURL(GetEnvWithDefault("NTFY_URL", "https://ntfy.sh")),
+			Topic:         redacted,
+			BatchMode:     GetEnvWithDefault("NTFY_BATCH_MODE", "true") == "true",
+			Priority:      GetEnvWithDefault("NTFY_PRIORITY", "default"),
+			MaxIndividual: parseIntWithDefault("NTFY_MAX_INDIVIDUAL", 0),
+			MinItemValue:  parseFloatWithDefault("NTFY_MIN_ITEM_VALUE", 0),
+			CrimeComplete: GetEnvWithDefault("NTFY_CRIME_COMPLETE", "false") == "true",
+			Retry: newRetrySettings(retry.Config{
+				MaxRetries: parseIntWithDefault("NTFY_MAX_RETRIES", 3),
+				BaseDelay:  time.Duration(parseIntWithDefault("NTFY_BASE_DELAY_MS", 1000)) * time.Millisecond,
+				MaxDelay:   time.Duration(parseIntWithDefault("NTFY_MAX_DELAY_MS", 30000)) * time.Millisecond,
+				Timeout:    time.Duration(parseIntWithDefault("NTFY_TIMEOUT_MS", int(notifications.DefaultAttemptTimeout/time.Millisecond))) * time.Millisecond,
+			}),
+			Routes: redactRoutes(notifications.ParseRoutes(os.Environ())),
+		},
+		Resilience: resilienceSettings{
+			ProcessLoop:          newRetrySettings(resilience.ProcessLoop),
+			APIRequest:           newRetrySettings(resilience.APIRequest),
+			SheetRead:            newRetrySettings(resilience.SheetRead),
+			StateTracking:        newRetrySettings(resilience.StateTracking),
+			RetryableStatusCodes: resilience.RetryableStatusCodes,
+		},
+		RetryMode:   GetRetryMode(),
+		Environment: configEnvironment(os.Environ()),
+	}
+}
+
+// PrintConfig writes the effective configuration to w as indented JSON, for operators to
+// attach to support requests. It must run after SetupEnvironment has loaded .env and the
+// retry settings. It reads nothing remote, so it works without credentials or network.
+func PrintConfig(w io.Writer, version string) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(buildEffectiveConfig(version))
+}
+
+// configEnvironment returns the recognized settings that are set in environ, in
+// os.Environ form, with secrets redacted
+func configEnvironment(environ []string) map[string]string {
+	settings := make(map[string]string)
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || value == "" || !isConfigKey(key) {
+			continue
+		}
+		switch {
+		case slices.Contains(secretKeys, key), strings.HasPrefix(key, notifications.RouteEnvPrefix):
+			value = redacted
+		case slices.Contains(urlKeys, key):
+			value = redactURL(value)
+		case key == "TORN_EXTRA_HEADERS":
+			value = redactHeaderValues(value)
+		}
+		settings[key] = value
+	}
+	return settings
+}
+
+// redactRoutes keeps the routed item types but hides their topics
+func redactRoutes(routes map[string]string) map[string]string {
+	for itemType := range routes {
+		routes[itemType] = redacted
+	}
+	return routes
+}
+
+func isConfigKey(key string) bool {
+	if slices.Contains(configKeys, key) {
+		return true
+	}
+	for _, prefix := range configPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL hides the password and query values of a URL as url.URL.Redacted does, or
+// all of it when it doesn't parse
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query.Set(key, "xxxxx")
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
+}
+
+// redactHeaderValues keeps the names in a TORN_EXTRA_HEADERS list but hides the values,
+// which may be gateway credentials
+func redactHeaderValues(value string) string {
+	var entries []string
+	for _, entry := range parseStringList(value) {
+		name, _, _ := strings.Cut(entry, ":")
+		entries = append(entries, strings.TrimSpace(name)+":"+redacted)
+	}
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,180 @@
+package app
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"time"
+)
+
+// LoopScheduler widens the effective process loop interval after repeated failed loops
+// so a sustained outage doesn't produce the same errors every tick. Ticks are skipped
+// rather than the ticker being changed, and the normal cadence returns after a success.
+type LoopScheduler struct {
+	interval  time.Duration
+	threshold int
+	maxTicks  int
+	failures  int
+	skipTicks int
+}
+
+// NewLoopScheduler creates a scheduler for a loop running every interval. After threshold
+// consecutive failures the interval doubles per further failure, up to maxInterval.
+// A threshold of zero disables backoff.
+func NewLoopScheduler(interval time.Duration, threshold int, maxInterval time.Duration) *LoopScheduler {
+	maxTicks := int(maxInterval / interval)
+	if maxTicks < 1 {
+		maxTicks = 1
+	}
+	return &LoopScheduler{interval: interval, threshold: threshold, maxTicks: maxTicks}
+}
+
+// InitializeLoopScheduler creates the loop scheduler from LOOP_BACKOFF_AFTER and
+// LOOP_BACKOFF_MAX_MIN
+func InitializeLoopScheduler(interval time.Duration) *LoopScheduler {
+	threshold := parseIntWithDefault("LOOP_BACKOFF_AFTER", 3)
+	maxInterval := time.Duration(parseIntWithDefault("LOOP_BACKOFF_MAX_MIN", 15)) * time.Minute
+	slog.Debug("Initializing loop scheduler", "interval", interval, "backoff_after", threshold, "max_interval", maxInterval)
+	return NewLoopScheduler(interval, threshold, maxInterval)
+}
+
+// Due reports whether the loop should run on this tick
+func (s *LoopScheduler) Due() bool {
+	if s.skipTicks > 0 {
+		s.skipTicks--
+		return false
+	}
+	return true
+}
+
+// RecordResult updates the schedule with the outcome of a loop; err is nil on success
+func (s *LoopScheduler) RecordResult(err error) {
+	if err == nil {
+		if s.threshold > 0 && s.failures >= s.threshold {
+			slog.Info("Process loop succeeded, restoring normal interval", "interval", s.interval, "failed_loops", s.failures)
+		}
+		s.failures = 0
+		s.skipTicks = 0
+		return
+	}
+
+	s.failures++
+	if s.threshold <= 0 || s.failures < s.threshold {
+		return
+	}
+
+	ticks := s.maxTicks
+	if shift := s.failures - s.threshold + 1; shift < 31 && 1<<shift < ticks {
+		ticks = 1 << shift
+	}
+	s.skipTicks = ticks - 1
+	slog.Warn("Process loop keeps failing, widening interval",
+		"consecutive_failures", s.failures,
+		"interval", time.Duration(ticks)*s.interval,
+		"error", err,
+	)
+}
+
+// Phases says which of the optional phases a process loop runs
+type Phases struct {
+	Supplied bool
+	Provided bool
+}
+
+// PhaseSchedule runs the supplied and provided phases on their own cadences, each a whole
+// number of loops, so the slower-changing crime needs can be polled less often than
+// provider sends. The first loop runs both.
+type PhaseSchedule struct {
+	suppliedEvery int
+	providedEvery int
+	loop          int
+}
+
+// NewPhaseSchedule creates a schedule running the supplied phase every suppliedEvery loops
+// and the provided phase every providedEvery loops
+func NewPhaseSchedule(suppliedEvery, providedEvery int) *PhaseSchedule {
+	return &PhaseSchedule{suppliedEvery: max(suppliedEvery, 1), providedEvery: max(providedEvery, 1)}
+}
+
+// InitializePhaseSchedule creates the phase schedule from SUPPLIED_INTERVAL and
+// PROVIDED_INTERVAL, rounded to whole loops of loopInterval
+func InitializePhaseSchedule(loopInterval time.Duration) *PhaseSchedule {
+	suppliedEvery := phaseLoops("SUPPLIED_INTERVAL", loopInterval)
+	providedEvery := phaseLoops("PROVIDED_INTERVAL", loopInterval)
+	if suppliedEvery > 1 || providedEvery > 1 {
+		slog.Info("Running phases on their own cadences",
+			"supplied_interval", time.Duration(suppliedEvery)*loopInterval,
+			"provided_interval", time.Duration(providedEvery)*loopInterval,
+		)
+	}
+	return NewPhaseSchedule(suppliedEvery, providedEvery)
+}
+
+// phaseLoops reads a phase interval as a Go duration such as "5m" and returns it in whole
+// loops, at least one. Unset or invalid values run the phase every loop.
+func phaseLoops(key string, loopInterval time.Duration) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return 1
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		slog.Warn("Invalid phase interval, running the phase every loop", "key", key, "value", value, "loop_interval", loopInterval)
+		return 1
+	}
+	loops := max(int((interval+loopInterval/2)/loopInterval), 1)
+	if time.Duration(loops)*loopInterval != interval {
+		slog.Warn("Phase interval is not a whole number of loops, rounding", "key", key, "value", value, "interval", time.Duration(loops)*loopInterval)
+	}
+	return loops
+}
+
+// Next returns the phases due in the loop about to run and advances the schedule
+func (s *PhaseSchedule) Next() Phases {
+	due := Phases{
+		Supplied: s.loop%s.suppliedEvery == 0,
+		Provided: s.loop%s.providedEvery == 0,
+	}
+	s.loop++
+	return due
+}
+
+// GetStartupSplay returns STARTUP_SPLAY, the longest random delay before the first loop,
+// as a Go duration such as "45s". Instances started together then tick at different
+// offsets within the minute instead of hitting the Torn API at once.
+func GetStartupSplay() time.Duration {
+	value := os.Getenv("STARTUP_SPLAY")
+	if value == "" {
+		return 0
+	}
+	splay, err := time.ParseDuration(value)
+	if err != nil || splay < 0 {
+		slog.Warn("Invalid STARTUP_SPLAY, starting without a delay", "startup_splay", value)
+		return 0
+	}
+	return splay
+}
+
+// GetLoopDeadline returns LOOP_DEADLINE, the longest one process loop may run including
+// its retries, as a Go duration such as "5m". A loop still running then is abandoned so
+// the next tick starts fresh. Zero means no deadline.
+func GetLoopDeadline() time.Duration {
+	value := os.Getenv("LOOP_DEADLINE")
+	if value == "" {
+		return 0
+	}
+	deadline, err := time.ParseDuration(value)
+	if err != nil || deadline < 0 {
+		slog.Warn("Invalid LOOP_DEADLINE, running loops without a deadline", "loop_deadline", value)
+		return 0
+	}
+	return deadline
+}
+
+// RandomSplay picks a delay in [0, maxSplay)
+func RandomSplay(maxSplay time.Duration) time.Duration {
+	if maxSplay <= 0 {
+		return 0
+	}
+	return rand.N(maxSplay)
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// runTicks counts how many of the next n ticks the scheduler runs the loop on
+func runTicks(s *LoopScheduler, n int) int {
+	runs := 0
+	for i := 0; i < n; i++ {
+		if s.Due() {
+			runs++
+		}
+	}
+	return runs
+}
+
+func TestLoopSchedulerBacksOffAfterThreshold(t *testing.T) {
+	s := NewLoopScheduler(time.Minute, 2, 4*time.Minute)
+	failure := errors.New("sheet unreachable")
+
+	s.RecordResult(failure)
+	if !s.Due() {
+		t.Fatal("Expected loop to run on the next tick below the threshold")
+	}
+
+	s.RecordResult(failure) // threshold reached: every 2nd tick
+	if runs := runTicks(s, 2); runs != 1 {
+		t.Errorf("Expected 1 run in 2 ticks, got %d", runs)
+	}
+
+	s.RecordResult(failure) // every 4th tick
+	if runs := runTicks(s, 4); runs != 1 {
+		t.Errorf("Expected 1 run in 4 ticks, got %d", runs)
+	}
+
+	s.RecordResult(failure) // capped at every 4th tick
+	if runs := runTicks(s, 4); runs != 1 {
+		t.Errorf("Expected interval capped at 4 ticks, got %d runs", runs)
+	}
+
+	s.RecordResult(nil)
+	if runs := runTicks(s, 3); runs != 3 {
+		t.Errorf("Expected normal cadence after success, got %d runs in 3 ticks", runs)
+	}
+}
+
+func TestLoopSchedulerDisabled(t *testing.T) {
+	s := NewLoopScheduler(time.Minute, 0, 15*time.Minute)
+	for i := 0; i < 10; i++ {
+		s.RecordResult(errors.New("failure"))
+	}
+	if runs := runTicks(s, 5); runs != 5 {
+		t.Errorf("Expected every tick to run with backoff disabled, got %d", runs)
+	}
+}
+
+func TestGetStartupSplay(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":    0,
+		"45s": 45 * time.Second,
+		"2m":  2 * time.Minute,
+		"-5s": 0,
+		"45":  0,
+	}
+	for value, want := range cases {
+		t.Setenv("STARTUP_SPLAY", value)
+		if got := GetStartupSplay(); got != want {
+			t.Errorf("STARTUP_SPLAY=%q: got %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestGetLoopDeadline(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":    0,
+		"5m":  5 * time.Minute,
+		"90s": 90 * time.Second,
+		"-1m": 0,
+		"300": 0,
+	}
+	for value, want := range cases {
+		t.Setenv("LOOP_DEADLINE", value)
+		if got := GetLoopDeadline(); got != want {
+			t.Errorf("LOOP_DEADLINE=%q: got %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestPhaseScheduleCadences(t *testing.T) {
+	schedule := NewPhaseSchedule(3, 1)
+
+	var supplied []int
+	for loop := 0; loop < 7; loop++ {
+		phases := schedule.Next()
+		if !phases.Provided {
+			t.Errorf("Loop %d: expected the provided phase every loop", loop)
+		}
+		if phases.Supplied {
+			supplied = append(supplied, loop)
+		}
+	}
+	if len(supplied) != 3 || supplied[0] != 0 || supplied[1] != 3 || supplied[2] != 6 {
+		t.Errorf("Expected the supplied phase on loops 0, 3 and 6, got %v", supplied)
+	}
+}
+
+func TestPhaseLoops(t *testing.T) {
+	cases := map[string]int{
+		"":    1,
+		"1m":  1,
+		"5m":  5,
+		"90s": 2,
+		"10s": 1,
+		"-5m": 1,
+		"5":   1,
+	}
+	for value, want := range cases {
+		t.Setenv("SUPPLIED_INTERVAL", value)
+		if got := phaseLoops("SUPPLIED_INTERVAL", time.Minute); got != want {
+			t.Errorf("SUPPLIED_INTERVAL=%q: got %d loops, want %d", value, got, want)
+		}
+	}
+}
+
+func TestRandomSplayStaysInRange(t *testing.T) {
+	if got := RandomSplay(0); got != 0 {
+		t.Errorf("Expected no splay when disabled, got %v", got)
+	}
+	for i := 0; i < 100; i++ {
+		if got := RandomSplay(time.Second); got < 0 || got >= time.Second {
+			t.Fatalf("Splay %v outside [0, 1s)", got)
+		}
+	}
+}
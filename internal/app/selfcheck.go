@@ -0,0 +1,178 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"torn_oc_items/internal/notifications"
+	"torn_oc_items/internal/providers"
+	"torn_oc_items/internal/sheets"
+	"torn_oc_items/internal/torn"
+)
+
+// Self-check outcomes
+const (
+	CheckPass = "PASS"
+	CheckWarn = "WARN"
+	CheckFail = "FAIL"
+	CheckSkip = "SKIP"
+)
+
+// CheckResult is the outcome of one startup self-check
+type CheckResult struct {
+	Name     string
+	Critical bool
+	Status   string
+	Detail   string
+}
+
+// RunSelfCheck verifies the configured keys, sheet and notification endpoint so common
+// misconfigurations are reported at startup rather than as silent no-op loops
+func RunSelfCheck(ctx context.Context, tornClient torn.TornAPI, sheetsClient *sheets.Client, notificationClient *notifications.Client, providerList []providers.Provider) []CheckResult {
+	results := []CheckResult{
+		checkTornKey(ctx, tornClient),
+		checkFactionCrimesAccess(ctx, tornClient),
+		checkSheetWritable(ctx, sheetsClient),
+		checkNotifications(ctx, notificationClient),
+		checkProviderKeysResolved(providerList),
+	}
+	for _, p := range providerList {
+		results = append(results, checkProviderLogs(ctx, p))
+	}
+	return results
+}
+
+func checkTornKey(ctx context.Context, tornClient torn.TornAPI) CheckResult {
+	result := CheckResult{Name: "Torn API key", Critical: true}
+	name, err := tornClient.WhoAmI(ctx)
+	if err != nil {
+		result.Status, result.Detail = CheckFail, err.Error()
+		return result
+	}
+	result.Status, result.Detail = CheckPass, "key belongs to "+name
+	return result
+}
+
+// checkFactionCrimesAccess fails only when the faction key's access level is too low to
+// read crimes; other errors are warnings so a transient blip doesn't stop startup
+func checkFactionCrimesAccess(ctx context.Context, tornClient torn.TornAPI) CheckResult {
+	result := CheckResult{Name: "Faction key reads crimes", Critical: true}
+	_, err := tornClient.GetFactionCrimes(ctx, "planning", 0)
+	if err == nil {
+		result.Status = CheckPass
+		return result
+	}
+
+	var tornErr *torn.TornError
+	if errors.As(err, &tornErr) && tornErr.AccessDenied() {
+		result.Status = CheckFail
+		result.Detail = fmt.Sprintf("TORN_FACTION_API_KEY can't read faction crimes (%s); create a key with Limited access or higher from a member with faction API access", tornErr.Message)
+		return result
+	}
+	result.Status, result.Detail = CheckWarn, err.Error()
+	return result
+}
+
+func checkSheetWritable(ctx context.Context, sheetsClient *sheets.Client) CheckResult {
+	result := CheckResult{Name: "Sheet readable and writable", Critical: true}
+	if sheetsClient.ReadOnly() {
+		result.Name = "Sheet readable"
+		if _, err := sheets.ReadExistingSheetData(ctx, sheetsClient); err != nil {
+			result.Status, result.Detail = CheckFail, err.Error()
+			return result
+		}
+		result.Status, result.Detail = CheckPass, "monitor-only, writes not checked"
+		return result
+	}
+	cell := GetEnvWithDefault("SELFCHECK_CELL", "Z1")
+	if err := sheets.CheckWritable(ctx, sheetsClient, cell); err != nil {
+		result.Status, result.Detail = CheckFail, err.Error()
+		if errors.Is(err, sheets.ErrPermission) {
+			result.Detail += "; share the spreadsheet with the service account in " + CredentialsFile() + " as an editor"
+		}
+		return result
+	}
+	result.Status = CheckPass
+	return result
+}
+
+func checkNotifications(ctx context.Context, notificationClient *notifications.Client) CheckResult {
+	result := CheckResult{Name: "Notification endpoint reachable"}
+	if !notificationClient.Enabled() {
+		result.Status, result.Detail = CheckSkip, "notifications disabled"
+		return result
+	}
+	if err := notificationClient.Probe(ctx); err != nil {
+		result.Status, result.Detail = CheckFail, err.Error()
+		return result
+	}
+	result.Status = CheckPass
+	return result
+}
+
+// checkProviderKeysResolved compares the keys in PROVIDER_KEYS against the providers that
+// loaded, since keys that fail to resolve are skipped at load time
+func checkProviderKeysResolved(providerList []providers.Provider) CheckResult {
+	result := CheckResult{Name: "Provider keys resolve"}
+	if MockModeEnabled() {
+		result.Status, result.Detail = CheckSkip, "mock mode"
+		return result
+	}
+
+	configured := 0
+	for _, key := range strings.Split(os.Getenv("PROVIDER_KEYS"), ",") {
+		if strings.TrimSpace(key) != "" {
+			configured++
+		}
+	}
+
+	result.Detail = fmt.Sprintf("%d of %d keys resolved", len(providerList), configured)
+	switch {
+	case configured == 0:
+		result.Status, result.Detail = CheckFail, "PROVIDER_KEYS is empty"
+	case len(providerList) < configured:
+		result.Status = CheckFail
+	default:
+		result.Status = CheckPass
+	}
+	return result
+}
+
+func checkProviderLogs(ctx context.Context, p providers.Provider) CheckResult {
+	result := CheckResult{Name: "Provider " + p.Name + " reads logs"}
+	if _, err := p.Client.GetItemSendLogs(ctx); err != nil {
+		result.Status, result.Detail = CheckFail, err.Error()
+		return result
+	}
+	result.Status = CheckPass
+	return result
+}
+
+// SelfCheckFailed reports whether any critical check failed
+func SelfCheckFailed(results []CheckResult) bool {
+	for _, r := range results {
+		if r.Critical && r.Status == CheckFail {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintSelfCheckReport writes the results as an aligned pass/fail table
+func PrintSelfCheckReport(w io.Writer, results []CheckResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CHECK\tRESULT\tCRITICAL\tDETAIL")
+	for _, r := range results {
+		critical := ""
+		if r.Critical {
+			critical = "yes"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Name, r.Status, critical, r.Detail)
+	}
+	_ = tw.Flush()
+}
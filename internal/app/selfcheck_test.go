@@ -0,0 +1,72 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"torn_oc_items/internal/sheets"
+)
+
+func TestSelfCheckFailedOnlyForCriticalChecks(t *testing.T) {
+	results := []CheckResult{
+		{Name: "Torn API key", Critical: true, Status: CheckPass},
+		{Name: "Notification endpoint reachable", Status: CheckFail},
+	}
+	if SelfCheckFailed(results) {
+		t.Error("Expected a non-critical failure not to fail the self-check")
+	}
+
+	results = append(results, CheckResult{Name: "Sheet readable and writable", Critical: true, Status: CheckFail})
+	if !SelfCheckFailed(results) {
+		t.Error("Expected a critical failure to fail the self-check")
+	}
+}
+
+func TestCheckSheetWritableClearsScratchCell(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "mock")
+	sheetsClient, err := sheets.NewMockClient("")
+	if err != nil {
+		t.Fatalf("Failed to create mock sheet: %v", err)
+	}
+
+	result := checkSheetWritable(context.Background(), sheetsClient)
+	if result.Status != CheckPass {
+		t.Fatalf("Expected sheet check to pass, got %+v", result)
+	}
+
+	values, err := sheetsClient.ReadSheet(context.Background(), "mock", "Test Sheet!Z1")
+	if err != nil {
+		t.Fatalf("Failed to read scratch cell: %v", err)
+	}
+	if len(values) != 1 || len(values[0]) != 1 || values[0][0] != "" {
+		t.Errorf("Expected scratch cell to be cleared, got %v", values)
+	}
+}
+
+func TestPrintSelfCheckReport(t *testing.T) {
+	var buf bytes.Buffer
+	PrintSelfCheckReport(&buf, []CheckResult{
+		{Name: "Torn API key", Critical: true, Status: CheckPass, Detail: "key belongs to Alice"},
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], "PASS") || !strings.Contains(lines[1], "key belongs to Alice") {
+		t.Errorf("Unexpected report:\n%s", buf.String())
+	}
+}
+
+func TestCheckSheetWritableReadOnly(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "mock")
+	sheetsClient, err := sheets.NewMockClient("")
+	if err != nil {
+		t.Fatalf("Failed to create mock sheet: %v", err)
+	}
+	sheetsClient.SetReadOnly(true)
+
+	result := checkSheetWritable(context.Background(), sheetsClient)
+	if result.Status != CheckPass || result.Name != "Sheet readable" {
+		t.Errorf("Expected only the read to be checked, got %+v", result)
+	}
+}
@@ -0,0 +1,45 @@
+package clock
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// fixed holds the time set by FIXED_NOW, nil for the real clock
+var fixed atomic.Pointer[time.Time]
+
+// Now returns the current time, or the fixed time while one is set
+func Now() time.Time {
+	if t := fixed.Load(); t != nil {
+		return *t
+	}
+	return time.Now()
+}
+
+// Location returns the zone times are written in: the fixed time's zone while one is
+// set, so sheet timestamps don't depend on the host's TZ, otherwise local time
+func Location() *time.Location {
+	if t := fixed.Load(); t != nil {
+		return t.Location()
+	}
+	return time.Local
+}
+
+// SetFixed makes Now return t from then on, for reproducible test runs; a zero t
+// restores the real clock
+func SetFixed(t time.Time) {
+	if t.IsZero() {
+		fixed.Store(nil)
+		return
+	}
+	fixed.Store(&t)
+}
+
+// ParseFixed parses FIXED_NOW, an RFC3339 time such as "2025-03-14T15:09:26Z"; empty
+// means the real clock
+func ParseFixed(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,28 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetFixed(t *testing.T) {
+	defer SetFixed(time.Time{})
+
+	fixedNow, err := ParseFixed("2025-03-14T15:09:26+02:00")
+	if err != nil {
+		t.Fatalf("Expected a valid time, got %v", err)
+	}
+	SetFixed(fixedNow)
+	if !Now().Equal(fixedNow) || Location() != fixedNow.Location() {
+		t.Errorf("Expected the fixed time %v, got %v in %v", fixedNow, Now(), Location())
+	}
+
+	SetFixed(time.Time{})
+	if Location() != time.Local || time.Since(Now()) > time.Minute {
+		t.Errorf("Expected the real clock after clearing, got %v", Now())
+	}
+
+	if _, err := ParseFixed("yesterday"); err == nil {
+		t.Error("Expected an invalid time to be rejected")
+	}
+}
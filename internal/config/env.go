@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"torn_oc_items/internal/retry"
+)
+
+// LoadResilienceFromEnv overrides each retry config in DefaultResilienceConfig from the
+// environment, using these prefixes with _MAX_RETRIES, _BASE_DELAY, _MAX_DELAY and
+// _TIMEOUT:
+//
+//   - PROCESS_LOOP: the main loop
+//   - TORN_API: Torn API requests
+//   - SHEET_READ: sheet reads and writes
+//   - STATE_TRACKING: crime state transition tracking
+//
+// Delays and timeouts are Go durations such as "1s". Unset or invalid values keep the
+// current setting; invalid ones are reported in the returned error. It must run once at
+// startup, before the clients are created, since they copy the settings.
+func LoadResilienceFromEnv() error {
+	sections := []struct {
+		prefix string
+		cfg    *retry.Config
+	}{
+		{"PROCESS_LOOP", &DefaultResilienceConfig.ProcessLoop},
+		{"TORN_API", &DefaultResilienceConfig.APIRequest},
+		{"SHEET_READ", &DefaultResilienceConfig.SheetRead},
+		{"STATE_TRACKING", &DefaultResilienceConfig.StateTracking},
+	}
+
+	var errs []error
+	for _, s := range sections {
+		cfg, err := retryFromEnv(s.prefix, *s.cfg)
+		*s.cfg = cfg
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// retryFromEnv returns base with each field overridden by <prefix>_MAX_RETRIES,
+// <prefix>_BASE_DELAY, <prefix>_MAX_DELAY and <prefix>_TIMEOUT when set and valid
+func retryFromEnv(prefix string, base retry.Config) (retry.Config, error) {
+	var errs []error
+	cfg := base
+
+	if value := os.Getenv(prefix + "_MAX_RETRIES"); value != "" {
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			errs = append(errs, fmt.Errorf("%s_MAX_RETRIES %q is not a non-negative integer", prefix, value))
+		} else {
+			cfg.MaxRetries = n
+		}
+	}
+
+	durations := []struct {
+		suffix string
+		field  *time.Duration
+	}{
+		{"_BASE_DELAY", &cfg.BaseDelay},
+		{"_MAX_DELAY", &cfg.MaxDelay},
+		{"_TIMEOUT", &cfg.Timeout},
+	}
+	for _, d := range durations {
+		value := os.Getenv(prefix + d.suffix)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			errs = append(errs, fmt.Errorf("%s%s %q is not a positive duration", prefix, d.suffix, value))
+			continue
+		}
+		*d.field = parsed
+	}
+
+	return cfg, errors.Join(errs...)
+}
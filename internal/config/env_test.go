@@ -0,0 +1,64 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"torn_oc_items/internal/retry"
+)
+
+func TestRetryFromEnv(t *testing.T) {
+	base := retry.Config{MaxRetries: 5, BaseDelay: time.Second, MaxDelay: 30 * time.Second, Timeout: 15 * time.Second}
+	t.Setenv("TORN_API_MAX_RETRIES", "2")
+	t.Setenv("TORN_API_BASE_DELAY", "250ms")
+	t.Setenv("TORN_API_TIMEOUT", "soon")
+
+	cfg, err := retryFromEnv("TORN_API", base)
+	if err == nil {
+		t.Error("Expected the invalid timeout to be reported")
+	}
+	want := retry.Config{MaxRetries: 2, BaseDelay: 250 * time.Millisecond, MaxDelay: 30 * time.Second, Timeout: 15 * time.Second}
+	if cfg != want {
+		t.Errorf("Expected %+v, got %+v", want, cfg)
+	}
+}
+
+func TestLoadResilienceFromEnv(t *testing.T) {
+	saved := DefaultResilienceConfig
+	defer func() { DefaultResilienceConfig = saved }()
+
+	t.Setenv("PROCESS_LOOP_TIMEOUT", "90s")
+	t.Setenv("SHEET_READ_MAX_RETRIES", "-1")
+	t.Setenv("STATE_TRACKING_MAX_DELAY", "0s")
+
+	if err := LoadResilienceFromEnv(); err == nil {
+		t.Error("Expected the negative retries and zero delay to be reported")
+	}
+	if DefaultResilienceConfig.ProcessLoop.Timeout != 90*time.Second {
+		t.Errorf("Expected the process loop timeout to be overridden, got %v", DefaultResilienceConfig.ProcessLoop.Timeout)
+	}
+	if DefaultResilienceConfig.SheetRead != saved.SheetRead || DefaultResilienceConfig.StateTracking != saved.StateTracking {
+		t.Error("Expected invalid values to keep the defaults")
+	}
+	if DefaultResilienceConfig.APIRequest != saved.APIRequest {
+		t.Error("Expected unset values to keep the defaults")
+	}
+}
+
+func TestParseRetryMode(t *testing.T) {
+	cases := map[string]RetryMode{
+		"":          RetryModeInfinite,
+		"infinite":  RetryModeInfinite,
+		" Finite ":  RetryModeFinite,
+		"sometimes": RetryModeInfinite,
+	}
+	for value, want := range cases {
+		mode, err := ParseRetryMode(value)
+		if mode != want {
+			t.Errorf("ParseRetryMode(%q) = %q, want %q", value, mode, want)
+		}
+		if (err != nil) != (value == "sometimes") {
+			t.Errorf("ParseRetryMode(%q) error = %v", value, err)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"torn_oc_items/internal/retry"
@@ -11,6 +14,29 @@ type ResilienceConfig struct {
 	APIRequest    retry.Config
 	SheetRead     retry.Config
 	StateTracking retry.Config
+	// HTTP status codes from the Torn API that are retried rather than treated as terminal
+	RetryableStatusCodes []int
+}
+
+// Validate checks each retry config, naming the config in any error
+func (r ResilienceConfig) Validate() error {
+	configs := []struct {
+		name string
+		cfg  retry.Config
+	}{
+		{"ProcessLoop", r.ProcessLoop},
+		{"APIRequest", r.APIRequest},
+		{"SheetRead", r.SheetRead},
+		{"StateTracking", r.StateTracking},
+	}
+
+	var errs []error
+	for _, c := range configs {
+		if err := c.cfg.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 var DefaultResilienceConfig = ResilienceConfig{
@@ -38,4 +64,28 @@ var DefaultResilienceConfig = ResilienceConfig{
 		MaxDelay:   10 * time.Second,
 		Timeout:    10 * time.Second,
 	},
+	RetryableStatusCodes: []int{429, 500, 502, 503, 504},
+}
+
+// RetryMode selects what happens when a loop still fails after its ProcessLoop retries
+type RetryMode string
+
+const (
+	// RetryModeInfinite logs the failure and tries again on the next loop, for daemons
+	RetryModeInfinite RetryMode = "infinite"
+	// RetryModeFinite ends the process with an error, for one-off and supervised runs
+	// where a failure should surface instead of being retried forever
+	RetryModeFinite RetryMode = "finite"
+)
+
+// ParseRetryMode parses RETRY_MODE; empty means infinite
+func ParseRetryMode(value string) (RetryMode, error) {
+	switch mode := RetryMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "", RetryModeInfinite:
+		return RetryModeInfinite, nil
+	case RetryModeFinite:
+		return mode, nil
+	default:
+		return RetryModeInfinite, fmt.Errorf("RETRY_MODE %q must be %q or %q", value, RetryModeInfinite, RetryModeFinite)
+	}
 }
@@ -0,0 +1,95 @@
+package currency
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Format selects how values are rendered, set with CURRENCY_FORMAT
+type Format string
+
+const (
+	// FormatFull renders whole dollars with thousands separators, e.g. "$1,234,567"
+	FormatFull Format = "full"
+	// FormatAbbrev renders a K/M/B suffix with one decimal, e.g. "$1.2M"
+	FormatAbbrev Format = "abbrev"
+)
+
+// ParseFormat maps a CURRENCY_FORMAT value to a Format. An empty value is valid and means
+// no preference; anything else unrecognized is reported as not ok.
+func ParseFormat(value string) (Format, bool) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(value))); f {
+	case "", FormatFull, FormatAbbrev:
+		return f, true
+	default:
+		return "", false
+	}
+}
+
+// String renders value in the given format, falling back to full for an empty format
+func (f Format) String(value float64) string {
+	if f == FormatAbbrev {
+		return Abbrev(value)
+	}
+	return Full(value)
+}
+
+// Full renders a value as whole dollars with thousands separators, e.g. "$1,234,567"
+func Full(value float64) string {
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	digits := strconv.FormatFloat(math.Round(value), 'f', 0, 64)
+	var sb strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(d)
+	}
+	if digits == "0" {
+		sign = ""
+	}
+	return sign + "$" + sb.String()
+}
+
+var suffixes = []struct {
+	scale  float64
+	suffix string
+}{
+	{1e9, "B"},
+	{1e6, "M"},
+	{1e3, "K"},
+}
+
+// Abbrev renders a value with a K/M/B suffix, e.g. "$1.2M". Values that round up to the
+// next unit move to it, so 999,999 is "$1.0M" rather than "$1000.0K".
+func Abbrev(value float64) string {
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	for i, s := range suffixes {
+		if value < s.scale {
+			continue
+		}
+		scaled := math.Round(value/s.scale*10) / 10
+		if scaled >= 1000 && i > 0 {
+			s = suffixes[i-1]
+			scaled = math.Round(value/s.scale*10) / 10
+		}
+		return fmt.Sprintf("%s$%.1f%s", sign, scaled, s.suffix)
+	}
+	if math.Round(value) >= 1000 {
+		return sign + "$1.0K"
+	}
+	if math.Round(value) == 0 {
+		sign = ""
+	}
+	return fmt.Sprintf("%s$%.0f", sign, value)
+}
@@ -0,0 +1,61 @@
+package currency
+
+import "testing"
+
+func TestFull(t *testing.T) {
+	cases := map[float64]string{
+		0:             "$0",
+		0.4:           "$0",
+		-0.4:          "$0",
+		999:           "$999",
+		1000:          "$1,000",
+		1234567:       "$1,234,567",
+		-1234567:      "-$1,234,567",
+		2500000000:    "$2,500,000,000",
+		1234567890123: "$1,234,567,890,123",
+	}
+	for value, want := range cases {
+		if got := Full(value); got != want {
+			t.Errorf("Full(%v) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestAbbrev(t *testing.T) {
+	cases := map[float64]string{
+		0:          "$0",
+		999:        "$999",
+		999.6:      "$1.0K",
+		1000:       "$1.0K",
+		1250:       "$1.3K",
+		999999:     "$1.0M",
+		1200000:    "$1.2M",
+		-1200000:   "-$1.2M",
+		2500000000: "$2.5B",
+		1.5e12:     "$1500.0B",
+	}
+	for value, want := range cases {
+		if got := Abbrev(value); got != want {
+			t.Errorf("Abbrev(%v) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	cases := []struct {
+		value string
+		want  Format
+		ok    bool
+	}{
+		{"", "", true},
+		{"full", FormatFull, true},
+		{" ABBREV ", FormatAbbrev, true},
+		{"short", "", false},
+	}
+	for _, c := range cases {
+		got, ok := ParseFormat(c.value)
+		if got != c.want || ok != c.ok {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q, %v", c.value, got, ok, c.want, c.ok)
+		}
+	}
+}
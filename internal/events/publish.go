@@ -0,0 +1,16 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+)
+
+// PublishAll sends each event to the sink, logging failures so a broken sink never
+// interrupts processing
+func PublishAll(ctx context.Context, sink EventSink, events []Event) {
+	for _, event := range events {
+		if err := sink.Publish(ctx, event); err != nil {
+			slog.Warn("Failed to publish event", "type", event.Type, "item", event.ItemName, "user", event.UserName, "error", err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Event types published to an EventSink
+const (
+	TypeSuppliedItem = "supplied_item"
+	TypeProvidedItem = "provided_item"
+)
+
+// Event describes a detected supplied item or a provider match
+type Event struct {
+	Type        string    `json:"type"`
+	Time        time.Time `json:"time"`
+	CrimeID     int       `json:"crime_id,omitempty"`
+	CrimeURL    string    `json:"crime_url"`
+	ItemName    string    `json:"item_name"`
+	UserName    string    `json:"user_name"`
+	Provider    string    `json:"provider,omitempty"`
+	MarketValue float64   `json:"market_value,omitempty"`
+	Row         int       `json:"row,omitempty"`
+}
+
+// EventSink receives events so they can be fed into other systems such as bots or databases
+type EventSink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// NopSink discards every event
+type NopSink struct{}
+
+func (NopSink) Publish(ctx context.Context, event Event) error {
+	return nil
+}
+
+// StreamSink writes each event as a line of JSON, e.g. to stdout
+type StreamSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewStreamSink(w io.Writer) *StreamSink {
+	return &StreamSink{w: w}
+}
+
+func (s *StreamSink) Publish(ctx context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// WebhookSink POSTs each event as JSON to a URL
+type WebhookSink struct {
+	url        string
+	userAgent  string
+	httpClient *http.Client
+}
+
+func NewWebhookSink(url, userAgent string) *WebhookSink {
+	return &WebhookSink{
+		url:        url,
+		userAgent:  userAgent,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.userAgent != "" {
+		req.Header.Set("User-Agent", s.userAgent)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post event: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("event webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
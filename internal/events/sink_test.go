@@ -0,0 +1,73 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamSinkWritesJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewStreamSink(&buf)
+
+	PublishAll(context.Background(), sink, []Event{
+		{Type: TypeSuppliedItem, ItemName: "Xanax", UserName: "Alice"},
+		{Type: TypeProvidedItem, ItemName: "Xanax", UserName: "Alice", Provider: "Bob"},
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	var event Event
+	if err := json.Unmarshal([]byte(lines[1]), &event); err != nil {
+		t.Fatalf("Invalid JSON line %q: %v", lines[1], err)
+	}
+	if event.Type != TypeProvidedItem || event.Provider != "Bob" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+}
+
+func TestWebhookSinkPostsEvent(t *testing.T) {
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected JSON content type, got %q", r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		var event Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("Invalid event body %q: %v", body, err)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, "torn-oc-items/test")
+	event := Event{Type: TypeSuppliedItem, Time: time.Unix(1700000000, 0).UTC(), CrimeID: 101, ItemName: "Binoculars", UserName: "Alice"}
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Expected publish to succeed, got %v", err)
+	}
+
+	got := <-received
+	if got.CrimeID != 101 || got.ItemName != "Binoculars" || !got.Time.Equal(event.Time) {
+		t.Errorf("Unexpected event %+v", got)
+	}
+}
+
+func TestWebhookSinkReportsHTTPErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := NewWebhookSink(server.URL, "").Publish(context.Background(), Event{}); err == nil {
+		t.Error("Expected error for HTTP 500")
+	}
+}
@@ -1,12 +1,25 @@
 package log
 
 import (
+	"context"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
-// Setup configures the global logger based on ENV and LOGLEVEL environment variables.
+// sampleEvery keeps one in every N sampled debug records; 1 keeps them all
+var sampleEvery atomic.Uint64
+
+// sampleCounter counts sampled debug records seen so far
+var sampleCounter atomic.Uint64
+
+func init() {
+	sampleEvery.Store(1)
+}
+
+// Setup configures the global logger based on ENV, LOG_FORMAT and LOGLEVEL environment variables.
 func Setup() {
 	var level slog.Level
 	levelStr := strings.ToLower(os.Getenv("LOGLEVEL"))
@@ -25,13 +38,114 @@ func Setup() {
 		level = slog.LevelInfo
 	}
 
+	format, valid := resolveFormat(os.Getenv("LOG_FORMAT"), os.Getenv("ENV"))
+
 	opts := &slog.HandlerOptions{Level: level}
 	var handler slog.Handler
-	if os.Getenv("ENV") == "production" {
+	if format == "json" {
 		handler = slog.NewJSONHandler(os.Stderr, opts)
 	} else {
 		handler = slog.NewTextHandler(os.Stderr, opts)
 	}
 
+	recentSize, recentErr := parseRecentEvents(os.Getenv("RECENT_EVENTS"))
+	recent = nil
+	if recentSize > 0 {
+		recent = newRing(recentSize)
+		handler = &recentHandler{inner: handler, ring: recent}
+	}
+
 	slog.SetDefault(slog.New(handler))
+
+	if recentErr != nil {
+		slog.Warn("Invalid RECENT_EVENTS, using the default", "recent_events", os.Getenv("RECENT_EVENTS"), "default", DefaultRecentEvents, "error", recentErr)
+	}
+
+	rate, err := parseSampleRate(os.Getenv("LOG_SAMPLE_RATE"))
+	if err != nil {
+		slog.Warn("Invalid LOG_SAMPLE_RATE, logging all debug records", "log_sample_rate", os.Getenv("LOG_SAMPLE_RATE"), "error", err)
+	}
+	SetSampleRate(rate)
+
+	if !valid {
+		slog.Warn("Invalid LOG_FORMAT, falling back to ENV-based default",
+			"log_format", os.Getenv("LOG_FORMAT"),
+			"using", format,
+		)
+	}
+}
+
+// resolveFormat picks "json" or "console" output. An explicit LOG_FORMAT wins; otherwise
+// production uses JSON and everything else uses console. The bool is false when
+// LOG_FORMAT is set to an unrecognized value.
+func resolveFormat(logFormat, env string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(logFormat)) {
+	case "json":
+		return "json", true
+	case "console", "text":
+		return "console", true
+	case "":
+		if env == "production" {
+			return "json", true
+		}
+		return "console", true
+	default:
+		if env == "production" {
+			return "json", false
+		}
+		return "console", false
+	}
+}
+
+// parseSampleRate parses a rate in (0, 1]; empty means 1 (no sampling)
+func parseSampleRate(value string) (float64, error) {
+	if value == "" {
+		return 1, nil
+	}
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 1, err
+	}
+	if rate <= 0 || rate > 1 {
+		return 1, strconv.ErrRange
+	}
+	return rate, nil
+}
+
+// parseRecentEvents parses the recent events buffer size; empty means DefaultRecentEvents
+// and 0 disables the buffer
+func parseRecentEvents(value string) (int, error) {
+	if value == "" {
+		return DefaultRecentEvents, nil
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil {
+		return DefaultRecentEvents, err
+	}
+	if size < 0 {
+		return DefaultRecentEvents, strconv.ErrRange
+	}
+	return size, nil
+}
+
+// SetSampleRate sets the fraction of sampled debug records that are emitted
+func SetSampleRate(rate float64) {
+	every := uint64(1)
+	if rate > 0 && rate < 1 {
+		every = uint64(1/rate + 0.5)
+	}
+	sampleEvery.Store(every)
+}
+
+// DebugSampled logs a DEBUG record subject to LOG_SAMPLE_RATE. Use it for hot-path
+// per-slot and per-lookup logging; actionable INFO+ events should use slog directly.
+func DebugSampled(msg string, args ...any) {
+	ctx := context.Background()
+	if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	if every := sampleEvery.Load(); every > 1 && sampleCounter.Add(1)%every != 1 {
+		return
+	}
+	slog.DebugContext(ctx, msg, args...)
 }
@@ -0,0 +1,87 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestResolveFormat(t *testing.T) {
+	tests := []struct {
+		logFormat string
+		env       string
+		want      string
+		valid     bool
+	}{
+		{"", "production", "json", true},
+		{"", "development", "console", true},
+		{"json", "development", "json", true},
+		{"console", "production", "console", true},
+		{"JSON", "", "json", true},
+		{"xml", "production", "json", false},
+		{"xml", "", "console", false},
+	}
+
+	for _, test := range tests {
+		got, valid := resolveFormat(test.logFormat, test.env)
+		if got != test.want || valid != test.valid {
+			t.Errorf("resolveFormat(%q, %q) = (%q, %v), want (%q, %v)",
+				test.logFormat, test.env, got, valid, test.want, test.valid)
+		}
+	}
+}
+
+func TestParseSampleRate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"", 1, false},
+		{"0.1", 0.1, false},
+		{"1", 1, false},
+		{"0", 1, true},
+		{"1.5", 1, true},
+		{"abc", 1, true},
+	}
+
+	for _, test := range tests {
+		got, err := parseSampleRate(test.value)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("parseSampleRate(%q) = (%v, %v), want (%v, error %v)", test.value, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestRecentHandlerKeepsLatestInfoRecords(t *testing.T) {
+	inner := slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelWarn})
+	r := newRing(2)
+	logger := slog.New(&recentHandler{inner: inner, ring: r}).With("loop", 7)
+
+	logger.Debug("dropped")
+	logger.Info("first")
+	logger.WithGroup("sheet").Info("second", "rows", 3)
+	logger.Warn("third", "error", errors.New("boom"), "delay", 2*time.Second)
+
+	events := r.snapshot()
+	if len(events) != 2 {
+		t.Fatalf("Expected the 2 latest events, got %d: %+v", len(events), events)
+	}
+	if events[0].Message != "second" || events[0].Attrs["sheet.rows"] != int64(3) || events[0].Attrs["loop"] != int64(7) {
+		t.Errorf("Unexpected first event %+v", events[0])
+	}
+	if events[1].Level != "WARN" || events[1].Attrs["error"] != "boom" || events[1].Attrs["delay"] != "2s" {
+		t.Errorf("Unexpected second event %+v", events[1])
+	}
+}
+
+func TestParseRecentEvents(t *testing.T) {
+	cases := map[string]int{"": DefaultRecentEvents, "0": 0, "50": 50, "-1": DefaultRecentEvents, "lots": DefaultRecentEvents}
+	for value, want := range cases {
+		if got, _ := parseRecentEvents(value); got != want {
+			t.Errorf("parseRecentEvents(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
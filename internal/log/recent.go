@@ -0,0 +1,140 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultRecentEvents is how many INFO and higher records are kept for the /events endpoint
+const DefaultRecentEvents = 200
+
+// Event is one log record kept in the recent events buffer
+type Event struct {
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level"`
+	Message string         `json:"message"`
+	Attrs   map[string]any `json:"attrs,omitempty"`
+}
+
+// ring is a fixed-size, thread-safe buffer of the most recent events
+type ring struct {
+	mu     sync.Mutex
+	events []Event
+	next   int
+	full   bool
+}
+
+func newRing(size int) *ring {
+	return &ring{events: make([]Event, size)}
+}
+
+func (r *ring) add(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[r.next] = e
+	r.next = (r.next + 1) % len(r.events)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// snapshot returns the buffered events, oldest first
+func (r *ring) snapshot() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]Event(nil), r.events[:r.next]...)
+	}
+	out := make([]Event, 0, len(r.events))
+	out = append(out, r.events[r.next:]...)
+	return append(out, r.events[:r.next]...)
+}
+
+// recent holds the buffer installed by Setup, nil when RECENT_EVENTS is 0
+var recent *ring
+
+// RecentEvents returns the last INFO and higher records, oldest first. It is empty when
+// the buffer is disabled.
+func RecentEvents() []Event {
+	if recent == nil {
+		return []Event{}
+	}
+	return recent.snapshot()
+}
+
+// recentHandler copies INFO and higher records into a ring before passing them on, so
+// they are kept even when LOGLEVEL hides them from the output
+type recentHandler struct {
+	inner  slog.Handler
+	ring   *ring
+	attrs  []slog.Attr
+	prefix string
+}
+
+func (h *recentHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo || h.inner.Enabled(ctx, level)
+}
+
+func (h *recentHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= slog.LevelInfo {
+		attrs := make(map[string]any, len(h.attrs)+record.NumAttrs())
+		for _, a := range h.attrs {
+			addAttr(attrs, "", a)
+		}
+		record.Attrs(func(a slog.Attr) bool {
+			addAttr(attrs, h.prefix, a)
+			return true
+		})
+		h.ring.add(Event{Time: record.Time, Level: record.Level.String(), Message: record.Message, Attrs: attrs})
+	}
+	if !h.inner.Enabled(ctx, record.Level) {
+		return nil
+	}
+	return h.inner.Handle(ctx, record)
+}
+
+func (h *recentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	prefixed := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	prefixed = append(prefixed, h.attrs...)
+	for _, a := range attrs {
+		prefixed = append(prefixed, slog.Attr{Key: h.prefix + a.Key, Value: a.Value})
+	}
+	return &recentHandler{inner: h.inner.WithAttrs(attrs), ring: h.ring, attrs: prefixed, prefix: h.prefix}
+}
+
+func (h *recentHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &recentHandler{inner: h.inner.WithGroup(name), ring: h.ring, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// addAttr stores a under prefix+key, flattening groups into dotted keys. Errors and
+// durations are stored as strings since they don't encode usefully as JSON.
+func addAttr(attrs map[string]any, prefix string, a slog.Attr) {
+	value := a.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix += a.Key + "."
+		}
+		for _, member := range value.Group() {
+			addAttr(attrs, groupPrefix, member)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+
+	switch v := value.Any().(type) {
+	case error:
+		attrs[prefix+a.Key] = v.Error()
+	case time.Duration:
+		attrs[prefix+a.Key] = v.String()
+	default:
+		attrs[prefix+a.Key] = v
+	}
+}
@@ -0,0 +1,127 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Status is a snapshot of the notification client's delivery state
+type Status struct {
+	Enabled      bool      `json:"enabled"`
+	Topic        string    `json:"topic"`
+	CircuitOpen  bool      `json:"circuit_open"`
+	OpenedAt     time.Time `json:"opened_at,omitzero"`
+	Failures     int       `json:"consecutive_failures"`
+	LastFailure  time.Time `json:"last_failure,omitzero"`
+	TotalSent    int64     `json:"total_sent"`
+	TotalFailed  int64     `json:"total_failed"`
+	TotalRetries int64     `json:"total_retries"`
+}
+
+// Status returns the current circuit breaker state and delivery counters
+func (c *Client) Status() Status {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	status := Status{
+		Enabled:      c.enabled,
+		Topic:        c.topic,
+		CircuitOpen:  c.circuitOpen,
+		Failures:     c.failures,
+		LastFailure:  c.lastFailure,
+		TotalSent:    c.totalSent,
+		TotalFailed:  c.totalFailed,
+		TotalRetries: c.totalRetries,
+	}
+	if c.circuitOpen {
+		status.OpenedAt = c.openedAt
+	}
+	return status
+}
+
+// Probe checks that the ntfy server is reachable via its health endpoint without
+// publishing a message. It reports nil when notifications are disabled.
+func (c *Client) Probe(ctx context.Context) error {
+	if !c.enabled {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/v1/health", nil)
+	if err != nil {
+		return err
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("ntfy health check returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Enabled reports whether notifications are sent
+func (c *Client) Enabled() bool {
+	return c.enabled
+}
+
+// breakerEvent is one line of the breaker audit file
+type breakerEvent struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"`
+	Topic    string    `json:"topic"`
+	Failures int       `json:"failures,omitempty"`
+}
+
+// recordBreakerEvent appends a breaker state change to the audit file, if configured
+func (c *Client) recordBreakerEvent(event string, failures int) {
+	if c.auditFile == "" {
+		return
+	}
+
+	line, err := json.Marshal(breakerEvent{Time: time.Now(), Event: event, Topic: c.topic, Failures: failures})
+	if err != nil {
+		slog.Warn("Failed to encode circuit breaker event", "event", event, "error", err)
+		return
+	}
+
+	f, err := os.OpenFile(c.auditFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		slog.Warn("Failed to open notification audit file", "path", c.auditFile, "error", err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		slog.Warn("Failed to write notification audit file", "path", c.auditFile, "error", err)
+	}
+}
+
+// sendFallbackProbe makes a single attempt to report the open breaker on the fallback
+// topic. It bypasses the breaker and retries, and runs in the background so it doesn't
+// hold up processing.
+func (c *Client) sendFallbackProbe(failures int) {
+	if c.fallbackTopic == "" {
+		return
+	}
+
+	message := fmt.Sprintf("⚠️ Notifications to topic %q are failing (%d consecutive failures); circuit breaker is open", c.topic, failures)
+	go func() {
+		if err := c.sendToTopic(context.Background(), c.fallbackTopic, c.priority, message, 1); err != nil {
+			slog.Warn("Fallback notification probe failed", "fallback_topic", c.fallbackTopic, "error", err)
+			return
+		}
+		slog.Info("Sent circuit breaker alert to fallback topic", "fallback_topic", c.fallbackTopic)
+	}()
+}
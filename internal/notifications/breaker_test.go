@@ -0,0 +1,67 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpenIsReportedOutOfBand(t *testing.T) {
+	var probes int32
+	probed := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fallback" {
+			if atomic.AddInt32(&probes, 1) == 1 {
+				close(probed)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	auditFile := filepath.Join(t.TempDir(), "notify-audit.jsonl")
+	client := NewClient(server.URL, "main", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	client.SetBreakerAlerts(auditFile, "fallback")
+
+	for i := 0; i < 5; i++ {
+		_ = client.SendNotification(context.Background(), "test")
+	}
+
+	status := client.Status()
+	if !status.CircuitOpen || status.Failures != 5 || status.OpenedAt.IsZero() {
+		t.Errorf("Expected open breaker after 5 failures, got %+v", status)
+	}
+
+	select {
+	case <-probed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a probe notification on the fallback topic")
+	}
+
+	f, err := os.Open(auditFile)
+	if err != nil {
+		t.Fatalf("Expected audit file to be written: %v", err)
+	}
+	defer f.Close()
+
+	var events []breakerEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event breakerEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Invalid audit line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 1 || events[0].Event != "circuit_opened" || events[0].Failures != 5 || events[0].Topic != "main" {
+		t.Errorf("Expected one circuit_opened event, got %+v", events)
+	}
+}
@@ -3,14 +3,16 @@ package notifications
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"math"
-	"math/rand"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
+
+	"torn_oc_items/internal/currency"
+	"torn_oc_items/internal/retry"
 )
 
 type Client struct {
@@ -20,14 +22,42 @@ type Client struct {
 	enabled    bool
 	batchMode  bool
 	priority   string
-	maxRetries int
-	baseDelay  time.Duration
-	maxDelay   time.Duration
+	userAgent  string
+	// Retries for each notification; Timeout bounds a single attempt
+	retryConfig retry.Config
+	// Items below this market value are tracked but not notified
+	minItemValue float64
+	// Send a summary when every item for a crime has been provided
+	crimeComplete bool
+	// Overrides each message's default value format when set
+	currencyFormat currency.Format
+	// Individual mode sends a batch instead when more items than this arrive at once; 0 is no cap
+	maxIndividual int
+	// Individual mode sends one message per crime listing its items
+	groupByCrime bool
+	// Batch messages list an item needed by several members of one crime on one line
+	groupSameItem bool
+	// Messages name each item's crime, e.g. "Mob Mentality", alongside its link
+	showCrimeName bool
+	// New item messages go out at urgentPriority when an item's slot pass rate is at least
+	// urgentPassRate; 0 never escalates
+	urgentPassRate int
+	urgentPriority string
 	// Circuit breaker state
 	failures    int
 	lastFailure time.Time
 	circuitOpen bool
+	openedAt    time.Time
 	mutex       sync.RWMutex
+	// Out-of-band signals for breaker state changes, since ntfy itself is failing
+	auditFile     string
+	fallbackTopic string
+	// Topic per normalized item type; types without a route use topic
+	routes map[string]string
+	// Batch mode collects new items across loops for this long before sending; 0 sends each loop
+	window batchWindow
+	// Async sends still running, waited on by FlushBatch at shutdown
+	inflight sync.WaitGroup
 	// Metrics
 	totalSent    int64
 	totalFailed  int64
@@ -35,9 +65,39 @@ type Client struct {
 }
 
 type ItemInfo struct {
+	ItemName    string
+	ItemType    string
+	UserName    string
+	CrimeURL    string
+	CrimeID     int
+	CrimeName   string
+	MarketValue float64
+	// PassRate is the slot's checkpoint pass rate, 0-100
+	PassRate int
+	// Circulation is set only for items flagged as hard to source, see RARE_ITEM_CIRCULATION
+	Circulation int
+}
+
+// HardToSource reports whether the item was flagged for its low circulation
+func (i ItemInfo) HardToSource() bool {
+	return i.Circulation > 0
+}
+
+// UnassignedInfo describes an item a crime slot will need once a member joins it
+type UnassignedInfo struct {
+	ItemName  string
+	CrimeName string
+	Position  string
+	CrimeURL  string
+}
+
+// ReminderInfo describes a sheet row still needed when a reminder is sent
+type ReminderInfo struct {
 	ItemName string
 	UserName string
 	CrimeURL string
+	// AddedAt is zero when the row has no added time
+	AddedAt time.Time
 }
 
 type NotificationError struct {
@@ -62,21 +122,116 @@ func (e *NotificationError) IsRetryable() bool {
 	}
 }
 
-func NewClient(baseURL, topic string, enabled, batchMode bool, priority string, maxRetries int, baseDelay, maxDelay time.Duration) *Client {
+// DefaultAttemptTimeout bounds a single notification attempt unless changed with SetAttemptTimeout
+const DefaultAttemptTimeout = 10 * time.Second
+
+func NewClient(baseURL, topic string, enabled, batchMode bool, priority string, maxRetries int, baseDelay, maxDelay time.Duration, minItemValue float64, crimeComplete bool, userAgent string) *Client {
 	return &Client{
-		httpClient: &http.Client{Timeout: 10 * time.Second},
+		httpClient: &http.Client{Timeout: DefaultAttemptTimeout},
 		baseURL:    baseURL,
 		topic:      topic,
 		enabled:    enabled,
 		batchMode:  batchMode,
 		priority:   priority,
-		maxRetries: maxRetries,
-		baseDelay:  baseDelay,
-		maxDelay:   maxDelay,
+		retryConfig: retry.Config{
+			MaxRetries: maxRetries,
+			BaseDelay:  baseDelay,
+			MaxDelay:   maxDelay,
+			Timeout:    DefaultAttemptTimeout,
+		},
+		minItemValue:  minItemValue,
+		crimeComplete: crimeComplete,
+		userAgent:     userAgent,
+	}
+}
+
+// SetBreakerAlerts configures where circuit breaker state changes are reported. Events are
+// appended as JSON lines to auditFile, and when the breaker opens a single probe
+// notification is sent to fallbackTopic. Either may be empty to disable it.
+func (c *Client) SetBreakerAlerts(auditFile, fallbackTopic string) {
+	c.auditFile = auditFile
+	c.fallbackTopic = fallbackTopic
+}
+
+// SetMaxIndividual caps how many items individual mode notifies one by one; larger waves
+// are sent as a single batch notification. Zero disables the cap.
+func (c *Client) SetMaxIndividual(max int) {
+	c.maxIndividual = max
+}
+
+// SetGroupByCrime makes individual mode send one notification per crime listing all of
+// its new items, instead of one per item. Batch mode is unaffected.
+func (c *Client) SetGroupByCrime(enabled bool) {
+	c.groupByCrime = enabled
+}
+
+// SetGroupSameItem makes batch messages combine an item needed by several members of the
+// same crime into one line, e.g. "3x Binoculars for Alice, Bob, Carol (crime #123)"
+func (c *Client) SetGroupSameItem(enabled bool) {
+	c.groupSameItem = enabled
+}
+
+// SetShowCrimeName makes new item messages name each item's crime, set by SHOW_CRIME_NAME
+func (c *Client) SetShowCrimeName(enabled bool) {
+	c.showCrimeName = enabled
+}
+
+// crimeName returns the item's crime name when messages should show it, or ""
+func (c *Client) crimeName(item ItemInfo) string {
+	if !c.showCrimeName {
+		return ""
 	}
+	return item.CrimeName
+}
+
+// SetUrgentPassRate sends new item messages at priority instead of the usual one when any
+// item's slot has a checkpoint pass rate of at least passRate, as its crime is nearly
+// ready. Set by NTFY_URGENT_PASS_RATE and NTFY_URGENT_PRIORITY; 0 disables.
+func (c *Client) SetUrgentPassRate(passRate int, priority string) {
+	c.urgentPassRate = passRate
+	c.urgentPriority = priority
+}
+
+// itemsPriority returns the priority for a message listing items
+func (c *Client) itemsPriority(items ...ItemInfo) string {
+	if c.urgentPassRate <= 0 {
+		return c.priority
+	}
+	for _, item := range items {
+		if item.PassRate >= c.urgentPassRate {
+			return c.urgentPriority
+		}
+	}
+	return c.priority
+}
+
+// SetCurrencyFormat renders every market value in format. When unset, batch messages use
+// abbreviated values and individual messages use full values.
+func (c *Client) SetCurrencyFormat(format currency.Format) {
+	c.currencyFormat = format
+}
+
+// formatValue renders a market value in the configured format, or fallback if none is set
+func (c *Client) formatValue(value float64, fallback currency.Format) string {
+	if c.currencyFormat != "" {
+		return c.currencyFormat.String(value)
+	}
+	return fallback.String(value)
+}
+
+// SetAttemptTimeout bounds each notification attempt; retries get a fresh timeout. The
+// HTTP client's own timeout follows it so neither cuts the other short.
+func (c *Client) SetAttemptTimeout(timeout time.Duration) {
+	c.retryConfig.Timeout = timeout
+	c.httpClient.Timeout = timeout
 }
 
 func (c *Client) SendNotification(ctx context.Context, message string) error {
+	return c.sendNotification(ctx, c.topic, c.priority, message)
+}
+
+// sendNotification delivers message to topic at priority, retrying through the circuit breaker
+func (c *Client) sendNotification(ctx context.Context, topic, priority, message string) error {
 	if !c.enabled {
 		slog.Debug("Notifications disabled, skipping")
 		return nil
@@ -90,48 +245,49 @@ func (c *Client) SendNotification(ctx context.Context, message string) error {
 		}
 	}
 
-	var lastErr error
-	for attempt := 0; attempt <= c.maxRetries; attempt++ {
-		if attempt > 0 {
-			delay := c.calculateBackoff(attempt)
-			slog.Debug("Retrying notification after delay", "attempt", attempt, "delay", delay)
-			select {
-			case <-time.After(delay):
-			case <-ctx.Done():
-				return ctx.Err()
-			}
+	attempts := 0
+	_, err := retry.WithRetry(ctx, c.retryConfig, func(ctx context.Context) (struct{}, error) {
+		attempts++
+		if attempts > 1 {
 			c.incrementRetries()
 		}
 
-		err := c.sendSingleNotification(ctx, message, attempt+1)
+		err := c.sendToTopic(ctx, topic, priority, message, attempts)
 		if err == nil {
-			c.recordSuccess()
-			return nil
+			return struct{}{}, nil
 		}
 
-		lastErr = err
-
-		if notifErr, ok := err.(*NotificationError); ok {
-			if !notifErr.IsRetryable() {
-				slog.Warn("Non-retryable error, giving up", "error", err, "attempt", attempt+1)
-				c.recordFailure()
-				return err
-			}
+		var notifErr *NotificationError
+		if errors.As(err, &notifErr) && !notifErr.IsRetryable() {
+			slog.Warn("Non-retryable error, giving up", "error", err, "attempt", attempts)
+			return struct{}{}, retry.Permanent(err)
 		}
 
-		slog.Warn("Notification attempt failed", "error", err, "attempt", attempt+1, "max_retries", c.maxRetries)
+		slog.Warn("Notification attempt failed", "error", err, "attempt", attempts, "max_retries", c.retryConfig.MaxRetries)
+		return struct{}{}, err
+	})
+	if err == nil {
+		c.recordSuccess()
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
 	}
 
 	c.recordFailure()
+	var notifErr *NotificationError
+	if retry.IsPermanent(err) && errors.As(err, &notifErr) {
+		return notifErr
+	}
 	return &NotificationError{
 		Type:       "max_retries_exceeded",
-		Attempt:    c.maxRetries + 1,
-		Underlying: lastErr,
+		Attempt:    attempts,
+		Underlying: errors.Unwrap(err),
 	}
 }
 
-func (c *Client) sendSingleNotification(ctx context.Context, message string, attempt int) error {
-	url := fmt.Sprintf("%s/%s", c.baseURL, c.topic)
+func (c *Client) sendToTopic(ctx context.Context, topic, priority, message string, attempt int) error {
+	url := fmt.Sprintf("%s/%s", c.baseURL, topic)
 	slog.Debug("Sending notification", "url", url, "attempt", attempt)
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBufferString(message))
@@ -140,8 +296,11 @@ func (c *Client) sendSingleNotification(ctx context.Context, message string, att
 	}
 
 	req.Header.Set("Content-Type", "text/plain")
-	if c.priority != "" {
-		req.Header.Set("Priority", c.priority)
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if priority != "" {
+		req.Header.Set("Priority", priority)
 	}
 
 	resp, err := c.httpClient.Do(req)
@@ -164,22 +323,97 @@ func (c *Client) sendSingleNotification(ctx context.Context, message string, att
 }
 
 func (c *Client) SendNotificationAsync(ctx context.Context, message string) {
+	c.sendNotificationAsync(ctx, c.topic, c.priority, message)
+}
+
+// sendNotificationAsync sends in the background. The send outlives the caller, so it
+// keeps ctx's values but not its cancellation or deadline.
+func (c *Client) sendNotificationAsync(ctx context.Context, topic, priority, message string) {
+	ctx = context.WithoutCancel(ctx)
+	c.inflight.Add(1)
 	go func() {
-		if err := c.SendNotification(ctx, message); err != nil {
-			slog.Warn("Async notification failed", "error", err)
+		defer c.inflight.Done()
+		if err := c.sendNotification(ctx, topic, priority, message); err != nil {
+			slog.Warn("Async notification failed", "topic", topic, "error", err)
 		}
 	}()
 }
 
+// NotifyNewItems announces items newly added to the sheet. With a batch window they are
+// held and sent together with later items once the window has passed.
 func (c *Client) NotifyNewItems(ctx context.Context, items []ItemInfo, totalAdded int) {
 	if !c.enabled || totalAdded == 0 {
 		return
 	}
-	if c.batchMode {
-		c.sendBatchNotification(ctx, items, totalAdded)
-	} else {
-		c.sendIndividualNotifications(ctx, items)
+	if c.batchMode && c.window.hold(ctx, c, items, totalAdded) {
+		return
 	}
+	c.notifyNewItems(ctx, items, totalAdded)
+}
+
+func (c *Client) notifyNewItems(ctx context.Context, items []ItemInfo, totalAdded int) {
+
+	if c.minItemValue > 0 {
+		filtered := c.filterByMinValue(items)
+		if len(filtered) < len(items) {
+			slog.Debug("Filtered low-value items from notification",
+				"min_item_value", c.minItemValue,
+				"total_items", len(items),
+				"notified_items", len(filtered),
+			)
+		}
+		if len(filtered) == 0 {
+			return
+		}
+		totalAdded -= len(items) - len(filtered)
+		items = filtered
+	}
+
+	groups := c.groupByTopic(items)
+	for _, group := range groups {
+		// With a single group the count from the caller still applies; once split, each
+		// topic only hears about its own items
+		groupTotal := totalAdded
+		if len(groups) > 1 {
+			groupTotal = len(group.items)
+		}
+		if c.batchMode {
+			c.sendBatchNotification(ctx, group.topic, group.items, groupTotal)
+			continue
+		}
+
+		// Grouped by crime, the cap counts messages (crimes) rather than items
+		var crimes [][]ItemInfo
+		messages := len(group.items)
+		if c.groupByCrime {
+			crimes = groupItemsByCrime(group.items)
+			messages = len(crimes)
+		}
+		if c.maxIndividual > 0 && messages > c.maxIndividual {
+			slog.Info("Too many new items for individual notifications, sending a batch instead",
+				"topic", group.topic,
+				"items", len(group.items),
+				"messages", messages,
+				"max_individual", c.maxIndividual,
+			)
+			c.sendBatchNotification(ctx, group.topic, group.items, groupTotal)
+		} else if c.groupByCrime {
+			c.sendCrimeNotifications(ctx, group.topic, crimes)
+		} else {
+			c.sendIndividualNotifications(ctx, group.topic, group.items)
+		}
+	}
+}
+
+// filterByMinValue returns the items whose market value meets the configured minimum
+func (c *Client) filterByMinValue(items []ItemInfo) []ItemInfo {
+	var filtered []ItemInfo
+	for _, item := range items {
+		if item.MarketValue >= c.minItemValue {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
 }
 
 func (c *Client) NotifyStateTransition(ctx context.Context, crimeID int, crimeName, fromState, toState string) {
@@ -199,21 +433,184 @@ func (c *Client) NotifyStateTransition(ctx context.Context, crimeID int, crimeNa
 	c.SendNotificationAsync(ctx, message)
 }
 
-func (c *Client) sendBatchNotification(ctx context.Context, items []ItemInfo, totalAdded int) {
-	slog.Info("Sending batch notification for new items", "items_added", totalAdded)
-	c.SendNotificationAsync(ctx, c.formatBatchMessage(items, totalAdded))
+func (c *Client) NotifyCrimeFullySupplied(ctx context.Context, crimeID int, crimeURL string, itemCount int) {
+	slog.Info("Crime fully supplied", "crime_id", crimeID, "items", itemCount)
+
+	if !c.enabled || !c.crimeComplete {
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "✅ Crime #%d fully supplied\n", crimeID)
+	if itemCount == 1 {
+		sb.WriteString("1 item provided")
+	} else {
+		fmt.Fprintf(&sb, "%d items provided", itemCount)
+	}
+	if crimeURL != "" {
+		fmt.Fprintf(&sb, "\n🔗 Crime: %s", crimeURL)
+	}
+	c.SendNotificationAsync(ctx, sb.String())
+}
+
+// NotifyUnassignedNeeds announces items that will be needed once their crime slots are
+// filled, in one message
+func (c *Client) NotifyUnassignedNeeds(ctx context.Context, needs []UnassignedInfo) {
+	if !c.enabled || len(needs) == 0 {
+		return
+	}
+	c.SendNotificationAsync(ctx, formatUnassignedMessage(needs))
+}
+
+func formatUnassignedMessage(needs []UnassignedInfo) string {
+	var sb strings.Builder
+	if len(needs) == 1 {
+		sb.WriteString("📋 Torn OC: 1 item will be needed once its slot is filled")
+	} else {
+		fmt.Fprintf(&sb, "📋 Torn OC: %d items will be needed once slots are filled", len(needs))
+	}
+	for _, need := range needs {
+		fmt.Fprintf(&sb, "\n• %s for %s (%s)", need.ItemName, need.CrimeName, need.Position)
+		if need.CrimeURL != "" {
+			fmt.Fprintf(&sb, "\n  🔗 %s", need.CrimeURL)
+		}
+	}
+	return sb.String()
+}
+
+// NotifyLoopStale alerts that no process loop has succeeded for age, longer than the
+// LOOP_STALE_AFTER threshold
+func (c *Client) NotifyLoopStale(ctx context.Context, age, threshold time.Duration) {
+	if !c.enabled {
+		return
+	}
+	c.SendNotificationAsync(ctx, fmt.Sprintf("⚠️ Torn OC: no successful loop for %s (threshold %s); the sheet is not being updated",
+		age.Round(time.Second), threshold))
 }
 
-func (c *Client) sendIndividualNotifications(ctx context.Context, items []ItemInfo) {
-	slog.Info("Sending individual notifications for new items", "items_added", len(items))
+// NotifyLoopRecovered reports that loops succeed again after a stale alert
+func (c *Client) NotifyLoopRecovered(ctx context.Context, downtime time.Duration) {
+	if !c.enabled {
+		return
+	}
+	c.SendNotificationAsync(ctx, fmt.Sprintf("✅ Torn OC: loops are succeeding again after %s", downtime.Round(time.Second)))
+}
+
+// NotifyNeededReminder summarizes rows that have stayed needed for a while, with how long
+// each has been open as of now
+func (c *Client) NotifyNeededReminder(ctx context.Context, items []ReminderInfo, now time.Time) {
+	if !c.enabled || len(items) == 0 {
+		return
+	}
+	c.SendNotificationAsync(ctx, formatReminderMessage(items, now))
+}
+
+func formatReminderMessage(items []ReminderInfo, now time.Time) string {
+	var sb strings.Builder
+	if len(items) == 1 {
+		sb.WriteString("⏰ Torn OC: 1 item is still needed")
+	} else {
+		fmt.Fprintf(&sb, "⏰ Torn OC: %d items are still needed", len(items))
+	}
+	for _, item := range items {
+		fmt.Fprintf(&sb, "\n• %s for %s", item.ItemName, item.UserName)
+		if !item.AddedAt.IsZero() {
+			fmt.Fprintf(&sb, " (open %s)", formatAge(now.Sub(item.AddedAt)))
+		}
+		if item.CrimeURL != "" {
+			fmt.Fprintf(&sb, "\n  🔗 %s", item.CrimeURL)
+		}
+	}
+	return sb.String()
+}
+
+// formatAge renders how long a row has been open in its largest whole unit
+func formatAge(age time.Duration) string {
+	switch {
+	case age >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(age/(24*time.Hour)))
+	case age >= time.Hour:
+		return fmt.Sprintf("%dh", int(age/time.Hour))
+	default:
+		return fmt.Sprintf("%dm", max(int(age/time.Minute), 0))
+	}
+}
+
+func (c *Client) sendBatchNotification(ctx context.Context, topic string, items []ItemInfo, totalAdded int) {
+	slog.Info("Sending batch notification for new items", "topic", topic, "items_added", totalAdded)
+	c.sendNotificationAsync(ctx, topic, c.itemsPriority(items...), c.formatBatchMessage(items, totalAdded))
+}
+
+func (c *Client) sendIndividualNotifications(ctx context.Context, topic string, items []ItemInfo) {
+	slog.Info("Sending individual notifications for new items", "topic", topic, "items_added", len(items))
 	for i, item := range items {
-		c.SendNotificationAsync(ctx, c.formatIndividualMessage(item, i+1, len(items)))
+		c.sendNotificationAsync(ctx, topic, c.itemsPriority(item), c.formatIndividualMessage(item, i+1, len(items)))
 		if i < len(items)-1 {
 			time.Sleep(100 * time.Millisecond)
 		}
 	}
 }
 
+// groupItemsByCrime splits items by crime URL, keeping crimes and the items within them
+// in their original order
+func groupItemsByCrime(items []ItemInfo) [][]ItemInfo {
+	index := make(map[string]int)
+	var crimes [][]ItemInfo
+	for _, item := range items {
+		i, ok := index[item.CrimeURL]
+		if !ok {
+			i = len(crimes)
+			index[item.CrimeURL] = i
+			crimes = append(crimes, nil)
+		}
+		crimes[i] = append(crimes[i], item)
+	}
+	return crimes
+}
+
+func (c *Client) sendCrimeNotifications(ctx context.Context, topic string, crimes [][]ItemInfo) {
+	slog.Info("Sending per-crime notifications for new items", "topic", topic, "crimes", len(crimes))
+	for i, items := range crimes {
+		c.sendNotificationAsync(ctx, topic, c.itemsPriority(items...), c.formatCrimeMessage(items, i+1, len(crimes)))
+		if i < len(crimes)-1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+}
+
+// formatCrimeMessage lists the new items for one crime; a single item gets the usual
+// individual message
+func (c *Client) formatCrimeMessage(items []ItemInfo, crimeNum, totalCrimes int) string {
+	if len(items) == 1 {
+		return c.formatIndividualMessage(items[0], crimeNum, totalCrimes)
+	}
+
+	crime := "one crime"
+	if name := c.crimeName(items[0]); name != "" {
+		crime = name
+	}
+	var sb strings.Builder
+	if totalCrimes > 1 {
+		fmt.Fprintf(&sb, "📋 %d new items needed for %s (%d/%d)\n", len(items), crime, crimeNum, totalCrimes)
+	} else {
+		fmt.Fprintf(&sb, "📋 %d new items needed for %s\n", len(items), crime)
+	}
+	for _, item := range items {
+		fmt.Fprintf(&sb, "• %s for %s", item.ItemName, item.UserName)
+		if item.MarketValue > 0 {
+			fmt.Fprintf(&sb, " (%s)", c.formatValue(item.MarketValue, currency.FormatFull))
+		}
+		if item.HardToSource() {
+			sb.WriteString(" ⚠️ hard to source")
+		}
+		sb.WriteString("\n")
+	}
+	if items[0].CrimeURL != "" {
+		fmt.Fprintf(&sb, "🔗 Crime: %s\n", items[0].CrimeURL)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
 func (c *Client) formatBatchMessage(items []ItemInfo, totalAdded int) string {
 	var sb strings.Builder
 	if totalAdded == 1 {
@@ -221,19 +618,82 @@ func (c *Client) formatBatchMessage(items []ItemInfo, totalAdded int) string {
 	} else {
 		fmt.Fprintf(&sb, "🎯 Torn OC: %d new items needed\n", totalAdded)
 	}
-	maxShow := 10
-	if len(items) < maxShow {
-		maxShow = len(items)
+
+	var lines [][]ItemInfo
+	if c.groupSameItem {
+		lines = groupSameItem(items)
+	} else {
+		for _, item := range items {
+			lines = append(lines, []ItemInfo{item})
+		}
 	}
-	for i := 0; i < maxShow; i++ {
-		fmt.Fprintf(&sb, "• %s for %s\n", items[i].ItemName, items[i].UserName)
+
+	maxShow := min(10, len(lines))
+	shown := 0
+	for _, line := range lines[:maxShow] {
+		item := line[0]
+		shown += len(line)
+		rare := ""
+		if item.HardToSource() {
+			rare = " ⚠️ hard to source"
+		}
+		if len(line) == 1 {
+			in := ""
+			if name := c.crimeName(item); name != "" {
+				in = " in " + name
+			}
+			if item.MarketValue > 0 {
+				fmt.Fprintf(&sb, "• %s (~%s) for %s%s%s\n", item.ItemName, c.formatValue(item.MarketValue, currency.FormatAbbrev), item.UserName, in, rare)
+			} else {
+				fmt.Fprintf(&sb, "• %s for %s%s%s\n", item.ItemName, item.UserName, in, rare)
+			}
+			continue
+		}
+
+		users := make([]string, len(line))
+		for i, member := range line {
+			users[i] = member.UserName
+		}
+		value := ""
+		if item.MarketValue > 0 {
+			value = fmt.Sprintf(" (~%s each)", c.formatValue(item.MarketValue, currency.FormatAbbrev))
+		}
+		crime := ""
+		if name := c.crimeName(item); name != "" {
+			crime = fmt.Sprintf(" (%s)", name)
+		} else if item.CrimeID > 0 {
+			crime = fmt.Sprintf(" (crime #%d)", item.CrimeID)
+		}
+		fmt.Fprintf(&sb, "• %dx %s%s for %s%s%s\n", len(line), item.ItemName, value, strings.Join(users, ", "), crime, rare)
 	}
-	if len(items) > 10 {
-		fmt.Fprintf(&sb, "... and %d more items\n", len(items)-10)
+	if remaining := len(items) - shown; remaining > 0 {
+		fmt.Fprintf(&sb, "... and %d more items\n", remaining)
 	}
 	return strings.TrimSuffix(sb.String(), "\n")
 }
 
+// groupSameItem collects items by crime and item name, keeping the order each pair was
+// first seen in
+func groupSameItem(items []ItemInfo) [][]ItemInfo {
+	type lineKey struct {
+		crimeURL string
+		itemName string
+	}
+	index := make(map[lineKey]int)
+	var lines [][]ItemInfo
+	for _, item := range items {
+		key := lineKey{crimeURL: item.CrimeURL, itemName: item.ItemName}
+		i, ok := index[key]
+		if !ok {
+			i = len(lines)
+			index[key] = i
+			lines = append(lines, nil)
+		}
+		lines[i] = append(lines[i], item)
+	}
+	return lines
+}
+
 func (c *Client) formatIndividualMessage(item ItemInfo, itemNum, totalItems int) string {
 	var sb strings.Builder
 	if totalItems > 1 {
@@ -243,6 +703,15 @@ func (c *Client) formatIndividualMessage(item ItemInfo, itemNum, totalItems int)
 	}
 	fmt.Fprintf(&sb, "🎯 **%s**\n", item.ItemName)
 	fmt.Fprintf(&sb, "👤 For: %s\n", item.UserName)
+	if name := c.crimeName(item); name != "" {
+		fmt.Fprintf(&sb, "🕵️ Crime: %s\n", name)
+	}
+	if item.MarketValue > 0 {
+		fmt.Fprintf(&sb, "💰 Value: %s\n", c.formatValue(item.MarketValue, currency.FormatFull))
+	}
+	if item.HardToSource() {
+		fmt.Fprintf(&sb, "⚠️ Hard to source: %d in circulation\n", item.Circulation)
+	}
 	if item.CrimeURL != "" {
 		fmt.Fprintf(&sb, "🔗 Crime: %s\n", item.CrimeURL)
 	}
@@ -265,6 +734,7 @@ func (c *Client) isCircuitOpen() bool {
 		c.mutex.Unlock()
 		c.mutex.RLock()
 		slog.Info("Circuit breaker moving to half-open state")
+		c.recordBreakerEvent("circuit_half_open", 0)
 	}
 
 	return c.circuitOpen
@@ -272,25 +742,38 @@ func (c *Client) isCircuitOpen() bool {
 
 func (c *Client) recordSuccess() {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
 	c.totalSent++
+	closed := c.circuitOpen
 	if c.circuitOpen {
 		c.circuitOpen = false
 		c.failures = 0
 		slog.Info("Circuit breaker closed after successful notification")
 	}
+	c.mutex.Unlock()
+
+	if closed {
+		c.recordBreakerEvent("circuit_closed", 0)
+	}
 }
 
 func (c *Client) recordFailure() {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
 	c.totalFailed++
 	c.failures++
 	c.lastFailure = time.Now()
-	if c.failures >= 5 && !c.circuitOpen {
+	opened := c.failures >= 5 && !c.circuitOpen
+	failures := c.failures
+	if opened {
 		c.circuitOpen = true
+		c.openedAt = c.lastFailure
 		slog.Warn("Circuit breaker opened due to consecutive failures", "failures", c.failures)
 	}
+	c.mutex.Unlock()
+
+	if opened {
+		c.recordBreakerEvent("circuit_opened", failures)
+		c.sendFallbackProbe(failures)
+	}
 }
 
 func (c *Client) incrementRetries() {
@@ -299,17 +782,6 @@ func (c *Client) incrementRetries() {
 	c.mutex.Unlock()
 }
 
-func (c *Client) calculateBackoff(attempt int) time.Duration {
-	base := float64(c.baseDelay)
-	backoff := base * math.Pow(2, float64(attempt-1))
-	jitter := rand.Float64()*0.5 - 0.25
-	backoff = backoff * (1 + jitter)
-	if backoff > float64(c.maxDelay) {
-		backoff = float64(c.maxDelay)
-	}
-	return time.Duration(backoff)
-}
-
 func (c *Client) categorizeHTTPError(statusCode int) string {
 	switch {
 	case statusCode == 401 || statusCode == 403:
@@ -0,0 +1,321 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"torn_oc_items/internal/currency"
+)
+
+func TestFilterByMinValue(t *testing.T) {
+	client := NewClient("https://ntfy.sh", "test", true, true, "default", 0, time.Millisecond, time.Millisecond, 1000, false, "")
+
+	items := []ItemInfo{
+		{ItemName: "Bandage", UserName: "Alice", MarketValue: 500},
+		{ItemName: "Binoculars", UserName: "Bob", MarketValue: 1000},
+		{ItemName: "Lockpicks", UserName: "Carol", MarketValue: 25000},
+	}
+
+	filtered := client.filterByMinValue(items)
+	if len(filtered) != 2 {
+		t.Fatalf("Expected 2 items at or above minimum value, got %d", len(filtered))
+	}
+	if filtered[0].ItemName != "Binoculars" || filtered[1].ItemName != "Lockpicks" {
+		t.Errorf("Unexpected filtered items: %+v", filtered)
+	}
+}
+
+func TestFormatBatchMessageIncludesValue(t *testing.T) {
+	client := NewClient("https://ntfy.sh", "test", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+
+	msg := client.formatBatchMessage([]ItemInfo{{ItemName: "Binoculars", UserName: "Alice", MarketValue: 1200000}}, 1)
+	want := "🎯 Torn OC: 1 new item needed\n• Binoculars (~$1.2M) for Alice"
+	if msg != want {
+		t.Errorf("Expected %q, got %q", want, msg)
+	}
+}
+
+func TestFormatBatchMessageGroupsSameItem(t *testing.T) {
+	client := NewClient("https://ntfy.sh", "test", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	items := []ItemInfo{
+		{ItemName: "Binoculars", UserName: "Alice", CrimeURL: "crime-123", CrimeID: 123, MarketValue: 1200000},
+		{ItemName: "Jemmy", UserName: "Dana", CrimeURL: "crime-123", CrimeID: 123},
+		{ItemName: "Binoculars", UserName: "Bob", CrimeURL: "crime-123", CrimeID: 123, MarketValue: 1200000},
+		{ItemName: "Binoculars", UserName: "Carol", CrimeURL: "crime-456", CrimeID: 456, MarketValue: 1200000},
+		{ItemName: "Binoculars", UserName: "Erin", CrimeURL: "crime-123", CrimeID: 123, MarketValue: 1200000},
+	}
+
+	want := "🎯 Torn OC: 5 new items needed\n• Binoculars (~$1.2M) for Alice\n• Jemmy for Dana\n• Binoculars (~$1.2M) for Bob\n• Binoculars (~$1.2M) for Carol\n• Binoculars (~$1.2M) for Erin"
+	if msg := client.formatBatchMessage(items, 5); msg != want {
+		t.Errorf("Expected the per-item listing by default, got %q", msg)
+	}
+
+	client.SetGroupSameItem(true)
+	want = "🎯 Torn OC: 5 new items needed\n• 3x Binoculars (~$1.2M each) for Alice, Bob, Erin (crime #123)\n• Jemmy for Dana\n• Binoculars (~$1.2M) for Carol"
+	if msg := client.formatBatchMessage(items, 5); msg != want {
+		t.Errorf("Expected %q, got %q", want, msg)
+	}
+}
+
+func TestMessagesShowCrimeName(t *testing.T) {
+	client := NewClient("https://ntfy.sh", "test", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	items := []ItemInfo{
+		{ItemName: "Jemmy", UserName: "Bob", CrimeID: 101, CrimeName: "Mob Mentality"},
+		{ItemName: "Binoculars", UserName: "Alice", CrimeID: 101, CrimeName: "Mob Mentality"},
+	}
+
+	if msg := client.formatBatchMessage(items[:1], 1); strings.Contains(msg, "Mob Mentality") {
+		t.Errorf("Expected no crime name by default, got %q", msg)
+	}
+
+	client.SetShowCrimeName(true)
+	if want := "🎯 Torn OC: 1 new item needed\n• Jemmy for Bob in Mob Mentality"; client.formatBatchMessage(items[:1], 1) != want {
+		t.Errorf("Expected %q, got %q", want, client.formatBatchMessage(items[:1], 1))
+	}
+	if msg := client.formatCrimeMessage(items, 1, 1); !strings.HasPrefix(msg, "📋 2 new items needed for Mob Mentality\n") {
+		t.Errorf("Expected the crime message to name the crime, got %q", msg)
+	}
+	if msg := client.formatIndividualMessage(items[0], 1, 1); !strings.Contains(msg, "🕵️ Crime: Mob Mentality") {
+		t.Errorf("Expected the individual message to name the crime, got %q", msg)
+	}
+}
+
+func TestMessagesFlagHardToSourceItems(t *testing.T) {
+	client := NewClient("https://ntfy.sh", "test", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	item := ItemInfo{ItemName: "Jemmy", UserName: "Bob", Circulation: 1200}
+
+	batch := client.formatBatchMessage([]ItemInfo{item}, 1)
+	if want := "🎯 Torn OC: 1 new item needed\n• Jemmy for Bob ⚠️ hard to source"; batch != want {
+		t.Errorf("Expected %q, got %q", want, batch)
+	}
+	individual := client.formatIndividualMessage(item, 1, 1)
+	if !strings.Contains(individual, "⚠️ Hard to source: 1200 in circulation") {
+		t.Errorf("Expected the individual message to flag the item, got %q", individual)
+	}
+}
+
+func TestFormatUnassignedMessage(t *testing.T) {
+	msg := formatUnassignedMessage([]UnassignedInfo{
+		{ItemName: "Jemmy", CrimeName: "Mob Mentality", Position: "Looter #4", CrimeURL: "https://example.com/101"},
+		{ItemName: "Bolt Cutters", CrimeName: "Pet Project", Position: "Picklock"},
+	})
+	want := "📋 Torn OC: 2 items will be needed once slots are filled\n• Jemmy for Mob Mentality (Looter #4)\n  🔗 https://example.com/101\n• Bolt Cutters for Pet Project (Picklock)"
+	if msg != want {
+		t.Errorf("Expected %q, got %q", want, msg)
+	}
+}
+
+func TestFormatReminderMessage(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	msg := formatReminderMessage([]ReminderInfo{
+		{ItemName: "Binoculars", UserName: "Alice", CrimeURL: "https://example.com/crime/1", AddedAt: now.Add(-3*time.Hour - 20*time.Minute)},
+		{ItemName: "Jemmy", UserName: "Bob", AddedAt: now.Add(-50 * time.Hour)},
+		{ItemName: "Hammer", UserName: "Erin"},
+	}, now)
+
+	for _, want := range []string{"3 items are still needed", "Binoculars for Alice (open 3h)\n  🔗 https://example.com/crime/1", "Jemmy for Bob (open 2d)", "Hammer for Erin"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected %q in message:\n%s", want, msg)
+		}
+	}
+	if strings.Contains(msg, "Hammer for Erin (") {
+		t.Errorf("Expected no age for a row without an added time:\n%s", msg)
+	}
+}
+
+func TestCurrencyFormatOverridesMessageDefaults(t *testing.T) {
+	client := NewClient("https://ntfy.sh", "test", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	client.SetCurrencyFormat(currency.FormatFull)
+
+	msg := client.formatBatchMessage([]ItemInfo{{ItemName: "Binoculars", UserName: "Alice", MarketValue: 1200000}}, 1)
+	want := "🎯 Torn OC: 1 new item needed\n• Binoculars (~$1,200,000) for Alice"
+	if msg != want {
+		t.Errorf("Expected %q, got %q", want, msg)
+	}
+}
+
+func TestSendNotificationRetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test", true, true, "default", 3, time.Millisecond, 5*time.Millisecond, 0, false, "")
+	if err := client.SendNotification(context.Background(), "hello"); err != nil {
+		t.Fatalf("Expected success after retries, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("Expected 3 attempts, got %d", got)
+	}
+	if status := client.Status(); status.TotalRetries != 2 || status.TotalSent != 1 {
+		t.Errorf("Expected 2 retries and 1 sent, got %+v", status)
+	}
+}
+
+func TestSendNotificationDoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test", true, true, "default", 3, time.Millisecond, 5*time.Millisecond, 0, false, "")
+	err := client.SendNotification(context.Background(), "hello")
+
+	var notifErr *NotificationError
+	if !errors.As(err, &notifErr) || notifErr.Type != "auth" {
+		t.Fatalf("Expected auth NotificationError, got %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Expected 1 attempt, got %d", got)
+	}
+}
+
+func TestSendNotificationReportsMaxRetriesExceeded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test", true, true, "default", 1, time.Millisecond, 5*time.Millisecond, 0, false, "")
+	err := client.SendNotification(context.Background(), "hello")
+
+	var notifErr *NotificationError
+	if !errors.As(err, &notifErr) || notifErr.Type != "max_retries_exceeded" || notifErr.Attempt != 2 {
+		t.Fatalf("Expected max_retries_exceeded after 2 attempts, got %v", err)
+	}
+}
+
+func TestSetAttemptTimeoutSetsHTTPClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	client.SetAttemptTimeout(20 * time.Millisecond)
+	if err := client.SendNotification(context.Background(), "slow"); err == nil {
+		t.Fatal("Expected the attempt to time out")
+	}
+
+	// Longer than the default, which the HTTP client must not cap
+	client.SetAttemptTimeout(30 * time.Second)
+	if client.httpClient.Timeout != 30*time.Second {
+		t.Errorf("Expected the HTTP client timeout to follow NTFY_TIMEOUT_MS, got %v", client.httpClient.Timeout)
+	}
+}
+
+func TestNotifyNewItemsFallsBackToBatchAboveMaxIndividual(t *testing.T) {
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test", true, false, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	client.SetMaxIndividual(2)
+
+	client.NotifyNewItems(context.Background(), []ItemInfo{
+		{ItemName: "Xanax", UserName: "Alice"},
+		{ItemName: "Vicodin", UserName: "Bob"},
+		{ItemName: "Bandage", UserName: "Carol"},
+	}, 3)
+
+	select {
+	case body := <-bodies:
+		if !strings.HasPrefix(body, "🎯 Torn OC: 3 new items needed") {
+			t.Errorf("Expected a batch notification, got %q", body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a notification")
+	}
+	select {
+	case body := <-bodies:
+		t.Errorf("Expected a single notification, also got %q", body)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestNotifyNewItemsGroupsByCrime(t *testing.T) {
+	bodies := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test", true, false, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	client.SetGroupByCrime(true)
+	client.SetMaxIndividual(2)
+
+	client.NotifyNewItems(context.Background(), []ItemInfo{
+		{ItemName: "Xanax", UserName: "Alice", CrimeURL: "crime-1"},
+		{ItemName: "Vicodin", UserName: "Bob", CrimeURL: "crime-2"},
+		{ItemName: "Bandage", UserName: "Carol", CrimeURL: "crime-1"},
+	}, 3)
+
+	var received []string
+	for len(received) < 2 {
+		select {
+		case body := <-bodies:
+			received = append(received, body)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected 2 notifications, got %q", received)
+		}
+	}
+	select {
+	case body := <-bodies:
+		t.Errorf("Expected one notification per crime, also got %q", body)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	grouped := "📋 2 new items needed for one crime (1/2)\n• Xanax for Alice\n• Bandage for Carol\n🔗 Crime: crime-1"
+	if !slices.Contains(received, grouped) {
+		t.Errorf("Expected %q among %q", grouped, received)
+	}
+	if !slices.ContainsFunc(received, func(body string) bool { return strings.HasPrefix(body, "📋 New item needed (2/2)\n🎯 **Vicodin**") }) {
+		t.Errorf("Expected the single-item crime as an individual message, got %q", received)
+	}
+}
+
+func TestNotifyNewItemsEscalatesNearlyReadyCrimes(t *testing.T) {
+	priorities := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		priorities <- r.Header.Get("Priority")
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	items := []ItemInfo{{ItemName: "Jemmy", UserName: "Bob", PassRate: 80}}
+	if got := client.itemsPriority(items...); got != "default" {
+		t.Errorf("Expected no escalation by default, got %q", got)
+	}
+
+	client.SetUrgentPassRate(75, "urgent")
+	if got := client.itemsPriority(ItemInfo{PassRate: 74}); got != "default" {
+		t.Errorf("Expected the usual priority below the threshold, got %q", got)
+	}
+	client.NotifyNewItems(context.Background(), append(items, ItemInfo{ItemName: "Binoculars", UserName: "Alice", PassRate: 20}), 2)
+
+	select {
+	case priority := <-priorities:
+		if priority != "urgent" {
+			t.Errorf("Expected the batch to be escalated, got priority %q", priority)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a notification")
+	}
+}
@@ -0,0 +1,80 @@
+package notifications
+
+import (
+	"strings"
+)
+
+// RouteEnvPrefix starts the env vars that route an item type to its own topic, e.g.
+// NOTIFY_ROUTE_PRIMARY=oc-weapons
+const RouteEnvPrefix = "NOTIFY_ROUTE_"
+
+type topicGroup struct {
+	topic string
+	items []ItemInfo
+}
+
+// RouteKey normalizes an item type for route lookup: upper case with anything other than
+// letters and digits replaced by underscores, so "Enhancer" and "Super Rare" become
+// ENHANCER and SUPER_RARE
+func RouteKey(itemType string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.TrimSpace(itemType))
+}
+
+// ParseRoutes collects NOTIFY_ROUTE_<TYPE>=topic entries from an environment listing in
+// os.Environ form. Entries with an empty topic are ignored.
+func ParseRoutes(environ []string) map[string]string {
+	routes := make(map[string]string)
+	for _, kv := range environ {
+		key, topic, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, RouteEnvPrefix) {
+			continue
+		}
+		itemType := RouteKey(strings.TrimPrefix(key, RouteEnvPrefix))
+		topic = strings.TrimSpace(topic)
+		if itemType == "" || topic == "" {
+			continue
+		}
+		routes[itemType] = topic
+	}
+	return routes
+}
+
+// SetRoutes sends items whose type has a route to that topic instead of the default topic
+func (c *Client) SetRoutes(routes map[string]string) {
+	c.routes = routes
+}
+
+// topicFor returns the topic for an item type, falling back to the default topic
+func (c *Client) topicFor(itemType string) string {
+	if topic, ok := c.routes[RouteKey(itemType)]; ok {
+		return topic
+	}
+	return c.topic
+}
+
+// groupByTopic splits items by destination topic, keeping item order within each group
+// and ordering groups by first appearance
+func (c *Client) groupByTopic(items []ItemInfo) []topicGroup {
+	var groups []topicGroup
+	index := make(map[string]int)
+	for _, item := range items {
+		topic := c.topicFor(item.ItemType)
+		i, ok := index[topic]
+		if !ok {
+			i = len(groups)
+			index[topic] = i
+			groups = append(groups, topicGroup{topic: topic})
+		}
+		groups[i].items = append(groups[i].items, item)
+	}
+	return groups
+}
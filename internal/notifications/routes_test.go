@@ -0,0 +1,67 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRoutes(t *testing.T) {
+	routes := ParseRoutes([]string{
+		"NTFY_TOPIC=torn-oc-items",
+		"NOTIFY_ROUTE_DRUG=oc-drugs",
+		"NOTIFY_ROUTE_super rare=oc-rare",
+		"NOTIFY_ROUTE_TOOL=",
+	})
+
+	if len(routes) != 2 {
+		t.Fatalf("Expected 2 routes, got %v", routes)
+	}
+	if routes["DRUG"] != "oc-drugs" || routes["SUPER_RARE"] != "oc-rare" {
+		t.Errorf("Unexpected routes: %v", routes)
+	}
+}
+
+func TestGroupByTopic(t *testing.T) {
+	client := NewClient("https://ntfy.sh", "default", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	client.SetRoutes(map[string]string{"DRUG": "oc-drugs", "MEDICAL": "oc-drugs", "PRIMARY": "oc-weapons"})
+
+	groups := client.groupByTopic([]ItemInfo{
+		{ItemName: "Xanax", ItemType: "Drug"},
+		{ItemName: "Lockpicks", ItemType: "Tool"},
+		{ItemName: "AK-47", ItemType: "Primary"},
+		{ItemName: "Bandage", ItemType: "Medical"},
+		{ItemName: "Binoculars"},
+	})
+
+	expected := []struct {
+		topic string
+		items []string
+	}{
+		{"oc-drugs", []string{"Xanax", "Bandage"}},
+		{"default", []string{"Lockpicks", "Binoculars"}},
+		{"oc-weapons", []string{"AK-47"}},
+	}
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected %d groups, got %+v", len(expected), groups)
+	}
+	for i, want := range expected {
+		if groups[i].topic != want.topic || len(groups[i].items) != len(want.items) {
+			t.Errorf("Group %d: got %+v, want %+v", i, groups[i], want)
+			continue
+		}
+		for j, name := range want.items {
+			if groups[i].items[j].ItemName != name {
+				t.Errorf("Group %d item %d: got %s, want %s", i, j, groups[i].items[j].ItemName, name)
+			}
+		}
+	}
+}
+
+func TestGroupByTopicWithoutRoutes(t *testing.T) {
+	client := NewClient("https://ntfy.sh", "default", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+
+	groups := client.groupByTopic([]ItemInfo{{ItemName: "Xanax", ItemType: "Drug"}, {ItemName: "Lockpicks", ItemType: "Tool"}})
+	if len(groups) != 1 || groups[0].topic != "default" || len(groups[0].items) != 2 {
+		t.Errorf("Expected a single default group, got %+v", groups)
+	}
+}
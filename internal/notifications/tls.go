@@ -0,0 +1,60 @@
+package notifications
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// LoadTLSConfig builds the TLS settings for a self-hosted ntfy server behind mutual TLS.
+// certFile and keyFile hold a PEM client certificate and key and must be set together;
+// caFile holds PEM certificates trusted in addition to the system roots. Empty files are
+// skipped, and nil is returned when all three are empty.
+func LoadTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" && caFile == "" {
+		return nil, nil
+	}
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("client certificate and key must be set together")
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// SetTLSConfig sends notifications, health probes and fallback alerts through a transport
+// using config; nil keeps the default transport
+func (c *Client) SetTLSConfig(config *tls.Config) {
+	if config == nil {
+		return
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	c.httpClient.Transport = transport
+}
@@ -0,0 +1,76 @@
+package notifications
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadTLSConfigValidatesFiles(t *testing.T) {
+	if config, err := LoadTLSConfig("", "", ""); config != nil || err != nil {
+		t.Errorf("Expected no TLS config when unset, got %v, %v", config, err)
+	}
+	if _, err := LoadTLSConfig("client.pem", "", ""); err == nil {
+		t.Error("Expected an error for a certificate without a key")
+	}
+	if _, err := LoadTLSConfig("", "", filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("Expected an error for a missing CA file")
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTLSConfig("", "", empty); err == nil {
+		t.Error("Expected an error for a CA file without certificates")
+	}
+}
+
+func TestSendNotificationWithClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	// The test server's certificate doubles as the CA and the client certificate
+	dir := t.TempDir()
+	serverCert := server.TLS.Certificates[0]
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writePEM(t, certFile, "CERTIFICATE", serverCert.Certificate[0])
+	key, err := x509.MarshalPKCS8PrivateKey(serverCert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, keyFile, "PRIVATE KEY", key)
+
+	config, err := LoadTLSConfig(certFile, keyFile, certFile)
+	if err != nil {
+		t.Fatalf("Failed to load TLS config: %v", err)
+	}
+
+	client := NewClient(server.URL, "test", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	client.SetTLSConfig(config)
+	if err := client.SendNotification(context.Background(), "hello"); err != nil {
+		t.Errorf("Expected the notification to be accepted over mutual TLS, got %v", err)
+	}
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
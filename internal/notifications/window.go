@@ -0,0 +1,98 @@
+package notifications
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// batchWindow holds new items in batch mode so items trickling in over consecutive loops
+// go out as one message. The first held item starts a timer; when it fires, everything
+// held by then is sent.
+type batchWindow struct {
+	mu       sync.Mutex
+	duration time.Duration
+	items    []ItemInfo
+	total    int
+	timer    *time.Timer
+}
+
+// SetBatchWindow makes batch mode collect new items for window after the first one before
+// sending them in one message, set by NTFY_BATCH_WINDOW. Zero sends each loop's items as
+// they are added. Individual mode is unaffected.
+func (c *Client) SetBatchWindow(window time.Duration) {
+	c.window.mu.Lock()
+	c.window.duration = max(window, 0)
+	c.window.mu.Unlock()
+}
+
+// hold adds items to the window and reports whether it did; false means no window is set
+// and the caller should send them now
+func (w *batchWindow) hold(ctx context.Context, c *Client, items []ItemInfo, totalAdded int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.duration <= 0 {
+		return false
+	}
+
+	w.items = append(w.items, items...)
+	w.total += totalAdded
+	if w.timer == nil {
+		ctx = context.WithoutCancel(ctx)
+		// The pending send counts as in flight from now, so FlushBatch never starts waiting
+		// while the timer is about to add a send of its own
+		c.inflight.Add(1)
+		w.timer = time.AfterFunc(w.duration, func() {
+			defer c.inflight.Done()
+			c.sendHeld(ctx)
+		})
+		slog.Debug("Holding new items for the batch window", "items", len(items), "window", w.duration)
+	} else {
+		slog.Debug("Adding new items to the open batch window", "items", len(items), "held", len(w.items))
+	}
+	return true
+}
+
+// take empties the window, returning what it held. Stopping the timer before it fires
+// releases its in-flight count, since its callback won't run.
+func (w *batchWindow) take(c *Client) ([]ItemInfo, int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		if w.timer.Stop() {
+			c.inflight.Done()
+		}
+		w.timer = nil
+	}
+	items, total := w.items, w.total
+	w.items, w.total = nil, 0
+	return items, total
+}
+
+// sendHeld sends everything held in the window as one batch
+func (c *Client) sendHeld(ctx context.Context) {
+	items, total := c.window.take(c)
+	if len(items) == 0 {
+		return
+	}
+	slog.Info("Batch window closed, sending held items", "items", len(items))
+	c.notifyNewItems(ctx, items, total)
+}
+
+// FlushBatch sends any items held in the batch window and waits up to timeout for
+// notifications still being sent, so nothing is lost at shutdown
+func (c *Client) FlushBatch(ctx context.Context, timeout time.Duration) {
+	c.sendHeld(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		slog.Warn("Timed out waiting for notifications to send at shutdown", "timeout", timeout)
+	}
+}
@@ -0,0 +1,86 @@
+package notifications
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newRecordingServer collects the body of every notification it receives
+func newRecordingServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var messages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		messages = append(messages, string(body))
+		mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), messages...)
+	}
+}
+
+func TestBatchWindowCombinesItemsAcrossLoops(t *testing.T) {
+	server, messages := newRecordingServer(t)
+	client := NewClient(server.URL, "test", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	client.SetBatchWindow(50 * time.Millisecond)
+	ctx := context.Background()
+
+	client.NotifyNewItems(ctx, []ItemInfo{{ItemName: "Binoculars", UserName: "Alice"}}, 1)
+	client.NotifyNewItems(ctx, []ItemInfo{{ItemName: "Jemmy", UserName: "Bob"}}, 1)
+	if got := messages(); len(got) != 0 {
+		t.Fatalf("Expected items to be held during the window, got %q", got)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	got := messages()
+	if len(got) != 1 {
+		t.Fatalf("Expected one message once the window closed, got %q", got)
+	}
+	if !strings.Contains(got[0], "2 new items needed") || !strings.Contains(got[0], "Binoculars") || !strings.Contains(got[0], "Jemmy") {
+		t.Errorf("Expected both items in one batch, got %q", got[0])
+	}
+}
+
+func TestFlushBatchSendsHeldItems(t *testing.T) {
+	server, messages := newRecordingServer(t)
+	client := NewClient(server.URL, "test", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	client.SetBatchWindow(time.Hour)
+
+	client.NotifyNewItems(context.Background(), []ItemInfo{{ItemName: "Binoculars", UserName: "Alice"}}, 1)
+	client.FlushBatch(context.Background(), time.Second)
+
+	if got := messages(); len(got) != 1 || !strings.Contains(got[0], "Binoculars") {
+		t.Errorf("Expected the held item to be sent on flush, got %q", got)
+	}
+}
+
+func TestFlushBatchWhileWindowCloses(t *testing.T) {
+	server, messages := newRecordingServer(t)
+	client := NewClient(server.URL, "test", true, true, "default", 0, time.Millisecond, time.Millisecond, 0, false, "")
+	client.SetBatchWindow(time.Millisecond)
+
+	// The timer and the flush race to send the held item; either way it goes out once and
+	// the flush waits for it
+	for i := 0; i < 20; i++ {
+		client.NotifyNewItems(context.Background(), []ItemInfo{{ItemName: "Binoculars", UserName: "Alice"}}, 1)
+		time.Sleep(time.Duration(i%3) * time.Millisecond)
+		client.FlushBatch(context.Background(), time.Second)
+		if got := messages(); len(got) != i+1 {
+			t.Fatalf("Round %d: expected %d messages after flush, got %d", i, i+1, len(got))
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package processing
+
+import (
+	"log/slog"
+
+	"torn_oc_items/internal/torn"
+)
+
+// supplyKey identifies a needed item in a crime slot across loops
+type supplyKey struct {
+	crimeID int
+	itemID  int
+	userID  int
+}
+
+// SupplyConfirmer holds back supplied items until they have been seen in a number of
+// consecutive loops, so a slot that flickers to needed for a moment doesn't add a row and
+// notify. State is kept in memory only; a restart starts every count again.
+type SupplyConfirmer struct {
+	required int
+	seen     map[supplyKey]int
+}
+
+// NewSupplyConfirmer creates a confirmer requiring required consecutive sightings
+func NewSupplyConfirmer(required int) *SupplyConfirmer {
+	return &SupplyConfirmer{required: max(required, 1), seen: make(map[supplyKey]int)}
+}
+
+// Confirm records one loop's supplied items and returns those seen in at least the
+// required number of consecutive loops, this one included. Items missing from this loop
+// start over.
+func (c *SupplyConfirmer) Confirm(items []torn.SuppliedItem) []torn.SuppliedItem {
+	seen := make(map[supplyKey]int, len(items))
+	var confirmed []torn.SuppliedItem
+	pending := 0
+	for _, item := range items {
+		key := supplyKey{crimeID: item.CrimeID, itemID: item.ItemID, userID: item.UserID}
+		count, counted := seen[key]
+		if !counted {
+			count = min(c.seen[key]+1, c.required)
+			seen[key] = count
+		}
+		if count >= c.required {
+			confirmed = append(confirmed, item)
+		} else if !counted {
+			pending++
+		}
+	}
+	c.seen = seen
+
+	if pending > 0 {
+		slog.Debug("Holding supplied items until confirmed", "pending", pending, "confirmed", len(confirmed), "required_loops", c.required)
+	}
+	return confirmed
+}
@@ -0,0 +1,34 @@
+package processing
+
+import (
+	"testing"
+
+	"torn_oc_items/internal/torn"
+)
+
+func TestSupplyConfirmerRequiresConsecutiveLoops(t *testing.T) {
+	jemmy := torn.SuppliedItem{ItemID: 568, UserID: 2002, CrimeID: 100}
+	binoculars := torn.SuppliedItem{ItemID: 1258, UserID: 2001, CrimeID: 100}
+	confirmer := NewSupplyConfirmer(2)
+
+	if got := confirmer.Confirm([]torn.SuppliedItem{jemmy, binoculars}); len(got) != 0 {
+		t.Fatalf("Expected nothing confirmed on the first sighting, got %+v", got)
+	}
+	// Binoculars flickers out, so its count starts over
+	if got := confirmer.Confirm([]torn.SuppliedItem{jemmy}); len(got) != 1 || got[0] != jemmy {
+		t.Fatalf("Expected Jemmy confirmed on the second loop, got %+v", got)
+	}
+	if got := confirmer.Confirm([]torn.SuppliedItem{jemmy, binoculars}); len(got) != 1 || got[0] != jemmy {
+		t.Errorf("Expected Binoculars to need two loops again, got %+v", got)
+	}
+	if got := confirmer.Confirm([]torn.SuppliedItem{jemmy, binoculars}); len(got) != 2 {
+		t.Errorf("Expected both items confirmed, got %+v", got)
+	}
+}
+
+func TestSupplyConfirmerSingleLoop(t *testing.T) {
+	item := torn.SuppliedItem{ItemID: 568, UserID: 2002, CrimeID: 100}
+	if got := NewSupplyConfirmer(1).Confirm([]torn.SuppliedItem{item, item}); len(got) != 2 {
+		t.Errorf("Expected every item passed through with one required loop, got %+v", got)
+	}
+}
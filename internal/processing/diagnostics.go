@@ -0,0 +1,85 @@
+package processing
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"torn_oc_items/internal/resolution"
+	"torn_oc_items/internal/sheets"
+)
+
+// maxDiagnosticCandidates bounds how many near-miss rows are logged per unmatched log item
+const maxDiagnosticCandidates = 5
+
+// matchDiagnosticsLevel returns the level at which unmatched log items are explained and
+// whether diagnostics are enabled at all. MATCH_DIAGNOSTICS=true raises them to INFO;
+// otherwise they are only produced when DEBUG logging is enabled.
+func matchDiagnosticsLevel(ctx context.Context) (slog.Level, bool) {
+	if os.Getenv("MATCH_DIAGNOSTICS") == "true" {
+		return slog.LevelInfo, true
+	}
+	return slog.LevelDebug, slog.Default().Enabled(ctx, slog.LevelDebug)
+}
+
+// logUnmatchedLogItem explains why a log item matched no sheet row by listing the
+// closest rows that share either the receiver or the item
+func logUnmatchedLogItem(ctx context.Context, itemName string, itemID int, receiverName string, receiverID int, providerName string, sheetItems []sheets.SheetItem) {
+	level, enabled := matchDiagnosticsLevel(ctx)
+	if !enabled {
+		return
+	}
+
+	slog.Log(ctx, level, "Log item matched no sheet row",
+		"provider", providerName,
+		"receiver", receiverName,
+		"receiver_id", receiverID,
+		"item", itemName,
+		"item_id", itemID,
+	)
+
+	strategy := matchStrategy()
+	candidates := 0
+	for _, i := range sheets.NewestFirst(len(sheetItems)) {
+		if candidates >= maxDiagnosticCandidates {
+			break
+		}
+		sheetItem := sheetItems[i]
+		userMatches := resolution.MatchesUser(strategy, sheetItem.UserName, receiverName, receiverID)
+		itemMatches := resolution.MatchesItem(strategy, sheetItem.ItemName, itemName, itemID)
+		if !userMatches && !itemMatches {
+			continue
+		}
+
+		reason := "already has provider"
+		switch {
+		case !userMatches:
+			reason = "different user"
+		case !itemMatches:
+			reason = "different item"
+		}
+
+		slog.Log(ctx, level, "Closest non-matching sheet row",
+			"provider", providerName,
+			"row", sheetItem.RowIndex,
+			"sheet_user", sheetItem.UserName,
+			"sheet_item", sheetItem.ItemName,
+			"sheet_provider", sheetItem.Provider,
+			"reason", reason,
+		)
+		candidates++
+	}
+
+	if candidates == 0 {
+		slog.Log(ctx, level, "No sheet rows share this receiver or item", "provider", providerName, "receiver", receiverName, "item", itemName)
+	}
+}
+
+// logUnresolvedLogEntry explains a log entry skipped because a name could not be resolved
+func logUnresolvedLogEntry(ctx context.Context, providerName, field string, id int) {
+	level, enabled := matchDiagnosticsLevel(ctx)
+	if !enabled {
+		return
+	}
+	slog.Log(ctx, level, "Log entry skipped, could not resolve name", "provider", providerName, "field", field, "id", id)
+}
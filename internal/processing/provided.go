@@ -1,53 +1,132 @@
 package processing
 
 import (
+	"cmp"
 	"context"
 	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
-	"torn_oc_items/internal/config"
+	"torn_oc_items/internal/clock"
+	"torn_oc_items/internal/events"
+	"torn_oc_items/internal/notifications"
 	"torn_oc_items/internal/providers"
 	"torn_oc_items/internal/resolution"
-	"torn_oc_items/internal/retry"
 	"torn_oc_items/internal/sheets"
 	"torn_oc_items/internal/torn"
 )
 
-// ProcessProvidedItems handles the complete workflow of processing provided items
-func ProcessProvidedItems(ctx context.Context, tornClient *torn.Client, sheetsClient *sheets.Client, providerList []providers.Provider) {
-	slog.Debug("Starting provided items processing")
+var noProvidersOnce sync.Once
 
-	existingData, err := retry.WithRetry(ctx, config.DefaultResilienceConfig.SheetRead, func(ctx context.Context) ([][]interface{}, error) {
-		return sheets.ReadExistingSheetData(ctx, sheetsClient)
-	})
-	if err != nil {
-		slog.Error("Failed to read existing sheet data after retries, skipping provided items processing", "error", err)
+// ProcessProvidedItems handles the complete workflow of processing provided items.
+// existingData is the sheet as already read this loop, including any rows written since.
+// The phase is skipped entirely when no providers are configured.
+func ProcessProvidedItems(ctx context.Context, tornClient torn.TornAPI, sheetsClient *sheets.Client, existingData [][]interface{}, providerList []providers.Provider, notificationClient *notifications.Client, eventSink events.EventSink) {
+	if len(providerList) == 0 {
+		noProvidersOnce.Do(func() {
+			slog.Info("No providers configured, skipping the provided items phase; set PROVIDER_KEYS to match provider logs")
+		})
 		return
 	}
+	slog.Debug("Starting provided items processing")
 
 	sheetItems := sheets.ParseSheetItems(existingData)
 	slog.Debug("Parsed sheet items", "total_rows", len(existingData), "parsed_items", len(sheetItems))
 
-	logEntries := providers.AggregateLogs(ctx, providerList)
+	logEntries := filterByMaxAge(providers.AggregateLogs(ctx, providerList), providerMatchMaxAge(), clock.Now())
 
 	updates := FindProviderUpdates(ctx, tornClient, sheetItems, logEntries)
+	if len(updates) > 0 && sheets.EditedSinceRead(ctx, sheetsClient) {
+		// Rows may have moved or been filled in by hand, so match again against a fresh read
+		slog.Info("Sheet was edited since it was read, re-reading before writing provided items")
+		sheetsClient.ResetReadCache()
+		freshData, err := sheets.ReadExistingSheetData(ctx, sheetsClient)
+		if err != nil {
+			slog.Error("Failed to re-read edited sheet, deferring provided items to next loop", "error", err)
+			return
+		}
+		sheetItems = sheets.ParseSheetItems(freshData)
+		updates = FindProviderUpdates(ctx, tornClient, sheetItems, logEntries)
+	}
 	if len(updates) > 0 {
 		slog.Debug("Updating provided item rows", "updates", len(updates))
-		sheets.UpdateProvidedItemRows(ctx, sheetsClient, updates)
+		written := sheets.UpdateProvidedItemRows(ctx, sheetsClient, sheetItems, updates, notificationClient)
+		events.PublishAll(ctx, eventSink, providedEvents(sheetItems, written))
 	} else {
 		slog.Debug("No provided items to update")
 	}
 }
 
-// FindProviderUpdates finds updates for sheet items based on provider logs
-func FindProviderUpdates(ctx context.Context, tornClient *torn.Client, sheetItems []sheets.SheetItem, logEntries []providers.ProviderLogEntry) []sheets.SheetRowUpdate {
+// providedEvents describes each provider match for the event sink
+func providedEvents(sheetItems []sheets.SheetItem, updates []sheets.SheetRowUpdate) []events.Event {
+	byRow := make(map[int]sheets.SheetItem, len(sheetItems))
+	for _, item := range sheetItems {
+		byRow[item.RowIndex] = item
+	}
+
+	now := clock.Now()
+	var result []events.Event
+	for _, update := range updates {
+		item := byRow[update.RowIndex]
+		crimeID, _ := sheets.ParseCrimeID(item.CrimeURL)
+		result = append(result, events.Event{
+			Type:        events.TypeProvidedItem,
+			Time:        now,
+			CrimeID:     crimeID,
+			CrimeURL:    item.CrimeURL,
+			ItemName:    item.ItemName,
+			UserName:    item.UserName,
+			Provider:    update.Provider,
+			MarketValue: update.MarketValue,
+			Row:         update.RowIndex,
+		})
+	}
+	return result
+}
+
+// FindProviderUpdates finds updates for sheet items based on provider logs. A summary of
+// the matching is kept for LastMatchStats.
+//
+// Each row is credited at most once. Log entries are matched oldest first, ties broken by
+// provider name, so when two providers send the same item the earlier send claims the row
+// and the later one moves on to the next matching row, if any.
+func FindProviderUpdates(ctx context.Context, tornClient torn.TornAPI, sheetItems []sheets.SheetItem, logEntries []providers.ProviderLogEntry) []sheets.SheetRowUpdate {
 	var updates []sheets.SheetRowUpdate
+	stats := newMatchStats(sheetItems, len(logEntries))
+	defer func() {
+		stats.finish()
+		recordMatchStats(stats)
+	}()
 
 	slog.Debug("Starting provider update matching", "sheet_items", len(sheetItems), "log_entries", len(logEntries))
 
-	for _, ple := range logEntries {
-		logEntryUpdates := processLogEntryForUpdates(ctx, tornClient, ple.Entry, ple.ProviderName, sheetItems)
+	// Matched rows are claimed in this copy so the caller's items are left untouched
+	sheetItems = slices.Clone(sheetItems)
+	logEntries = slices.Clone(logEntries)
+	slices.SortStableFunc(logEntries, func(a, b providers.ProviderLogEntry) int {
+		return cmp.Or(cmp.Compare(a.Entry.Timestamp, b.Entry.Timestamp), cmp.Compare(a.ProviderName, b.ProviderName))
+	})
+
+	for i, ple := range logEntries {
+		if tornClient.BudgetExhausted() {
+			slog.Warn("API call budget reached, deferring remaining log entries to next loop",
+				"deferred", len(logEntries)-i,
+				"api_calls", tornClient.GetAPICallCount(),
+			)
+			break
+		}
+		var logEntryUpdates []sheets.SheetRowUpdate
+		if ple.Armory {
+			logEntryUpdates = processArmoryEntryForUpdates(ctx, tornClient, ple.Entry, ple.ProviderName, sheetItems)
+		} else {
+			logEntryUpdates = processLogEntryForUpdates(ctx, tornClient, ple.Entry, ple.ProviderName, sheetItems)
+		}
 		updates = append(updates, logEntryUpdates...)
+		stats.addEntry(ple.ProviderName, len(logEntryUpdates))
 	}
 
 	slog.Debug("Completed provider update matching", "updates_found", len(updates))
@@ -55,65 +134,426 @@ func FindProviderUpdates(ctx context.Context, tornClient *torn.Client, sheetItem
 }
 
 // processLogEntryForUpdates processes a single log entry and returns any updates found
-func processLogEntryForUpdates(ctx context.Context, tornClient *torn.Client, logEntry torn.LogEntry, providerName string, sheetItems []sheets.SheetItem) []sheets.SheetRowUpdate {
+func processLogEntryForUpdates(ctx context.Context, tornClient torn.TornAPI, logEntry torn.LogEntry, providerName string, sheetItems []sheets.SheetItem) []sheets.SheetRowUpdate {
 	var updates []sheets.SheetRowUpdate
 
 	receiverID := logEntry.Data.Receiver
+	if relayID := relayPlayerID(); relayID != 0 && receiverID == relayID {
+		return processRelayEntryForUpdates(ctx, tornClient, logEntry, providerName, sheetItems)
+	}
 	receiverName := resolution.GetUserNameByID(ctx, tornClient, receiverID)
 	if receiverName == "" {
+		logUnresolvedLogEntry(ctx, providerName, "receiver", receiverID)
 		return updates
 	}
 
+	messageCrimeID := parseMessageCrimeID(logEntry.Data.Message)
+	if messageCrimeID != 0 {
+		slog.Debug("Log entry message references crime", "provider", providerName, "crime_id", messageCrimeID)
+	}
+
 	for _, logItem := range logEntry.Data.Items {
-		itemUpdates := processLogItemForUpdates(ctx, tornClient, logItem, logEntry.Timestamp, receiverName, receiverID, providerName, sheetItems)
+		itemUpdates := processLogItemForUpdates(ctx, tornClient, logItem, logEntry.Timestamp, receiverName, receiverID, providerName, messageCrimeID, sheetItems)
 		updates = append(updates, itemUpdates...)
 	}
 
 	return updates
 }
 
-// processLogItemForUpdates processes a single log item and returns any updates found
-func processLogItemForUpdates(ctx context.Context, tornClient *torn.Client, logItem torn.LogItem, timestamp int64, receiverName string, receiverID int, providerName string, sheetItems []sheets.SheetItem) []sheets.SheetRowUpdate {
+// processLogItemForUpdates processes a single log item and returns any updates found. When the
+// send message names a crime, rows for that crime are preferred over the latest matching row.
+func processLogItemForUpdates(ctx context.Context, tornClient torn.TornAPI, logItem torn.LogItem, timestamp int64, receiverName string, receiverID int, providerName string, messageCrimeID int, sheetItems []sheets.SheetItem) []sheets.SheetRowUpdate {
 	var updates []sheets.SheetRowUpdate
 
 	itemID := logItem.ID
 	itemName := resolution.GetItemNameByID(ctx, tornClient, itemID)
 	if itemName == "" {
+		logUnresolvedLogEntry(ctx, providerName, "item", itemID)
 		return updates
 	}
 
-	for i := len(sheetItems) - 1; i >= 0; i-- {
-		sheetItem := sheetItems[i]
-		if !sheetItem.HasProvider &&
-			resolution.MatchesUser(sheetItem.UserName, receiverName, receiverID) &&
-			resolution.MatchesItem(sheetItem.ItemName, itemName, itemID) {
+	idx := -1
+	if messageCrimeID != 0 {
+		idx = findMatchingRow(sheetItems, itemName, itemID, receiverName, receiverID, timestamp, messageCrimeID)
+		if idx == -1 {
+			slog.Debug("No row for crime referenced in message, falling back to name matching", "crime_id", messageCrimeID, "item", itemName, "receiver", receiverName)
+		}
+	}
+	if idx == -1 {
+		idx = findMatchingRow(sheetItems, itemName, itemID, receiverName, receiverID, timestamp, 0)
+	}
 
-			update := createSheetRowUpdate(ctx, tornClient, sheetItem, itemID, timestamp, providerName)
-			updates = append(updates, update)
+	if idx != -1 {
+		sheetItem := sheetItems[idx]
+		update, ok := createSheetRowUpdate(ctx, tornClient, sheetItem, itemID, timestamp, providerName)
+		if !ok {
+			return updates
+		}
+		sheetItems[idx].HasProvider = true
+		updates = append(updates, update)
+
+		slog.Info("Found provided item match",
+			"row", sheetItem.RowIndex,
+			"item", sheetItem.ItemName,
+			"user", sheetItem.UserName,
+			"provider", providerName,
+			"market_value", update.MarketValue,
+		)
+	}
+
+	if len(updates) == 0 {
+		logUnmatchedLogItem(ctx, itemName, itemID, receiverName, receiverID, providerName, sheetItems)
+	}
+
+	return updates
+}
 
-			slog.Info("Found provided item match",
+// findMatchingRow returns the index of the latest sheet item without a provider matching
+// the receiver and item, or -1: bottommost, or topmost with SHEET_INSERT=top. With a
+// non-default MATCH_STRATEGY the best-ranked row wins instead, the latest among equals.
+// A non-zero crimeID restricts matches to that crime.
+func findMatchingRow(sheetItems []sheets.SheetItem, itemName string, itemID int, receiverName string, receiverID int, timestamp int64, crimeID int) int {
+	matchAfterAdded := matchAfterRowAddedEnabled()
+	strategy := matchStrategy()
+	best, bestRank := -1, resolution.NoMatch
+	for _, i := range sheets.NewestFirst(len(sheetItems)) {
+		sheetItem := sheetItems[i]
+		if matchAfterAdded && sheetItem.AddedAt.Unix() > timestamp {
+			slog.Debug("Skipping row added after log entry",
 				"row", sheetItem.RowIndex,
-				"item", sheetItem.ItemName,
-				"user", sheetItem.UserName,
-				"provider", providerName,
-				"market_value", update.MarketValue,
+				"added_at", sheetItem.AddedAt,
+				"log_timestamp", timestamp,
 			)
-			break
+			continue
+		}
+		if sheetItem.Status == ResolvedStatus() || sheetItem.Status == NonTradeableStatus {
+			continue
+		}
+		if crimeID != 0 {
+			if rowCrimeID, ok := sheets.ParseCrimeID(sheetItem.CrimeURL); !ok || rowCrimeID != crimeID {
+				continue
+			}
+		}
+		if sheetItem.HasProvider {
+			continue
+		}
+		userRank := resolution.UserMatchRank(strategy, sheetItem.UserName, receiverName, receiverID)
+		itemRank := resolution.ItemMatchRank(strategy, sheetItem.ItemName, itemName, itemID)
+		if userRank == resolution.NoMatch || itemRank == resolution.NoMatch {
+			continue
+		}
+		if strategy == resolution.MatchNameFirst {
+			return i
+		}
+		if rank := userRank + itemRank; rank > bestRank {
+			best, bestRank = i, rank
+		}
+	}
+	return best
+}
+
+// processArmoryEntryForUpdates credits the provider for armory deposits of needed items.
+// Deposits have no receiver, so each deposited item matches the latest needed row for
+// that item regardless of who it is for.
+func processArmoryEntryForUpdates(ctx context.Context, tornClient torn.TornAPI, logEntry torn.LogEntry, providerName string, sheetItems []sheets.SheetItem) []sheets.SheetRowUpdate {
+	var updates []sheets.SheetRowUpdate
+
+	for _, logItem := range logEntry.Data.Items {
+		itemName := resolution.GetItemNameByID(ctx, tornClient, logItem.ID)
+		if itemName == "" {
+			logUnresolvedLogEntry(ctx, providerName, "item", logItem.ID)
+			continue
+		}
+
+		idx := findArmoryRow(sheetItems, itemName, logItem.ID, logEntry.Timestamp)
+		if idx == -1 {
+			slog.Debug("No needed row for armory deposit", "item", itemName, "provider", providerName)
+			continue
+		}
+
+		sheetItem := sheetItems[idx]
+		update, ok := createSheetRowUpdate(ctx, tornClient, sheetItem, logItem.ID, logEntry.Timestamp, providerName)
+		if !ok {
+			continue
+		}
+		sheetItems[idx].HasProvider = true
+		updates = append(updates, update)
+
+		slog.Info("Found armory deposit match",
+			"row", sheetItem.RowIndex,
+			"item", sheetItem.ItemName,
+			"user", sheetItem.UserName,
+			"provider", providerName,
+			"market_value", update.MarketValue,
+		)
+	}
+
+	return updates
+}
+
+// findArmoryRow returns the index of the latest sheet item without a provider matching
+// the item for any member, or -1. With a non-default MATCH_STRATEGY the best-ranked row
+// wins instead, the latest among equals.
+func findArmoryRow(sheetItems []sheets.SheetItem, itemName string, itemID int, timestamp int64) int {
+	matchAfterAdded := matchAfterRowAddedEnabled()
+	strategy := matchStrategy()
+	best, bestRank := -1, resolution.NoMatch
+	for _, i := range sheets.NewestFirst(len(sheetItems)) {
+		sheetItem := sheetItems[i]
+		if matchAfterAdded && sheetItem.AddedAt.Unix() > timestamp {
+			continue
+		}
+		if sheetItem.HasProvider || sheetItem.Status == ResolvedStatus() || sheetItem.Status == NonTradeableStatus {
+			continue
+		}
+		rank := resolution.ItemMatchRank(strategy, sheetItem.ItemName, itemName, itemID)
+		if rank != resolution.NoMatch && strategy == resolution.MatchNameFirst {
+			return i
+		}
+		if rank > bestRank {
+			best, bestRank = i, rank
+		}
+	}
+	return best
+}
+
+// processRelayEntryForUpdates credits the provider for sends to the relay player, who
+// passes items on to members. The receiver says nothing about who the item is for, so
+// each item matches the latest needed row for that item added within RELAY_MATCH_WINDOW
+// before the send, preferring the crime named in the message.
+func processRelayEntryForUpdates(ctx context.Context, tornClient torn.TornAPI, logEntry torn.LogEntry, providerName string, sheetItems []sheets.SheetItem) []sheets.SheetRowUpdate {
+	var updates []sheets.SheetRowUpdate
+	window := relayMatchWindow()
+	messageCrimeID := parseMessageCrimeID(logEntry.Data.Message)
+
+	for _, logItem := range logEntry.Data.Items {
+		itemName := resolution.GetItemNameByID(ctx, tornClient, logItem.ID)
+		if itemName == "" {
+			logUnresolvedLogEntry(ctx, providerName, "item", logItem.ID)
+			continue
+		}
+
+		idx := -1
+		if messageCrimeID != 0 {
+			idx = findRelayRow(sheetItems, itemName, logItem.ID, logEntry.Timestamp, window, messageCrimeID)
+		}
+		if idx == -1 {
+			idx = findRelayRow(sheetItems, itemName, logItem.ID, logEntry.Timestamp, window, 0)
+		}
+		if idx == -1 {
+			slog.Debug("No needed row for relay send", "item", itemName, "provider", providerName, "window", window)
+			continue
 		}
+
+		sheetItem := sheetItems[idx]
+		update, ok := createSheetRowUpdate(ctx, tornClient, sheetItem, logItem.ID, logEntry.Timestamp, providerName)
+		if !ok {
+			continue
+		}
+		sheetItems[idx].HasProvider = true
+		updates = append(updates, update)
+
+		slog.Info("Found relay send match",
+			"row", sheetItem.RowIndex,
+			"item", sheetItem.ItemName,
+			"user", sheetItem.UserName,
+			"provider", providerName,
+			"market_value", update.MarketValue,
+		)
 	}
 
 	return updates
 }
 
-// createSheetRowUpdate creates a SheetRowUpdate with market value and formatted timestamp
-func createSheetRowUpdate(ctx context.Context, tornClient *torn.Client, sheetItem sheets.SheetItem, itemID int, timestamp int64, providerName string) sheets.SheetRowUpdate {
-	marketValue := resolution.GetItemMarketValue(ctx, tornClient, itemID)
-	dateTime := time.Unix(timestamp, 0).Format("15:04:05 - 02/01/06")
+// findRelayRow returns the index of the best-ranked open sheet item for the item, for any
+// member, added at most window before the send, or -1. Rows without an added time can't be
+// placed in the window and never match. A non-zero crimeID restricts matches to that crime.
+func findRelayRow(sheetItems []sheets.SheetItem, itemName string, itemID int, timestamp int64, window time.Duration, crimeID int) int {
+	strategy := matchStrategy()
+	sentAt := time.Unix(timestamp, 0)
+	best, bestRank := -1, resolution.NoMatch
+	for _, i := range sheets.NewestFirst(len(sheetItems)) {
+		sheetItem := sheetItems[i]
+		if sheetItem.AddedAt.IsZero() || sheetItem.AddedAt.After(sentAt) || sentAt.Sub(sheetItem.AddedAt) > window {
+			continue
+		}
+		if sheetItem.HasProvider || sheetItem.Status == ResolvedStatus() || sheetItem.Status == NonTradeableStatus {
+			continue
+		}
+		if crimeID != 0 {
+			if rowCrimeID, ok := sheets.ParseCrimeID(sheetItem.CrimeURL); !ok || rowCrimeID != crimeID {
+				continue
+			}
+		}
+		rank := resolution.ItemMatchRank(strategy, sheetItem.ItemName, itemName, itemID)
+		if rank != resolution.NoMatch && strategy == resolution.MatchNameFirst {
+			return i
+		}
+		if rank > bestRank {
+			best, bestRank = i, rank
+		}
+	}
+	return best
+}
+
+// relayPlayerID returns RELAY_PLAYER_ID, the player who receives items on the faction's
+// behalf and passes them on; zero (unset or invalid) disables relay matching
+func relayPlayerID() int {
+	id, err := strconv.Atoi(os.Getenv("RELAY_PLAYER_ID"))
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+// DefaultRelayMatchWindow is how long before a relay send a row may have been added
+const DefaultRelayMatchWindow = 24 * time.Hour
+
+// relayMatchWindow returns RELAY_MATCH_WINDOW, a Go duration such as "12h"
+func relayMatchWindow() time.Duration {
+	value := os.Getenv("RELAY_MATCH_WINDOW")
+	if value == "" {
+		return DefaultRelayMatchWindow
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil || window <= 0 {
+		slog.Warn("Invalid RELAY_MATCH_WINDOW, using the default", "relay_match_window", value, "default", DefaultRelayMatchWindow)
+		return DefaultRelayMatchWindow
+	}
+	return window
+}
+
+var strategyWarnOnce sync.Once
+
+// matchStrategy returns MATCH_STRATEGY, warning once and using name_first when invalid
+func matchStrategy() resolution.MatchStrategy {
+	value := os.Getenv("MATCH_STRATEGY")
+	strategy, ok := resolution.ParseMatchStrategy(value)
+	if !ok {
+		strategyWarnOnce.Do(func() {
+			slog.Warn("Unknown MATCH_STRATEGY, using name_first", "match_strategy", value)
+		})
+	}
+	return strategy
+}
+
+var (
+	messagePatternOnce sync.Once
+	messagePattern     *regexp.Regexp
+)
+
+// parseMessageCrimeID extracts a crime ID from a send message using MATCH_MESSAGE_PATTERN,
+// a regular expression whose first capture group is the crime ID. Returns 0 when the
+// pattern is unset or does not match.
+func parseMessageCrimeID(message string) int {
+	messagePatternOnce.Do(func() {
+		pattern := os.Getenv("MATCH_MESSAGE_PATTERN")
+		if pattern == "" {
+			return
+		}
+		compiled, err := regexp.Compile(pattern)
+		if err != nil || compiled.NumSubexp() < 1 {
+			slog.Warn("Invalid MATCH_MESSAGE_PATTERN, message matching disabled", "pattern", pattern, "error", err)
+			return
+		}
+		messagePattern = compiled
+	})
+
+	if messagePattern == nil || message == "" {
+		return 0
+	}
+	match := messagePattern.FindStringSubmatch(message)
+	if match == nil {
+		return 0
+	}
+	crimeID, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0
+	}
+	return crimeID
+}
+
+// matchAfterRowAddedEnabled reports whether log entries only match rows added (or reset)
+// before the item was sent, based on the timestamp in column I
+func matchAfterRowAddedEnabled() bool {
+	return os.Getenv("MATCH_AFTER_ROW_ADDED") == "true"
+}
+
+// providerMatchMaxAge returns PROVIDER_MATCH_MAX_AGE, a Go duration such as "24h"; only
+// sends newer than this can credit a provider. Zero (unset or invalid) uses every fetched entry.
+func providerMatchMaxAge() time.Duration {
+	value := os.Getenv("PROVIDER_MATCH_MAX_AGE")
+	if value == "" {
+		return 0
+	}
+	maxAge, err := time.ParseDuration(value)
+	if err != nil || maxAge < 0 {
+		slog.Warn("Invalid PROVIDER_MATCH_MAX_AGE, matching the whole fetch window", "provider_match_max_age", value)
+		return 0
+	}
+	return maxAge
+}
+
+// filterByMaxAge drops log entries sent more than maxAge before now; zero keeps them all
+func filterByMaxAge(logEntries []providers.ProviderLogEntry, maxAge time.Duration, now time.Time) []providers.ProviderLogEntry {
+	if maxAge <= 0 {
+		return logEntries
+	}
+	cutoff := now.Add(-maxAge).Unix()
+	kept := make([]providers.ProviderLogEntry, 0, len(logEntries))
+	for _, ple := range logEntries {
+		if ple.Entry.Timestamp >= cutoff {
+			kept = append(kept, ple)
+		}
+	}
+	if dropped := len(logEntries) - len(kept); dropped > 0 {
+		slog.Debug("Ignoring provider log entries older than the match age", "dropped", dropped, "max_age", maxAge)
+	}
+	return kept
+}
+
+// factionID returns FACTION_ID, used to build crime links that work outside the faction.
+// Zero (unset or invalid) keeps the "your faction" link form.
+func factionID() int {
+	id, err := strconv.Atoi(os.Getenv("FACTION_ID"))
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+// createSheetRowUpdate creates a SheetRowUpdate with market value and formatted timestamp.
+// The market value is left at zero without a lookup when SKIP_MARKET_VALUE is set. When
+// the lookup fails, MARKET_VALUE_UNAVAILABLE decides: ok is false for defer, and the row
+// should be left for the next loop.
+func createSheetRowUpdate(ctx context.Context, tornClient torn.TornAPI, sheetItem sheets.SheetItem, itemID int, timestamp int64, providerName string) (sheets.SheetRowUpdate, bool) {
+	update := sheets.SheetRowUpdate{
+		RowIndex: sheetItem.RowIndex,
+		Provider: providerName,
+		DateTime: time.Unix(timestamp, 0).In(clock.Location()).Format(sheets.DateTimeLayout),
+	}
+	if sheets.SkipMarketValue() {
+		return update, true
+	}
+
+	marketValue, ok := resolution.GetItemMarketValue(ctx, tornClient, itemID)
+	if ok {
+		update.MarketValue = marketValue
+		return update, true
+	}
 
-	return sheets.SheetRowUpdate{
-		RowIndex:    sheetItem.RowIndex,
-		Provider:    providerName,
-		DateTime:    dateTime,
-		MarketValue: marketValue,
+	mode := sheets.MarketValueUnavailable()
+	slog.Warn("Market value unavailable for provided item",
+		"row", sheetItem.RowIndex,
+		"item", sheetItem.ItemName,
+		"provider", providerName,
+		"market_value_unavailable", mode,
+	)
+	switch mode {
+	case sheets.MarketValueDefer:
+		return update, false
+	case sheets.MarketValueBlank:
+		update.MarketValueUnknown = true
 	}
+	return update, true
 }
@@ -1,9 +1,15 @@
 package processing
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"torn_oc_items/internal/events"
+	"torn_oc_items/internal/providers"
 	"torn_oc_items/internal/sheets"
+	"torn_oc_items/internal/torn"
 )
 
 // TestFindLatestMatchingRow verifies the core logic: when multiple rows match,
@@ -185,3 +191,406 @@ func TestBackwardIterationVsForward(t *testing.T) {
 		t.Error("Forward and backward iteration should produce different results")
 	}
 }
+
+// TestFindMatchingRow_PrefersMessageCrime verifies that a crime ID parsed from the
+// send message selects that crime's row instead of the bottommost match
+func TestFindMatchingRow_PrefersMessageCrime(t *testing.T) {
+	crimeURL := "http://www.torn.com/factions.php?step=your#/tab=crimes&crimeId="
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 10, CrimeURL: crimeURL + "111", ItemName: "Xanax", UserName: "Alice"},
+		{RowIndex: 20, CrimeURL: crimeURL + "222", ItemName: "Xanax", UserName: "Alice"},
+	}
+
+	if idx := findMatchingRow(sheetItems, "Xanax", 206, "Alice", 1, 0, 111); idx != 0 {
+		t.Errorf("Expected row for crime 111 (index 0), got index %d", idx)
+	}
+	if idx := findMatchingRow(sheetItems, "Xanax", 206, "Alice", 1, 0, 0); idx != 1 {
+		t.Errorf("Expected bottommost row (index 1) without crime hint, got index %d", idx)
+	}
+	if idx := findMatchingRow(sheetItems, "Xanax", 206, "Alice", 1, 0, 333); idx != -1 {
+		t.Errorf("Expected no match for unknown crime, got index %d", idx)
+	}
+}
+
+// TestFindArmoryRow verifies that armory deposits match the latest needed row for the
+// item regardless of member, skipping rows that already have a provider
+func TestFindArmoryRow(t *testing.T) {
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 10, ItemName: "Xanax", UserName: "Alice"},
+		{RowIndex: 20, ItemName: "Xanax", UserName: "Bob"},
+		{RowIndex: 30, ItemName: "Xanax", UserName: "Carol", HasProvider: true},
+		{RowIndex: 40, ItemName: "Vicodin", UserName: "Dana"},
+	}
+
+	if idx := findArmoryRow(sheetItems, "Xanax", 206, 0); idx != 1 {
+		t.Errorf("Expected latest unprovided Xanax row (index 1), got index %d", idx)
+	}
+	if idx := findArmoryRow(sheetItems, "Ecstasy", 197, 0); idx != -1 {
+		t.Errorf("Expected no match for an item nobody needs, got index %d", idx)
+	}
+}
+
+func TestFindMatchingRow_SkipsResolvedRows(t *testing.T) {
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 10, Status: "Needed", ItemName: "Xanax", UserName: "Alice"},
+		{RowIndex: 20, Status: "Resolved", ItemName: "Xanax", UserName: "Alice"},
+	}
+
+	if idx := findMatchingRow(sheetItems, "Xanax", 206, "Alice", 1, 0, 0); idx != 0 {
+		t.Errorf("Expected resolved row to be skipped (index 0), got index %d", idx)
+	}
+
+	t.Setenv("MATCH_ARMORY", "true")
+	if idx := findArmoryRow(sheetItems, "Xanax", 206, 0); idx != 0 {
+		t.Errorf("Expected resolved row to be skipped for armory deposits (index 0), got index %d", idx)
+	}
+}
+
+// TestFindMatchingRow_MatchStrategy verifies which of two conflicting rows, one holding the
+// member and item names and one the ID fallbacks, each strategy selects
+func TestFindMatchingRow_MatchStrategy(t *testing.T) {
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 10, ItemName: "Xanax", UserName: "Alice"},
+		{RowIndex: 20, ItemName: "Item ID: 206", UserName: "User ID: 1"},
+		{RowIndex: 30, ItemName: "Xanax", UserName: "Bob"},
+	}
+
+	cases := map[string]int{
+		"":           1,
+		"name_first": 1,
+		"id_first":   1,
+		"id_only":    1,
+	}
+	for strategy, want := range cases {
+		t.Setenv("MATCH_STRATEGY", strategy)
+		if idx := findMatchingRow(sheetItems, "Xanax", 206, "Alice", 1, 0, 0); idx != want {
+			t.Errorf("MATCH_STRATEGY=%q: expected index %d, got %d", strategy, want, idx)
+		}
+	}
+
+	t.Setenv("MATCH_STRATEGY", "id_only")
+	if idx := findMatchingRow(sheetItems[:1], "Xanax", 206, "Alice", 1, 0, 0); idx != -1 {
+		t.Errorf("Expected id_only not to match a name-only row, got index %d", idx)
+	}
+}
+
+// TestFindMatchingRow_DefaultKeepsLatestRow verifies the default strategy takes the
+// bottommost matching row even when a higher row matches by name rather than ID fallback
+func TestFindMatchingRow_DefaultKeepsLatestRow(t *testing.T) {
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 10, ItemName: "Item ID: 206", UserName: "User ID: 1"},
+		{RowIndex: 20, ItemName: "Xanax", UserName: "Alice"},
+		{RowIndex: 30, ItemName: "Item ID: 206", UserName: "Alice"},
+	}
+
+	if idx := findMatchingRow(sheetItems, "Xanax", 206, "Alice", 1, 0, 0); idx != 2 {
+		t.Errorf("Expected the bottommost matching row (index 2), got index %d", idx)
+	}
+	if idx := findArmoryRow(sheetItems, "Xanax", 206, 0); idx != 2 {
+		t.Errorf("Expected the bottommost armory row (index 2), got index %d", idx)
+	}
+}
+
+func TestFindMatchingRow_TopInsertPrefersTopmostRow(t *testing.T) {
+	t.Setenv("SHEET_INSERT", "top")
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 2, ItemName: "Xanax", UserName: "Alice"},
+		{RowIndex: 3, ItemName: "Xanax", UserName: "Alice"},
+	}
+
+	if idx := findMatchingRow(sheetItems, "Xanax", 206, "Alice", 1, 0, 0); idx != 0 {
+		t.Errorf("Expected the topmost (newest) row with top insertion, got index %d", idx)
+	}
+}
+
+func TestCreateSheetRowUpdate_SkipMarketValue(t *testing.T) {
+	ctx := context.Background()
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	sheetItem := sheets.SheetItem{RowIndex: 5}
+
+	update, _ := createSheetRowUpdate(ctx, tornClient, sheetItem, 568, 0, "Carol")
+	if tornClient.GetAPICallCount() != 1 {
+		t.Fatalf("Expected one market value lookup, got %d API calls", tornClient.GetAPICallCount())
+	}
+
+	t.Setenv("SKIP_MARKET_VALUE", "true")
+	update, _ = createSheetRowUpdate(ctx, tornClient, sheetItem, 568, 0, "Carol")
+	if tornClient.GetAPICallCount() != 1 {
+		t.Errorf("Expected no lookup with SKIP_MARKET_VALUE, got %d API calls", tornClient.GetAPICallCount())
+	}
+	if update.MarketValue != 0 || update.RowIndex != 5 || update.Provider != "Carol" {
+		t.Errorf("Unexpected update %+v", update)
+	}
+}
+
+func TestCreateSheetRowUpdate_MarketValueUnavailable(t *testing.T) {
+	ctx := context.Background()
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	sheetItem := sheets.SheetItem{RowIndex: 5, ItemName: "Item ID: 99999"}
+
+	cases := []struct {
+		mode    string
+		ok      bool
+		unknown bool
+	}{
+		{mode: "", ok: true, unknown: true},
+		{mode: "blank", ok: true, unknown: true},
+		{mode: "zero", ok: true, unknown: false},
+		{mode: "defer", ok: false},
+	}
+	for _, tc := range cases {
+		t.Setenv("MARKET_VALUE_UNAVAILABLE", tc.mode)
+		update, ok := createSheetRowUpdate(ctx, tornClient, sheetItem, 99999, 0, "Carol")
+		if ok != tc.ok {
+			t.Errorf("MARKET_VALUE_UNAVAILABLE=%q: expected ok %v, got %v", tc.mode, tc.ok, ok)
+		}
+		if ok && (update.MarketValueUnknown != tc.unknown || update.MarketValue != 0) {
+			t.Errorf("MARKET_VALUE_UNAVAILABLE=%q: unexpected update %+v", tc.mode, update)
+		}
+	}
+
+	// A worthless item is a known value, never blank or deferred
+	t.Setenv("MARKET_VALUE_UNAVAILABLE", "defer")
+	if update, ok := createSheetRowUpdate(ctx, tornClient, sheets.SheetItem{RowIndex: 5}, 568, 0, "Carol"); !ok || update.MarketValueUnknown {
+		t.Errorf("Expected a successful lookup to be used, got %+v, %v", update, ok)
+	}
+}
+
+// flakyItemClient serves the first ok item lookups and fails the rest, so a send's item
+// name resolves but its market value lookup fails
+type flakyItemClient struct {
+	*torn.MockClient
+	ok int
+}
+
+func (c *flakyItemClient) GetItem(ctx context.Context, itemID string) (*torn.Item, error) {
+	if c.ok == 0 {
+		return nil, errors.New("item lookup failed")
+	}
+	c.ok--
+	return c.MockClient.GetItem(ctx, itemID)
+}
+
+func TestFindProviderUpdates_MarketValueUnavailable(t *testing.T) {
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 2, Status: "Needed", ItemName: "Jemmy", UserName: "Bob"},
+	}
+	logEntries := []providers.ProviderLogEntry{sendLog("Carol", 1700000000, 2002, 568)}
+
+	t.Setenv("MARKET_VALUE_UNAVAILABLE", "defer")
+	tornClient := &flakyItemClient{MockClient: torn.NewMockClient("../../test/testdata/mock", "MockFaction", ""), ok: 1}
+	if updates := FindProviderUpdates(context.Background(), tornClient, sheetItems, logEntries); len(updates) != 0 {
+		t.Errorf("Expected the match to be deferred to the next loop, got %+v", updates)
+	}
+
+	t.Setenv("MARKET_VALUE_UNAVAILABLE", "blank")
+	tornClient.ok = 1
+	updates := FindProviderUpdates(context.Background(), tornClient, sheetItems, logEntries)
+	if len(updates) != 1 || !updates[0].MarketValueUnknown {
+		t.Errorf("Expected a match with the market value left blank, got %+v", updates)
+	}
+}
+
+// sendLog builds an item send log entry from a provider for the pipeline tests
+func sendLog(provider string, timestamp int64, receiver, itemID int) providers.ProviderLogEntry {
+	return providers.ProviderLogEntry{
+		ProviderName: provider,
+		Entry: torn.LogEntry{
+			Timestamp: timestamp,
+			Data:      torn.ItemSendData{Receiver: receiver, Items: []torn.LogItem{{ID: itemID, Qty: 1}}},
+		},
+	}
+}
+
+func TestFindProviderUpdates_MatchesNeededRow(t *testing.T) {
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 2, Status: "Needed", ItemName: "Jemmy", UserName: "Bob"},
+		{RowIndex: 3, Status: "Needed", ItemName: "Binoculars", UserName: "Bob"},
+	}
+
+	updates := FindProviderUpdates(context.Background(), tornClient, sheetItems, []providers.ProviderLogEntry{
+		sendLog("Carol", 1700000000, 2002, 568),
+	})
+	if len(updates) != 1 {
+		t.Fatalf("Expected 1 update, got %+v", updates)
+	}
+	if updates[0].RowIndex != 2 || updates[0].Provider != "Carol" || updates[0].MarketValue != 1750000 {
+		t.Errorf("Unexpected update %+v", updates[0])
+	}
+	if sheetItems[0].HasProvider {
+		t.Error("Expected the caller's sheet items to be left untouched")
+	}
+}
+
+func TestFindProviderUpdates_SkipsProvidedRow(t *testing.T) {
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 2, Status: "Provided", ItemName: "Jemmy", UserName: "Bob", Provider: "Erin", HasProvider: true},
+	}
+
+	updates := FindProviderUpdates(context.Background(), tornClient, sheetItems, []providers.ProviderLogEntry{
+		sendLog("Carol", 1700000000, 2002, 568),
+	})
+	if len(updates) != 0 {
+		t.Errorf("Expected an already provided row to be skipped, got %+v", updates)
+	}
+}
+
+func TestFindProviderUpdates_RenamedReceiverMatchesIDFallback(t *testing.T) {
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	// The row was written while Bob's name couldn't be resolved
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 2, Status: "Needed", ItemName: "Jemmy", UserName: "Robert"},
+		{RowIndex: 3, Status: "Needed", ItemName: "Jemmy", UserName: "User ID: 2002"},
+	}
+
+	updates := FindProviderUpdates(context.Background(), tornClient, sheetItems, []providers.ProviderLogEntry{
+		sendLog("Carol", 1700000000, 2002, 568),
+	})
+	if len(updates) != 1 || updates[0].RowIndex != 3 {
+		t.Errorf("Expected the ID fallback row to match, got %+v", updates)
+	}
+}
+
+func TestFindProviderUpdates_SameItemFromTwoProviders(t *testing.T) {
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 2, Status: "Needed", ItemName: "Jemmy", UserName: "Bob"},
+	}
+	logs := []providers.ProviderLogEntry{
+		sendLog("Erin", 1700000100, 2002, 568),
+		sendLog("Carol", 1700000000, 2002, 568),
+	}
+
+	// The earlier send claims the row regardless of the order logs were aggregated in
+	for _, order := range [][]providers.ProviderLogEntry{logs, {logs[1], logs[0]}} {
+		tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+		updates := FindProviderUpdates(context.Background(), tornClient, sheetItems, order)
+		if len(updates) != 1 {
+			t.Fatalf("Expected a single update, got %+v", updates)
+		}
+		if updates[0].RowIndex != 2 || updates[0].Provider != "Carol" {
+			t.Errorf("Expected Carol's earlier send to claim row 2, got %+v", updates[0])
+		}
+	}
+}
+
+func TestFindRelayRow(t *testing.T) {
+	sent := time.Unix(1700000000, 0)
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 10, Status: "Needed", ItemName: "Jemmy", UserName: "Alice", AddedAt: sent.Add(-2 * time.Hour), CrimeURL: sheets.CrimeURL(0, 101)},
+		{RowIndex: 20, Status: "Needed", ItemName: "Jemmy", UserName: "Bob", AddedAt: sent.Add(-time.Hour)},
+		{RowIndex: 30, Status: "Needed", ItemName: "Jemmy", UserName: "Carol", AddedAt: sent.Add(time.Minute)},
+		{RowIndex: 40, Status: "Needed", ItemName: "Jemmy", UserName: "Dana"},
+		{RowIndex: 50, Status: "Needed", ItemName: "Jemmy", UserName: "Erin", AddedAt: sent.Add(-48 * time.Hour)},
+	}
+
+	if idx := findRelayRow(sheetItems, "Jemmy", 568, sent.Unix(), 24*time.Hour, 0); idx != 1 {
+		t.Errorf("Expected the latest row added within the window before the send (index 1), got %d", idx)
+	}
+	if idx := findRelayRow(sheetItems, "Jemmy", 568, sent.Unix(), 24*time.Hour, 101); idx != 0 {
+		t.Errorf("Expected the row for the crime in the message (index 0), got %d", idx)
+	}
+	if idx := findRelayRow(sheetItems, "Jemmy", 568, sent.Unix(), 30*time.Minute, 0); idx != -1 {
+		t.Errorf("Expected no match when every row is outside the window, got %d", idx)
+	}
+}
+
+func TestFindProviderUpdates_RelaySendMatchesByItem(t *testing.T) {
+	t.Setenv("RELAY_PLAYER_ID", "9999")
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	sent := time.Unix(1700000000, 0)
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 2, Status: "Needed", ItemName: "Jemmy", UserName: "Bob", AddedAt: sent.Add(-time.Hour)},
+		{RowIndex: 3, Status: "Needed", ItemName: "Binoculars", UserName: "Alice", AddedAt: sent.Add(-time.Hour)},
+	}
+
+	updates := FindProviderUpdates(context.Background(), tornClient, sheetItems, []providers.ProviderLogEntry{
+		sendLog("Carol", sent.Unix(), 9999, 568),
+	})
+	if len(updates) != 1 || updates[0].RowIndex != 2 || updates[0].Provider != "Carol" {
+		t.Errorf("Expected the send to the relay to credit the Jemmy row, got %+v", updates)
+	}
+
+	t.Setenv("RELAY_PLAYER_ID", "")
+	if updates := FindProviderUpdates(context.Background(), tornClient, sheetItems, []providers.ProviderLogEntry{
+		sendLog("Carol", sent.Unix(), 9999, 568),
+	}); len(updates) != 0 {
+		t.Errorf("Expected no match for the relay without RELAY_PLAYER_ID, got %+v", updates)
+	}
+}
+
+func TestFilterByMaxAge(t *testing.T) {
+	now := time.Unix(100000, 0)
+	entries := []providers.ProviderLogEntry{
+		sendLog("Carol", now.Add(-25*time.Hour).Unix(), 2001, 1258),
+		sendLog("Carol", now.Add(-24*time.Hour).Unix(), 2002, 159),
+		sendLog("Carol", now.Add(-time.Hour).Unix(), 2003, 568),
+	}
+
+	if kept := filterByMaxAge(entries, 0, now); len(kept) != 3 {
+		t.Errorf("Expected every entry kept without a max age, got %d", len(kept))
+	}
+	kept := filterByMaxAge(entries, 24*time.Hour, now)
+	if len(kept) != 2 || kept[0].Entry.Data.Receiver != 2002 || kept[1].Entry.Data.Receiver != 2003 {
+		t.Errorf("Expected the entries within 24h, got %+v", kept)
+	}
+
+	t.Setenv("PROVIDER_MATCH_MAX_AGE", "24h")
+	if got := providerMatchMaxAge(); got != 24*time.Hour {
+		t.Errorf("Expected 24h, got %v", got)
+	}
+	t.Setenv("PROVIDER_MATCH_MAX_AGE", "a day")
+	if got := providerMatchMaxAge(); got != 0 {
+		t.Errorf("Expected an invalid age to disable the filter, got %v", got)
+	}
+}
+
+func TestProcessProvidedItemsRereadsEditedSheet(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "mock")
+	t.Setenv("SPREADSHEET_RANGE", "Mock Sheet!A1")
+	ctx := context.Background()
+
+	sheetsClient, err := sheets.NewMockClient("")
+	if err != nil {
+		t.Fatalf("Failed to create mock sheet: %v", err)
+	}
+	sheetsClient.EnableEditCheck(true)
+	header := []interface{}{"Status", "Provider", "Crime", "Time", "Item", "User"}
+	if err := sheetsClient.AppendRows(ctx, "mock", "Mock Sheet!A1", [][]interface{}{
+		header,
+		{"Needed", "", sheets.CrimeURL(0, 1), "", "Jemmy", "Dana"},
+	}); err != nil {
+		t.Fatalf("Failed to seed mock sheet: %v", err)
+	}
+	existingData, err := sheets.ReadExistingSheetData(ctx, sheetsClient)
+	if err != nil {
+		t.Fatalf("Failed to read mock sheet: %v", err)
+	}
+
+	// An organizer inserts a row above ours after the loop read the sheet
+	if err := sheetsClient.InsertRows(ctx, "mock", "Mock Sheet", 1, [][]interface{}{
+		{"Needed", "", sheets.CrimeURL(0, 2), "", "Binoculars", "Alice"},
+	}); err != nil {
+		t.Fatalf("Failed to edit mock sheet: %v", err)
+	}
+	if !sheets.EditedSinceRead(ctx, sheetsClient) {
+		t.Fatal("Expected the edit to be detected")
+	}
+
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	provider := providers.Provider{Name: "Carol", Client: torn.NewMockClient("../../test/testdata/mock", "Carol", "logs_Carol.json"), Health: &providers.Health{}}
+	ProcessProvidedItems(ctx, tornClient, sheetsClient, existingData, []providers.Provider{provider}, nil, events.NopSink{})
+
+	data, err := sheetsClient.ReadSheet(ctx, "mock", "Mock Sheet!A1:F10")
+	if err != nil {
+		t.Fatalf("Failed to read mock sheet: %v", err)
+	}
+	// Without the re-read, the Jemmy update would land on row 2, which is now Alice's
+	if len(data) != 3 || data[2][0] != "Provided" || data[2][1] != "Carol" || data[2][4] != "Jemmy" {
+		t.Errorf("Expected the moved Jemmy row to be provided by Carol, got %v", data)
+	}
+	if data[1][4] != "Binoculars" || data[1][1] != "Carol" {
+		t.Errorf("Expected the inserted row to be matched after the re-read, got %v", data[1])
+	}
+}
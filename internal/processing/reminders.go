@@ -0,0 +1,74 @@
+package processing
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"torn_oc_items/internal/clock"
+	"torn_oc_items/internal/notifications"
+	"torn_oc_items/internal/sheets"
+)
+
+// NeedReminder sends a periodic summary of rows still "Needed" without a provider, so
+// items nobody picked up aren't forgotten. Each row is included at most once per interval,
+// counted from when it was added. State is kept in memory only; after a restart, rows
+// already older than the interval are included in the first reminder.
+type NeedReminder struct {
+	interval time.Duration
+	// When each open row's interval started: its added time, or its last reminder
+	since map[string]time.Time
+}
+
+// NewNeedReminder creates a reminder repeating every interval
+func NewNeedReminder(interval time.Duration) *NeedReminder {
+	return &NeedReminder{interval: interval, since: make(map[string]time.Time)}
+}
+
+// Remind scans the sheet for open rows due a reminder and sends them as one notification,
+// returning how many rows it included. A row is due once it has been open for the interval
+// and again each interval after its last reminder. Rows without an added time count from
+// when they were first seen. Rows that are no longer open are forgotten.
+func (r *NeedReminder) Remind(ctx context.Context, existingData [][]interface{}, notificationClient *notifications.Client) int {
+	now := clock.Now()
+	open := make(map[string]time.Time)
+	var due []notifications.ReminderInfo
+	for _, item := range sheets.ParseSheetItems(existingData) {
+		if item.Status != "Needed" || item.HasProvider {
+			continue
+		}
+		key := sheets.ExistingKey(item.CrimeURL, item.UserName, item.ItemName)
+		if _, seen := open[key]; seen {
+			continue
+		}
+
+		since, tracked := r.since[key]
+		if !tracked {
+			since = item.AddedAt
+			if since.IsZero() {
+				since = now
+			}
+		}
+		if now.Sub(since) < r.interval {
+			open[key] = since
+			continue
+		}
+
+		open[key] = now
+		due = append(due, notifications.ReminderInfo{
+			ItemName: item.ItemName,
+			UserName: item.UserName,
+			CrimeURL: item.CrimeURL,
+			AddedAt:  item.AddedAt,
+		})
+	}
+	r.since = open
+
+	if len(due) > 0 {
+		slog.Info("Reminding of items still needed", "due", len(due), "open", len(open), "interval", r.interval)
+		if notificationClient != nil {
+			notificationClient.NotifyNeededReminder(ctx, due, now)
+		}
+	}
+	return len(due)
+}
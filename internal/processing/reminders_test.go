@@ -0,0 +1,63 @@
+package processing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"torn_oc_items/internal/clock"
+	"torn_oc_items/internal/sheets"
+)
+
+func TestNeedReminderRepeatsEachInterval(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 3, 14, 12, 0, 0, 0, time.Local)
+	t.Cleanup(func() { clock.SetFixed(time.Time{}) })
+
+	row := func(status, provider, item string, added time.Time) []interface{} {
+		addedAt := ""
+		if !added.IsZero() {
+			addedAt = added.Format(sheets.DateTimeLayout)
+		}
+		return []interface{}{status, provider, "https://www.torn.com/factions.php#/tab=crimes&crimeId=101", "", item, "Alice", "", "", addedAt}
+	}
+	data := [][]interface{}{
+		{"Status", "Provider", "Crime", "", "Item", "User", "", "", "Added"},
+		row("Needed", "", "Binoculars", start.Add(-2*time.Hour)),
+		row("Needed", "", "Jemmy", start.Add(-30*time.Minute)),
+		row("Needed", "Carol", "Bolt Cutters", start.Add(-5*time.Hour)),
+		row("Resolved", "", "Lockpicks", start.Add(-5*time.Hour)),
+		row("Needed", "", "Hammer", time.Time{}),
+	}
+	reminder := NewNeedReminder(time.Hour)
+
+	clock.SetFixed(start)
+	if due := reminder.Remind(ctx, data, nil); due != 1 {
+		t.Errorf("Expected only the row open for 2h to be due, got %d", due)
+	}
+
+	clock.SetFixed(start.Add(45 * time.Minute))
+	if due := reminder.Remind(ctx, data, nil); due != 1 {
+		t.Errorf("Expected the Jemmy row to become due after an hour open, got %d", due)
+	}
+
+	// The Binoculars row was reminded at start and the undated Hammer row first seen then
+	clock.SetFixed(start.Add(time.Hour))
+	if due := reminder.Remind(ctx, data, nil); due != 2 {
+		t.Errorf("Expected the Binoculars and Hammer rows to be due again, got %d", due)
+	}
+
+	// Filling a row forgets it, so if reopened it counts from its added time again
+	opened := data[1]
+	data[1] = row("Needed", "Carol", "Binoculars", start.Add(-2*time.Hour))
+	clock.SetFixed(start.Add(2 * time.Hour))
+	if due := reminder.Remind(ctx, data, nil); due != 2 {
+		t.Errorf("Expected the Jemmy and Hammer rows to be due, got %d", due)
+	}
+
+	data[1] = opened
+	clock.SetFixed(start.Add(2*time.Hour + 15*time.Minute))
+	if due := reminder.Remind(ctx, data, nil); due != 1 {
+		t.Errorf("Expected the reopened Binoculars row to be due at once, got %d", due)
+	}
+}
@@ -0,0 +1,81 @@
+package processing
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"torn_oc_items/internal/config"
+	"torn_oc_items/internal/resolution"
+	"torn_oc_items/internal/sheets"
+	"torn_oc_items/internal/torn"
+)
+
+// reresolveFallbacksEnabled reports whether RERESOLVE_FALLBACKS replaces "Item ID: X" and
+// "User ID: X" fallback names with the real names once they resolve
+func reresolveFallbacksEnabled() bool {
+	return os.Getenv("RERESOLVE_FALLBACKS") == "true"
+}
+
+// ReresolveFallbacks looks up the real names for rows written with an ID fallback because
+// resolution failed at the time, and rewrites those rows in place. It runs before the
+// supplied phase so a freshly resolved name isn't appended again as a new row.
+func ReresolveFallbacks(ctx context.Context, tornClient torn.TornAPI, sheetsClient *sheets.Client) {
+	if !reresolveFallbacksEnabled() {
+		return
+	}
+
+	existingData, err := sheets.ReadExistingSheetDataWithRetry(ctx, sheetsClient, config.DefaultResilienceConfig.SheetRead)
+	if err != nil {
+		slog.Error("Failed to read existing sheet data after retries, skipping fallback re-resolution", "error", err)
+		return
+	}
+
+	updates := findNameUpdates(ctx, tornClient, sheets.ParseSheetItems(existingData))
+	if len(updates) == 0 {
+		return
+	}
+	updated := sheets.UpdateRowNames(ctx, sheetsClient, updates)
+	slog.Info("Re-resolved fallback names", "rows", len(updates), "updated", updated)
+}
+
+// findNameUpdates resolves the fallback names in sheetItems, returning an update for each
+// row where at least one name now resolves. Names that still fail keep their fallback.
+func findNameUpdates(ctx context.Context, tornClient torn.TornAPI, sheetItems []sheets.SheetItem) []sheets.NameUpdate {
+	var updates []sheets.NameUpdate
+	for _, item := range sheetItems {
+		if item.ItemIDFallback == 0 && item.UserIDFallback == 0 {
+			continue
+		}
+		if tornClient.BudgetExhausted() {
+			slog.Warn("API call budget reached, deferring fallback re-resolution to next loop")
+			break
+		}
+
+		update := sheets.NameUpdate{RowIndex: item.RowIndex, ItemName: item.ItemName, UserName: item.UserName}
+		changed := false
+		if item.ItemIDFallback != 0 {
+			if name := resolution.GetItemNameByID(ctx, tornClient, item.ItemIDFallback); name != "" {
+				update.ItemName = name
+				changed = true
+			}
+		}
+		if item.UserIDFallback != 0 {
+			if name := resolution.GetUserNameByID(ctx, tornClient, item.UserIDFallback); name != "" {
+				update.UserName = name
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		slog.Info("Resolved fallback names for row",
+			"row", item.RowIndex,
+			"item", update.ItemName,
+			"user", update.UserName,
+		)
+		updates = append(updates, update)
+	}
+	return updates
+}
@@ -0,0 +1,35 @@
+package processing
+
+import (
+	"context"
+	"testing"
+
+	"torn_oc_items/internal/sheets"
+	"torn_oc_items/internal/torn"
+)
+
+func TestFindNameUpdatesReplacesResolvableFallbacks(t *testing.T) {
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	sheetItems := sheets.ParseSheetItems([][]interface{}{
+		{"Status", "Provider", "Crime", "DateTime", "Item", "User"},
+		{"Needed", "", sheets.CrimeURL(0, 1), "", "Item ID: 1258", "Alice"},
+		{"Needed", "", sheets.CrimeURL(0, 1), "", "Jemmy", "User ID: 2002"},
+		{"Needed", "", sheets.CrimeURL(0, 1), "", "Item ID: 99999", "User ID: 99999"},
+		{"Needed", "", sheets.CrimeURL(0, 1), "", "Bolt Cutters", "Dana"},
+	})
+
+	updates := findNameUpdates(context.Background(), tornClient, sheetItems)
+
+	expected := []sheets.NameUpdate{
+		{RowIndex: 2, ItemName: "Binoculars", UserName: "Alice"},
+		{RowIndex: 3, ItemName: "Jemmy", UserName: "Bob"},
+	}
+	if len(updates) != len(expected) {
+		t.Fatalf("Expected %d updates, got %+v", len(expected), updates)
+	}
+	for i, want := range expected {
+		if updates[i] != want {
+			t.Errorf("Update %d: got %+v, want %+v", i, updates[i], want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package processing
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"torn_oc_items/internal/config"
+	"torn_oc_items/internal/resolution"
+	"torn_oc_items/internal/sheets"
+	"torn_oc_items/internal/torn"
+)
+
+// resolveAvailableEnabled reports whether RESOLVE_AVAILABLE_ITEMS marks needed reusable
+// items that became available without a provider
+func resolveAvailableEnabled() bool {
+	return os.Getenv("RESOLVE_AVAILABLE_ITEMS") == "true"
+}
+
+// ResolvedStatus is the status written to rows whose reusable item became available on
+// its own, set by RESOLVED_STATUS
+func ResolvedStatus() string {
+	if status := os.Getenv("RESOLVED_STATUS"); status != "" {
+		return status
+	}
+	return "Resolved"
+}
+
+// NonTradeableStatus is the status of rows for non-tradeable items with
+// NON_TRADEABLE_ITEMS=mark; the member has to acquire these themselves
+const NonTradeableStatus = "Non-tradeable"
+
+// availableSlot identifies a crime slot whose reusable item is now available
+type availableSlot struct {
+	crimeID int
+	itemID  int
+	userID  int
+}
+
+// ResolveAvailableItems marks "Needed" rows without a provider as resolved when the slot's
+// reusable item is now available in the live crime data, e.g. because the member acquired
+// it themselves. No provider is credited.
+func ResolveAvailableItems(ctx context.Context, tornClient torn.TornAPI, sheetsClient *sheets.Client) {
+	if !resolveAvailableEnabled() {
+		return
+	}
+
+	existingData, err := sheets.ReadExistingSheetDataWithRetry(ctx, sheetsClient, config.DefaultResilienceConfig.SheetRead)
+	if err != nil {
+		slog.Error("Failed to read existing sheet data after retries, skipping availability check", "error", err)
+		return
+	}
+
+	neededCrimes := make(map[int]bool)
+	var needed []sheets.SheetItem
+	for _, item := range sheets.ParseSheetItems(existingData) {
+		if item.Status != "Needed" || item.HasProvider {
+			continue
+		}
+		if crimeID, ok := sheets.ParseCrimeID(item.CrimeURL); ok {
+			neededCrimes[crimeID] = true
+			needed = append(needed, item)
+		}
+	}
+	if len(needed) == 0 {
+		return
+	}
+
+	var slots []availableSlot
+	for _, category := range tornClient.CrimeCategories() {
+		crimesResp, err := tornClient.GetFactionCrimes(ctx, category, 0)
+		if err != nil {
+			slog.Warn("Failed to get faction crimes for availability check", "category", category, "error", err)
+			return
+		}
+		slots = append(slots, findAvailableSlots(crimesResp.Crimes, neededCrimes)...)
+	}
+
+	var rowIndexes []int
+	for _, slot := range slots {
+		if tornClient.BudgetExhausted() {
+			slog.Warn("API call budget reached, deferring availability check to next loop")
+			break
+		}
+		itemName := resolution.GetItemDetails(ctx, tornClient, slot.itemID)
+		userName := resolution.GetUserDetails(ctx, tornClient, slot.userID)
+
+		for _, item := range needed {
+			crimeID, ok := sheets.ParseCrimeID(item.CrimeURL)
+			if ok && crimeID == slot.crimeID && item.ItemName == itemName && item.UserName == userName {
+				slog.Info("Needed item is now available, marking row resolved",
+					"row", item.RowIndex,
+					"crime_id", slot.crimeID,
+					"item", itemName,
+					"user", userName,
+				)
+				rowIndexes = append(rowIndexes, item.RowIndex)
+			}
+		}
+	}
+
+	if len(rowIndexes) > 0 {
+		updated := sheets.UpdateRowStatuses(ctx, sheetsClient, rowIndexes, ResolvedStatus())
+		slog.Info("Resolved rows for items that became available", "resolved", updated)
+	}
+}
+
+// findAvailableSlots returns the occupied slots of the given crimes whose reusable item
+// requirement is now available
+func findAvailableSlots(crimes []torn.Crime, crimeIDs map[int]bool) []availableSlot {
+	var slots []availableSlot
+	for _, crime := range crimes {
+		if !crimeIDs[crime.ID] {
+			continue
+		}
+		for _, slot := range crime.Slots {
+			req := slot.ItemRequirement
+			if req == nil || slot.User == nil || !req.IsReusable || !req.IsAvailable {
+				continue
+			}
+			slots = append(slots, availableSlot{crimeID: crime.ID, itemID: req.ID, userID: slot.User.ID})
+		}
+	}
+	return slots
+}
@@ -0,0 +1,35 @@
+package processing
+
+import (
+	"testing"
+
+	"torn_oc_items/internal/torn"
+)
+
+func TestFindAvailableSlots(t *testing.T) {
+	crimes := []torn.Crime{
+		{
+			ID: 101,
+			Slots: []torn.Slot{
+				{ItemRequirement: &torn.ItemRequirement{ID: 568, IsReusable: true, IsAvailable: true}, User: &torn.User{ID: 1}},
+				{ItemRequirement: &torn.ItemRequirement{ID: 568, IsReusable: true, IsAvailable: false}, User: &torn.User{ID: 2}},
+				{ItemRequirement: &torn.ItemRequirement{ID: 206, IsReusable: false, IsAvailable: true}, User: &torn.User{ID: 3}},
+				{ItemRequirement: &torn.ItemRequirement{ID: 568, IsReusable: true, IsAvailable: true}},
+			},
+		},
+		{
+			ID: 102,
+			Slots: []torn.Slot{
+				{ItemRequirement: &torn.ItemRequirement{ID: 568, IsReusable: true, IsAvailable: true}, User: &torn.User{ID: 4}},
+			},
+		},
+	}
+
+	slots := findAvailableSlots(crimes, map[int]bool{101: true})
+	if len(slots) != 1 {
+		t.Fatalf("Expected 1 available slot, got %d: %+v", len(slots), slots)
+	}
+	if slots[0] != (availableSlot{crimeID: 101, itemID: 568, userID: 1}) {
+		t.Errorf("Unexpected slot %+v", slots[0])
+	}
+}
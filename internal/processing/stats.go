@@ -0,0 +1,82 @@
+package processing
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"torn_oc_items/internal/clock"
+	"torn_oc_items/internal/sheets"
+)
+
+// MatchStats summarizes one loop's provider matching for external dashboards
+type MatchStats struct {
+	Time time.Time `json:"time"`
+	// Parsed sheet rows, and those still without a provider
+	SheetItems int `json:"sheet_items"`
+	OpenItems  int `json:"open_items"`
+	// Provider log entries fetched, and those scanned before any API budget ran out
+	LogEntries     int                  `json:"log_entries"`
+	ScannedEntries int                  `json:"scanned_entries"`
+	Matches        int                  `json:"matches"`
+	Providers      []ProviderMatchStats `json:"providers"`
+}
+
+// ProviderMatchStats is one provider's contribution to a loop's matching
+type ProviderMatchStats struct {
+	Name       string `json:"name"`
+	LogEntries int    `json:"log_entries"`
+	Matches    int    `json:"matches"`
+}
+
+var (
+	matchStatsMu   sync.RWMutex
+	lastMatchStats *MatchStats
+)
+
+// LastMatchStats returns the most recent loop's matching summary, or nil before the first
+// provided phase has run
+func LastMatchStats() *MatchStats {
+	matchStatsMu.RLock()
+	defer matchStatsMu.RUnlock()
+	return lastMatchStats
+}
+
+// recordMatchStats publishes a loop's summary; stats must not be modified afterwards
+func recordMatchStats(stats *MatchStats) {
+	matchStatsMu.Lock()
+	lastMatchStats = stats
+	matchStatsMu.Unlock()
+}
+
+// newMatchStats starts a summary for a loop over sheetItems
+func newMatchStats(sheetItems []sheets.SheetItem, logEntries int) *MatchStats {
+	stats := &MatchStats{Time: clock.Now(), SheetItems: len(sheetItems), LogEntries: logEntries}
+	for _, item := range sheetItems {
+		if !item.HasProvider {
+			stats.OpenItems++
+		}
+	}
+	return stats
+}
+
+// addEntry counts a scanned log entry and its matches against its provider
+func (s *MatchStats) addEntry(providerName string, matches int) {
+	s.ScannedEntries++
+	s.Matches += matches
+	for i := range s.Providers {
+		if s.Providers[i].Name == providerName {
+			s.Providers[i].LogEntries++
+			s.Providers[i].Matches += matches
+			return
+		}
+	}
+	s.Providers = append(s.Providers, ProviderMatchStats{Name: providerName, LogEntries: 1, Matches: matches})
+}
+
+// finish orders providers by name so consecutive polls are stable
+func (s *MatchStats) finish() {
+	sort.Slice(s.Providers, func(i, j int) bool {
+		return s.Providers[i].Name < s.Providers[j].Name
+	})
+}
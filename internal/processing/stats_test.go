@@ -0,0 +1,71 @@
+package processing
+
+import (
+	"context"
+	"testing"
+
+	"torn_oc_items/internal/providers"
+	"torn_oc_items/internal/sheets"
+	"torn_oc_items/internal/torn"
+)
+
+func TestFindProviderUpdatesRecordsMatchStats(t *testing.T) {
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	sheetItems := []sheets.SheetItem{
+		{RowIndex: 2, Status: "Needed", ItemName: "Binoculars", UserName: "Alice"},
+		{RowIndex: 3, Status: "Needed", ItemName: "Jemmy", UserName: "Bob"},
+		{RowIndex: 4, Status: "Provided", ItemName: "Jemmy", UserName: "Dana", Provider: "Carol", HasProvider: true},
+	}
+	sendTo := func(provider string, receiver, itemID int) providers.ProviderLogEntry {
+		return providers.ProviderLogEntry{
+			ProviderName: provider,
+			Entry:        torn.LogEntry{Data: torn.ItemSendData{Receiver: receiver, Items: []torn.LogItem{{ID: itemID, Qty: 1}}}},
+		}
+	}
+
+	updates := FindProviderUpdates(context.Background(), tornClient, sheetItems, []providers.ProviderLogEntry{
+		sendTo("Erin", 2001, 1258),
+		sendTo("Carol", 2002, 568),
+		sendTo("Carol", 2002, 159),
+	})
+	if len(updates) != 2 {
+		t.Fatalf("Expected 2 updates, got %d", len(updates))
+	}
+
+	stats := LastMatchStats()
+	if stats == nil {
+		t.Fatal("Expected match stats to be recorded")
+	}
+	if stats.SheetItems != 3 || stats.OpenItems != 2 || stats.LogEntries != 3 || stats.ScannedEntries != 3 || stats.Matches != 2 {
+		t.Errorf("Unexpected totals: %+v", stats)
+	}
+	expected := []ProviderMatchStats{
+		{Name: "Carol", LogEntries: 2, Matches: 1},
+		{Name: "Erin", LogEntries: 1, Matches: 1},
+	}
+	if len(stats.Providers) != len(expected) {
+		t.Fatalf("Expected %d providers, got %+v", len(expected), stats.Providers)
+	}
+	for i, want := range expected {
+		if stats.Providers[i] != want {
+			t.Errorf("Provider %d: got %+v, want %+v", i, stats.Providers[i], want)
+		}
+	}
+}
+
+func TestProcessProvidedItemsSkipsWithoutProviders(t *testing.T) {
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	existingData := [][]interface{}{
+		{"Status", "Provider", "Crime", "Time", "Item", "User"},
+		{"Needed", "", sheets.CrimeURL(0, 1), "", "Jemmy", "Bob"},
+	}
+	before := LastMatchStats()
+
+	ProcessProvidedItems(context.Background(), tornClient, nil, existingData, nil, nil, nil)
+	if LastMatchStats() != before {
+		t.Error("Expected matching to be skipped without providers")
+	}
+	if tornClient.GetAPICallCount() != 0 {
+		t.Errorf("Expected no API calls, got %d", tornClient.GetAPICallCount())
+	}
+}
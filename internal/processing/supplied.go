@@ -2,57 +2,278 @@ package processing
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
+	"torn_oc_items/internal/clock"
+	"torn_oc_items/internal/events"
+	"torn_oc_items/internal/notifications"
 	"torn_oc_items/internal/resolution"
+	"torn_oc_items/internal/sheets"
 	"torn_oc_items/internal/torn"
 )
 
 // GetSuppliedItems fetches and returns supplied items from the Torn API
-func GetSuppliedItems(ctx context.Context, tornClient *torn.Client) []torn.SuppliedItem {
+func GetSuppliedItems(ctx context.Context, tornClient torn.TornAPI) ([]torn.SuppliedItem, error) {
 	slog.Debug("Fetching supplied items")
 	callsBefore := tornClient.GetAPICallCount()
 
 	suppliedItems, err := tornClient.GetSuppliedItems(ctx)
 	if err != nil {
 		slog.Error("Failed to get supplied items, skipping this cycle", "error", err)
-		return nil
+		return nil, err
 	}
 
 	callsAfter := tornClient.GetAPICallCount()
 	slog.Debug("Retrieved supplied items", "count", len(suppliedItems), "api_calls", callsAfter-callsBefore)
-	return suppliedItems
+	return suppliedItems, nil
 }
 
-// ProcessSuppliedItems processes supplied items and returns rows to be added to the sheet
-func ProcessSuppliedItems(ctx context.Context, tornClient *torn.Client, suppliedItems []torn.SuppliedItem, existing map[string]bool) [][]interface{} {
+// newSheetRow is a row to append along with the details used to order and notify it
+type newSheetRow struct {
+	crimeID int
+	row     []interface{}
+	item    notifications.ItemInfo
+	silent  bool // added without a notification
+}
+
+// sortNewRows orders rows by crime ID, user name, then item name so the sheet grows
+// in a predictable order regardless of API response ordering
+func sortNewRows(newRows []newSheetRow) {
+	sort.SliceStable(newRows, func(i, j int) bool {
+		a, b := newRows[i], newRows[j]
+		if a.crimeID != b.crimeID {
+			return a.crimeID < b.crimeID
+		}
+		if a.item.UserName != b.item.UserName {
+			return a.item.UserName < b.item.UserName
+		}
+		return a.item.ItemName < b.item.ItemName
+	})
+}
+
+// SuppliedEvents describes each newly detected supplied item for the event sink
+func SuppliedEvents(items []notifications.ItemInfo) []events.Event {
+	now := clock.Now()
+	result := make([]events.Event, 0, len(items))
+	for _, item := range items {
+		crimeID, _ := sheets.ParseCrimeID(item.CrimeURL)
+		result = append(result, events.Event{
+			Type:        events.TypeSuppliedItem,
+			Time:        now,
+			CrimeID:     crimeID,
+			CrimeURL:    item.CrimeURL,
+			ItemName:    item.ItemName,
+			UserName:    item.UserName,
+			MarketValue: item.MarketValue,
+		})
+	}
+	return result
+}
+
+// suppliedPair identifies an item needed by a member; the same pair often appears in
+// several crimes during a busy wave
+type suppliedPair struct {
+	itemID int
+	userID int
+}
+
+// resolvedPair holds the names and item details looked up once per suppliedPair
+type resolvedPair struct {
+	itemName    string
+	itemType    string
+	userName    string
+	marketValue float64
+	// circulation is only looked up when RARE_ITEM_CIRCULATION is set
+	circulation int
+	// nonTradeable is only looked up when NON_TRADEABLE_ITEMS is set
+	nonTradeable bool
+}
+
+// showCrimeNameEnabled reports whether SHOW_CRIME_NAME=true, which writes each new row's
+// crime name to column J
+func showCrimeNameEnabled() bool {
+	return os.Getenv("SHOW_CRIME_NAME") == "true"
+}
+
+// rareItemCirculation returns RARE_ITEM_CIRCULATION, the circulation below which a needed
+// item is flagged as hard to source; zero (unset or invalid) disables the check
+func rareItemCirculation() int {
+	threshold, err := strconv.Atoi(os.Getenv("RARE_ITEM_CIRCULATION"))
+	if err != nil || threshold < 0 {
+		return 0
+	}
+	return threshold
+}
+
+// Values of NON_TRADEABLE_ITEMS, for needed items that providers can't send
+const (
+	nonTradeableOff  = ""
+	nonTradeableWarn = "warn" // add the row as usual and log a warning
+	nonTradeableSkip = "skip" // leave the item off the sheet
+	nonTradeableMark = "mark" // add the row as "Non-tradeable" without notifying providers
+)
+
+var nonTradeableWarnOnce sync.Once
+
+// nonTradeableMode returns NON_TRADEABLE_ITEMS, warning once and disabling the check when
+// the value is unknown
+func nonTradeableMode() string {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv("NON_TRADEABLE_ITEMS")))
+	switch value {
+	case nonTradeableOff, nonTradeableWarn, nonTradeableSkip, nonTradeableMark:
+		return value
+	default:
+		nonTradeableWarnOnce.Do(func() {
+			slog.Warn("Unknown NON_TRADEABLE_ITEMS, not checking tradeability", "non_tradeable_items", value)
+		})
+		return nonTradeableOff
+	}
+}
+
+// resolvePairs looks up each unique (item, user) pair once. Pairs left unresolved when the
+// API call budget runs out are missing from the result.
+func resolvePairs(ctx context.Context, tornClient torn.TornAPI, suppliedItems []torn.SuppliedItem) map[suppliedPair]resolvedPair {
+	resolved := make(map[suppliedPair]resolvedPair)
+	rareThreshold := rareItemCirculation()
+	nonTradeable := nonTradeableMode()
+	for _, itm := range suppliedItems {
+		pair := suppliedPair{itemID: itm.ItemID, userID: itm.UserID}
+		if _, ok := resolved[pair]; ok {
+			continue
+		}
+		if tornClient.BudgetExhausted() {
+			break
+		}
+		// An unknown value stays zero, which notifications leave out
+		marketValue, _ := resolution.GetItemMarketValue(ctx, tornClient, itm.ItemID)
+		rp := resolvedPair{
+			itemName:    resolution.GetItemDetails(ctx, tornClient, itm.ItemID),
+			itemType:    resolution.GetItemType(ctx, tornClient, itm.ItemID),
+			userName:    resolution.GetUserDetails(ctx, tornClient, itm.UserID),
+			marketValue: marketValue,
+		}
+		if rareThreshold > 0 {
+			rp.circulation = resolution.GetItemCirculation(ctx, tornClient, itm.ItemID)
+		}
+		if nonTradeable != nonTradeableOff {
+			rp.nonTradeable = resolution.IsItemNonTradeable(ctx, tornClient, itm.ItemID)
+		}
+		resolved[pair] = rp
+	}
+	return resolved
+}
+
+// ProcessSuppliedItems processes supplied items and returns rows to be added to the sheet,
+// along with the notification details for each new row that providers should be told about
+func ProcessSuppliedItems(ctx context.Context, tornClient torn.TornAPI, suppliedItems []torn.SuppliedItem, existing map[string]bool) ([][]interface{}, []notifications.ItemInfo) {
 	slog.Debug("Processing supplied items", "count", len(suppliedItems))
 	callsBefore := tornClient.GetAPICallCount()
-	var rows [][]interface{}
+	resolved := resolvePairs(ctx, tornClient, suppliedItems)
+	slog.Debug("Resolved supplied item pairs", "items", len(suppliedItems), "unique_pairs", len(resolved))
 
+	rareThreshold := rareItemCirculation()
+	nonTradeable := nonTradeableMode()
+	// Column I feeds both MATCH_AFTER_ROW_ADDED and DEDUPE_WINDOW
+	recordAddedAt := matchAfterRowAddedEnabled() || sheets.DedupeWindow() > 0
+	showCrimeName := showCrimeNameEnabled()
+	var newRows []newSheetRow
+	deferred := 0
 	for _, itm := range suppliedItems {
-		crimeURL := fmt.Sprintf("http://www.torn.com/factions.php?step=your#/tab=crimes&crimeId=%d", itm.CrimeID)
+		pair, ok := resolved[suppliedPair{itemID: itm.ItemID, userID: itm.UserID}]
+		if !ok {
+			deferred++
+			continue
+		}
 
-		itemName := resolution.GetItemDetails(ctx, tornClient, itm.ItemID)
-		userName := resolution.GetUserDetails(ctx, tornClient, itm.UserID)
+		crimeURL := sheets.CrimeURL(factionID(), itm.CrimeID)
+		itemName := pair.itemName
+		userName := pair.userName
 
 		slog.Info("Supplied item",
 			"crime_id", itm.CrimeID,
+			"category", itm.Category,
 			"item", itemName,
 			"user", userName,
 			"crime_url", crimeURL,
 		)
 
-		key := fmt.Sprintf("%s|%s|%s", crimeURL, userName, itemName)
+		// Unknown circulation (0) is never flagged
+		var rareCirculation int
+		if pair.circulation > 0 && pair.circulation < rareThreshold {
+			rareCirculation = pair.circulation
+		}
+
+		if pair.nonTradeable && nonTradeable == nonTradeableSkip {
+			slog.Debug("Skipping non-tradeable item", "crime_id", itm.CrimeID, "item", itemName, "user", userName)
+			continue
+		}
+
+		key := sheets.ExistingKey(crimeURL, userName, itemName)
 		if !existing[key] {
 			slog.Debug("Adding new item to sheet", "key", key)
-			formula := "=IF(OR(INDIRECT(\"A\"&ROW())=\"Provided\",INDIRECT(\"A\"&ROW())=\"Cash Sent\"), INDIRECT(\"G\"&ROW()), 0)"
-			rows = append(rows, []interface{}{"Needed", "", crimeURL, "", itemName, userName, "", formula})
+			status := "Needed"
+			if pair.nonTradeable {
+				slog.Warn("Needed item is not tradeable, the member must acquire it", "crime_id", itm.CrimeID, "item", itemName, "user", userName)
+				if nonTradeable == nonTradeableMark {
+					status = NonTradeableStatus
+				}
+			}
+			formula := sheets.ProvidedValueFormula(sheets.StatusColumn, sheets.MarketValueColumn)
+			row := []interface{}{status, "", crimeURL, "", itemName, userName, "", formula}
+			if recordAddedAt {
+				row = append(row, clock.Now().Format(sheets.DateTimeLayout))
+			}
+			if showCrimeName {
+				// Column J, after an empty column I when added times aren't recorded
+				if !recordAddedAt {
+					row = append(row, "")
+				}
+				row = append(row, itm.CrimeName)
+			}
+			if rareCirculation > 0 {
+				slog.Info("Needed item is hard to source", "item", itemName, "user", userName, "circulation", rareCirculation, "threshold", rareThreshold)
+			}
+			newRows = append(newRows, newSheetRow{
+				crimeID: itm.CrimeID,
+				row:     row,
+				silent:  status == NonTradeableStatus,
+				item: notifications.ItemInfo{
+					ItemName:    itemName,
+					ItemType:    pair.itemType,
+					UserName:    userName,
+					CrimeURL:    crimeURL,
+					CrimeID:     itm.CrimeID,
+					CrimeName:   itm.CrimeName,
+					PassRate:    itm.PassRate,
+					MarketValue: pair.marketValue,
+					Circulation: rareCirculation,
+				},
+			})
 		} else {
 			slog.Debug("Skipping duplicate entry", "key", key)
 		}
 	}
+	if deferred > 0 {
+		slog.Warn("API call budget reached, deferring remaining supplied items to next loop",
+			"deferred", deferred,
+			"api_calls", tornClient.GetAPICallCount(),
+		)
+	}
+
+	sortNewRows(newRows)
+	rows := make([][]interface{}, 0, len(newRows))
+	items := make([]notifications.ItemInfo, 0, len(newRows))
+	for _, nr := range newRows {
+		rows = append(rows, nr.row)
+		if !nr.silent {
+			items = append(items, nr.item)
+		}
+	}
 
 	callsAfter := tornClient.GetAPICallCount()
 	slog.Debug("Finished processing supplied items",
@@ -61,5 +282,5 @@ func ProcessSuppliedItems(ctx context.Context, tornClient *torn.Client, supplied
 		"api_calls", callsAfter-callsBefore,
 	)
 
-	return rows
+	return rows, items
 }
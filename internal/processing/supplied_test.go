@@ -0,0 +1,149 @@
+package processing
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"torn_oc_items/internal/notifications"
+	"torn_oc_items/internal/sheets"
+	"torn_oc_items/internal/torn"
+)
+
+func TestSortNewRowsIsStableForShuffledInput(t *testing.T) {
+	expected := []newSheetRow{
+		{crimeID: 100, item: notifications.ItemInfo{UserName: "Alice", ItemName: "Lockpicks"}},
+		{crimeID: 100, item: notifications.ItemInfo{UserName: "Alice", ItemName: "Xanax"}},
+		{crimeID: 100, item: notifications.ItemInfo{UserName: "Bob", ItemName: "Binoculars"}},
+		{crimeID: 200, item: notifications.ItemInfo{UserName: "Alice", ItemName: "Binoculars"}},
+		{crimeID: 300, item: notifications.ItemInfo{UserName: "Carol", ItemName: "Bandage"}},
+	}
+
+	for seed := int64(0); seed < 10; seed++ {
+		shuffled := make([]newSheetRow, len(expected))
+		copy(shuffled, expected)
+		rand.New(rand.NewSource(seed)).Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+
+		sortNewRows(shuffled)
+
+		for i := range expected {
+			if shuffled[i].crimeID != expected[i].crimeID || shuffled[i].item != expected[i].item {
+				t.Fatalf("Seed %d: position %d got crime %d %+v, want crime %d %+v",
+					seed, i, shuffled[i].crimeID, shuffled[i].item, expected[i].crimeID, expected[i].item)
+			}
+		}
+	}
+}
+
+func TestProcessSuppliedItemsResolvesEachPairOnce(t *testing.T) {
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	suppliedItems := []torn.SuppliedItem{
+		{ItemID: 1258, UserID: 2001, CrimeID: 100},
+		{ItemID: 1258, UserID: 2001, CrimeID: 200},
+		{ItemID: 1258, UserID: 2001, CrimeID: 300},
+		{ItemID: 568, UserID: 2002, CrimeID: 200},
+	}
+
+	rows, items := ProcessSuppliedItems(context.Background(), tornClient, suppliedItems, map[string]bool{})
+
+	if len(rows) != 4 || len(items) != 4 {
+		t.Fatalf("Expected a row per supplied item, got %d rows and %d items", len(rows), len(items))
+	}
+	// Two unique pairs, each needing item name, type, value and user name
+	if calls := tornClient.GetAPICallCount(); calls != 8 {
+		t.Errorf("Expected 8 API calls for 2 unique pairs, got %d", calls)
+	}
+	if items[0].ItemName != "Binoculars" || items[0].UserName != "Alice" || items[0].ItemType != "Tool" || items[0].MarketValue != 1200000 {
+		t.Errorf("Unexpected resolved item: %+v", items[0])
+	}
+}
+
+func TestProcessSuppliedItemsFlagsRareItems(t *testing.T) {
+	t.Setenv("RARE_ITEM_CIRCULATION", "2000")
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	suppliedItems := []torn.SuppliedItem{
+		{ItemID: 1258, UserID: 2001, CrimeID: 100},
+		{ItemID: 568, UserID: 2002, CrimeID: 200},
+	}
+
+	_, items := ProcessSuppliedItems(context.Background(), tornClient, suppliedItems, map[string]bool{})
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if items[0].HardToSource() {
+		t.Errorf("Expected Binoculars (3100 in circulation) not to be flagged: %+v", items[0])
+	}
+	if !items[1].HardToSource() || items[1].Circulation != 1200 {
+		t.Errorf("Expected Jemmy (1200 in circulation) to be flagged: %+v", items[1])
+	}
+}
+
+func TestProcessSuppliedItemsWritesCrimeName(t *testing.T) {
+	t.Setenv("SHOW_CRIME_NAME", "true")
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	suppliedItems := []torn.SuppliedItem{{ItemID: 568, UserID: 2002, CrimeID: 101, CrimeName: "Mob Mentality"}}
+
+	rows, items := ProcessSuppliedItems(context.Background(), tornClient, suppliedItems, map[string]bool{})
+	if len(rows) != 1 || len(items) != 1 {
+		t.Fatalf("Expected 1 row and 1 item, got %d and %d", len(rows), len(items))
+	}
+	if len(rows[0]) != 10 || rows[0][8] != "" || rows[0][9] != "Mob Mentality" {
+		t.Errorf("Expected an empty column I and the crime name in column J, got %v", rows[0])
+	}
+	if items[0].CrimeName != "Mob Mentality" {
+		t.Errorf("Expected the notification to carry the crime name, got %+v", items[0])
+	}
+}
+
+func TestProcessSuppliedItemsSkipsProvidedRows(t *testing.T) {
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	suppliedItems := []torn.SuppliedItem{{ItemID: 568, UserID: 2002, CrimeID: 101}}
+	existing := sheets.BuildExistingMap([][]interface{}{
+		{"Provided", "Carol", sheets.CrimeURL(0, 101), "12:00:00 - 16/10/26", "Jemmy", "Bob", 1750000.0},
+	})
+
+	rows, items := ProcessSuppliedItems(context.Background(), tornClient, suppliedItems, existing)
+	if len(rows) != 0 || len(items) != 0 {
+		t.Errorf("Expected a provided item still shown as needed not to be added again, got %v", rows)
+	}
+}
+
+func TestProcessSuppliedItemsNonTradeable(t *testing.T) {
+	suppliedItems := []torn.SuppliedItem{
+		{ItemID: 1258, UserID: 2001, CrimeID: 100},
+		{ItemID: 1397, UserID: 2002, CrimeID: 100},
+	}
+
+	cases := []struct {
+		mode      string
+		rows      int
+		items     int
+		keyStatus string
+	}{
+		{"", 2, 2, "Needed"},
+		{"warn", 2, 2, "Needed"},
+		{"skip", 1, 1, ""},
+		{"mark", 2, 1, NonTradeableStatus},
+	}
+	for _, c := range cases {
+		t.Run(c.mode, func(t *testing.T) {
+			t.Setenv("NON_TRADEABLE_ITEMS", c.mode)
+			tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+
+			rows, items := ProcessSuppliedItems(context.Background(), tornClient, suppliedItems, map[string]bool{})
+			if len(rows) != c.rows || len(items) != c.items {
+				t.Fatalf("Expected %d rows and %d items, got %d and %d", c.rows, c.items, len(rows), len(items))
+			}
+			for _, item := range items {
+				if item.ItemName == "Faction Keycard" && c.mode == "mark" {
+					t.Error("Expected a marked item not to be notified")
+				}
+			}
+			if c.keyStatus != "" && rows[1][0] != c.keyStatus {
+				t.Errorf("Expected the keycard row to have status %q, got %v", c.keyStatus, rows[1][0])
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package processing
+
+import (
+	"context"
+	"log/slog"
+
+	"torn_oc_items/internal/notifications"
+	"torn_oc_items/internal/resolution"
+	"torn_oc_items/internal/sheets"
+	"torn_oc_items/internal/torn"
+)
+
+// unassignedKey identifies an unassigned need by crime and slot
+type unassignedKey struct {
+	crimeID   int
+	slotIndex int
+	itemID    int
+}
+
+// UnassignedTracker announces item requirements of crime slots that have no member yet,
+// once each, so organizers can plan ahead. They are never written to the sheet; a slot
+// that gets a member becomes an ordinary supplied item.
+type UnassignedTracker struct {
+	announced map[unassignedKey]bool
+}
+
+// NewUnassignedTracker creates a tracker with nothing announced yet
+func NewUnassignedTracker() *UnassignedTracker {
+	return &UnassignedTracker{announced: make(map[unassignedKey]bool)}
+}
+
+// Report announces the unassigned needs from this loop's GetSuppliedItems that weren't
+// announced before and returns how many were new. Needs that have gone, because the slot
+// was filled or the crime left planning, are forgotten and announced again if they return.
+func (t *UnassignedTracker) Report(ctx context.Context, tornClient torn.TornAPI, notificationClient *notifications.Client) int {
+	needs := tornClient.UnassignedNeeds()
+	current := make(map[unassignedKey]bool, len(needs))
+	var fresh []notifications.UnassignedInfo
+	for _, need := range needs {
+		key := unassignedKey{crimeID: need.CrimeID, slotIndex: need.SlotIndex, itemID: need.ItemID}
+		current[key] = true
+		if t.announced[key] {
+			continue
+		}
+		fresh = append(fresh, notifications.UnassignedInfo{
+			ItemName:  resolution.GetItemNameByID(ctx, tornClient, need.ItemID),
+			CrimeName: need.CrimeName,
+			Position:  need.Position,
+			CrimeURL:  sheets.CrimeURL(factionID(), need.CrimeID),
+		})
+	}
+	t.announced = current
+
+	if len(fresh) > 0 {
+		slog.Info("Items will be needed once slots are filled", "new", len(fresh), "unassigned", len(needs))
+		if notificationClient != nil {
+			notificationClient.NotifyUnassignedNeeds(ctx, fresh)
+		}
+	}
+	return len(fresh)
+}
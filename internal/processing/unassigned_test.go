@@ -0,0 +1,41 @@
+package processing
+
+import (
+	"context"
+	"testing"
+
+	"torn_oc_items/internal/torn"
+)
+
+func TestUnassignedTrackerAnnouncesEachNeedOnce(t *testing.T) {
+	ctx := context.Background()
+	tornClient := torn.NewMockClient("../../test/testdata/mock", "MockFaction", "")
+	tornClient.SetTrackUnassigned(true)
+	tracker := NewUnassignedTracker()
+
+	if _, err := tornClient.GetSuppliedItems(ctx); err != nil {
+		t.Fatal(err)
+	}
+	needs := tornClient.UnassignedNeeds()
+	if len(needs) != 1 || needs[0].Position != "Looter #4" || needs[0].ItemID != 568 || needs[0].Category != "planning" {
+		t.Fatalf("Expected the unassigned Looter #4 slot needing a Jemmy, got %+v", needs)
+	}
+	if fresh := tracker.Report(ctx, tornClient, nil); fresh != 1 {
+		t.Errorf("Expected 1 new unassigned need, got %d", fresh)
+	}
+
+	if _, err := tornClient.GetSuppliedItems(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if fresh := tracker.Report(ctx, tornClient, nil); fresh != 0 {
+		t.Errorf("Expected the need not to be announced again, got %d", fresh)
+	}
+
+	tornClient.SetTrackUnassigned(false)
+	if _, err := tornClient.GetSuppliedItems(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if needs := tornClient.UnassignedNeeds(); len(needs) != 0 {
+		t.Errorf("Expected no unassigned needs when tracking is off, got %+v", needs)
+	}
+}
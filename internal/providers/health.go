@@ -0,0 +1,111 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// maxProviderBackoff caps how long a failing provider is skipped between log fetches
+const maxProviderBackoff = 30 * time.Minute
+
+// failureAlertEvery is how many consecutive failures a provider racks up between ERROR
+// logs; with the backoff capped, a key that stays broken is reported every few hours
+const failureAlertEvery = 5
+
+// Health tracks the log-fetch health of a single provider key
+type Health struct {
+	mutex               sync.RWMutex
+	lastSuccess         time.Time
+	lastError           string
+	errorCount          int
+	consecutiveFailures int
+	backoffUntil        time.Time
+}
+
+// HealthStatus is a point-in-time snapshot of a provider's health
+type HealthStatus struct {
+	Provider            string    `json:"provider"`
+	LastSuccess         time.Time `json:"last_success"`
+	LastError           string    `json:"last_error,omitempty"`
+	ErrorCount          int       `json:"error_count"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	BackingOff          bool      `json:"backing_off"`
+	BackoffUntil        time.Time `json:"backoff_until"`
+}
+
+// RecordSuccess marks a successful log fetch and clears any backoff
+func (h *Health) RecordSuccess() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.lastSuccess = time.Now()
+	h.consecutiveFailures = 0
+	h.backoffUntil = time.Time{}
+}
+
+// RecordFailure counts a failed log fetch and backs the provider off exponentially. It
+// reports whether the failure streak has reached another multiple of failureAlertEvery.
+func (h *Health) RecordFailure(err error) (alert bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.errorCount++
+	h.consecutiveFailures++
+	h.lastError = err.Error()
+
+	backoff := time.Minute << min(h.consecutiveFailures-1, 5)
+	if backoff > maxProviderBackoff {
+		backoff = maxProviderBackoff
+	}
+	h.backoffUntil = time.Now().Add(backoff)
+	return h.consecutiveFailures%failureAlertEvery == 0
+}
+
+// IsBackingOff reports whether the provider should be skipped for now
+func (h *Health) IsBackingOff() bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return time.Now().Before(h.backoffUntil)
+}
+
+// Snapshot returns the current health state for reporting
+func (h *Health) Snapshot(providerName string) HealthStatus {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return HealthStatus{
+		Provider:            providerName,
+		LastSuccess:         h.lastSuccess,
+		LastError:           h.lastError,
+		ErrorCount:          h.errorCount,
+		ConsecutiveFailures: h.consecutiveFailures,
+		BackingOff:          time.Now().Before(h.backoffUntil),
+		BackoffUntil:        h.backoffUntil,
+	}
+}
+
+// HealthReport returns a health snapshot for every provider
+func HealthReport(provs []Provider) []HealthStatus {
+	report := make([]HealthStatus, 0, len(provs))
+	for _, p := range provs {
+		report = append(report, p.Health.Snapshot(p.Name))
+	}
+	return report
+}
+
+// LogHealthReport emits one log line per provider describing its health
+func LogHealthReport(provs []Provider) {
+	for _, status := range HealthReport(provs) {
+		level := slog.LevelInfo
+		if status.ConsecutiveFailures > 0 {
+			level = slog.LevelWarn
+		}
+		slog.Log(context.Background(), level, "Provider health",
+			"provider", status.Provider,
+			"last_success", status.LastSuccess,
+			"error_count", status.ErrorCount,
+			"consecutive_failures", status.ConsecutiveFailures,
+			"backing_off", status.BackingOff,
+			"last_error", status.LastError,
+		)
+	}
+}
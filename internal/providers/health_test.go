@@ -0,0 +1,44 @@
+package providers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHealthBackoffClearsOnSuccess(t *testing.T) {
+	h := &Health{}
+
+	h.RecordFailure(errors.New("invalid key"))
+	status := h.Snapshot("Alice")
+	if !status.BackingOff || status.ErrorCount != 1 || status.LastError != "invalid key" {
+		t.Fatalf("Expected backing off after failure, got %+v", status)
+	}
+
+	h.RecordSuccess()
+	status = h.Snapshot("Alice")
+	if status.BackingOff || status.ConsecutiveFailures != 0 || status.LastSuccess.IsZero() {
+		t.Errorf("Expected backoff cleared after success, got %+v", status)
+	}
+	if status.ErrorCount != 1 {
+		t.Errorf("Expected cumulative error count to be kept, got %d", status.ErrorCount)
+	}
+}
+
+func TestHealthAlertsEveryFewConsecutiveFailures(t *testing.T) {
+	h := &Health{}
+
+	var alerts []int
+	for i := 1; i <= 2*failureAlertEvery; i++ {
+		if h.RecordFailure(errors.New("key lost log access")) {
+			alerts = append(alerts, i)
+		}
+	}
+	if len(alerts) != 2 || alerts[0] != failureAlertEvery || alerts[1] != 2*failureAlertEvery {
+		t.Errorf("Expected alerts at %d and %d failures, got %v", failureAlertEvery, 2*failureAlertEvery, alerts)
+	}
+
+	h.RecordSuccess()
+	if h.RecordFailure(errors.New("timeout")) {
+		t.Error("Expected a success to restart the failure streak")
+	}
+}
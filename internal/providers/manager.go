@@ -1,60 +1,290 @@
 package providers
 
 import (
+	"cmp"
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
+	"torn_oc_items/internal/config"
+	"torn_oc_items/internal/retry"
 	"torn_oc_items/internal/torn"
 )
 
 type Provider struct {
 	Name   string
-	Client *torn.Client
+	Label  string // display name from PROVIDER_LABELS, empty to use Name
+	Client torn.TornAPI
+	Health *Health
+}
+
+// DisplayName returns the label written to the sheet for this provider's sends
+func (p Provider) DisplayName() string {
+	if p.Label != "" {
+		return p.Label
+	}
+	return p.Name
 }
 
 // ProviderLogEntry pairs a log entry with the provider name that fetched it.
 type ProviderLogEntry struct {
 	ProviderName string
 	Entry        torn.LogEntry
+	Armory       bool // deposited into the faction armory rather than sent to a member
+}
+
+// DefaultResolveAttempts is how many times a provider key's owner is looked up before
+// the key is skipped
+const DefaultResolveAttempts = 5
+
+// nameResolver is a provider client that can look up its key's owner with its own retry
+type nameResolver interface {
+	torn.TornAPI
+	WhoAmIWithRetry(ctx context.Context, cfg retry.Config) (string, error)
 }
 
 // LoadProviders reads PROVIDER_KEYS from the environment (comma-separated list of Torn API keys),
 // resolves each key to a player name via WhoAmI, and returns a slice of Provider instances.
-func LoadProviders(ctx context.Context) []Provider {
+// A key that can't be resolved within PROVIDER_RESOLVE_ATTEMPTS attempts is skipped so one
+// revoked key never holds up startup.
+func LoadProviders(ctx context.Context, userAgent string, catalog *torn.Catalog) []Provider {
 	keys := strings.Split(os.Getenv("PROVIDER_KEYS"), ",")
+	return loadProviders(ctx, keys, providerResolveAttempts(), func(key string) nameResolver {
+		return torn.NewClient(key, "", userAgent, catalog)
+	})
+}
+
+func loadProviders(ctx context.Context, keys []string, attempts int, newClient func(key string) nameResolver) []Provider {
+	labels := parseProviderLabels(os.Getenv("PROVIDER_LABELS"))
+	cfg := config.DefaultResilienceConfig.APIRequest
+	cfg.MaxRetries = attempts - 1
+
 	var providers []Provider
-	for _, raw := range keys {
+	for i, raw := range keys {
 		key := strings.TrimSpace(raw)
 		if key == "" {
 			continue
 		}
-		client := torn.NewClient(key, "")
-		name, err := client.WhoAmI(ctx)
+		client := newClient(key)
+		name, err := client.WhoAmIWithRetry(ctx, cfg)
 		if err != nil {
-			slog.Warn("Failed to resolve provider key; skipping", "error", err)
+			slog.Error("Failed to resolve provider key; skipping", "position", i+1, "attempts", attempts, "error", err)
 			continue
 		}
-		providers = append(providers, Provider{Name: name, Client: client})
-		slog.Info("Loaded provider API key", "provider", name)
+		label := cmp.Or(labels[key], labels[name])
+		providers = append(providers, Provider{Name: name, Label: label, Client: client, Health: &Health{}})
+		slog.Info("Loaded provider API key", "provider", name, "label", label)
+	}
+	return providers
+}
+
+// LoadMockProviders creates a fixture-backed provider for each logs_<provider>.json in dir
+// for MOCK_MODE.
+func LoadMockProviders(dir string) []Provider {
+	names, err := torn.MockProviderNames(dir)
+	if err != nil {
+		slog.Warn("Failed to list mock provider fixtures", "mock_data_dir", dir, "error", err)
+		return nil
+	}
+
+	labels := parseProviderLabels(os.Getenv("PROVIDER_LABELS"))
+	var providers []Provider
+	for _, name := range names {
+		client := torn.NewMockClient(dir, name, torn.MockLogsFile(name))
+		providers = append(providers, Provider{Name: name, Label: labels[name], Client: client, Health: &Health{}})
+		slog.Info("Loaded mock provider", "provider", name)
 	}
 	return providers
 }
 
+// parseProviderLabels parses PROVIDER_LABELS, a comma-separated list of <key or name>=<label>
+// pairs. Malformed pairs are logged and skipped.
+func parseProviderLabels(value string) map[string]string {
+	labels := make(map[string]string)
+	for i, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		who, label, ok := strings.Cut(pair, "=")
+		who, label = strings.TrimSpace(who), strings.TrimSpace(label)
+		if !ok || who == "" || label == "" {
+			// The entry may hold an API key, so only its position is logged
+			slog.Warn("Ignoring malformed PROVIDER_LABELS entry, expected <key or name>=<label>", "position", i+1)
+			continue
+		}
+		labels[who] = label
+	}
+	return labels
+}
+
 // AggregateLogs fetches item-send logs for the last 48h from all providers.
 func AggregateLogs(ctx context.Context, provs []Provider) []ProviderLogEntry {
 	var combined []ProviderLogEntry
 	for _, p := range provs {
+		if p.Health.IsBackingOff() {
+			slog.Debug("Skipping provider while backing off", "provider", p.Name)
+			continue
+		}
 		resp, err := p.Client.GetItemSendLogs(ctx)
 		if err != nil {
-			slog.Warn("Failed to fetch logs for provider", "provider", p.Name, "error", err)
+			recordFetchFailure(p, "logs", err)
 			continue
 		}
 		for _, entry := range resp.Log {
-			combined = append(combined, ProviderLogEntry{ProviderName: p.Name, Entry: entry})
+			combined = append(combined, ProviderLogEntry{ProviderName: p.DisplayName(), Entry: entry})
+		}
+
+		if ArmoryMatchingEnabled() {
+			armoryResp, err := p.Client.GetArmoryDepositLogs(ctx, armoryLogType())
+			if err != nil {
+				recordFetchFailure(p, "armory deposit logs", err)
+				continue
+			}
+			for _, entry := range armoryResp.Log {
+				combined = append(combined, ProviderLogEntry{ProviderName: p.DisplayName(), Entry: entry, Armory: true})
+			}
 		}
+		p.Health.RecordSuccess()
 	}
 	slog.Debug("Aggregated logs from all providers", "combined_log_entries", len(combined))
-	return combined
+	if dedupeLogsEnabled() {
+		combined = dedupeLogEntries(combined)
+	}
+	return capLogEntries(combined, maxCombinedLogEntries())
+}
+
+// recordFetchFailure backs a provider off after a failed fetch. The other providers' logs
+// are still used; a provider that keeps failing, such as a key that lost log access, is
+// escalated to an ERROR every few failures rather than on each one.
+func recordFetchFailure(p Provider, what string, err error) {
+	if p.Health.RecordFailure(err) {
+		status := p.Health.Snapshot(p.Name)
+		slog.Error("Provider keeps failing to fetch "+what+"; its sends are not being matched",
+			"provider", p.Name,
+			"consecutive_failures", status.ConsecutiveFailures,
+			"last_success", status.LastSuccess,
+			"error", err,
+		)
+		return
+	}
+	slog.Warn("Failed to fetch "+what+" for provider", "provider", p.Name, "error", err)
+}
+
+// dedupeLogsEnabled reports whether DEDUPE_PROVIDER_LOGS collapses log entries that
+// several providers report for the same send
+func dedupeLogsEnabled() bool {
+	return os.Getenv("DEDUPE_PROVIDER_LOGS") == "true"
+}
+
+// logSignature identifies the send behind a log entry by its content, since the same send
+// can appear in more than one provider's log
+func logSignature(ple ProviderLogEntry) string {
+	items := make([]string, 0, len(ple.Entry.Data.Items))
+	for _, item := range ple.Entry.Data.Items {
+		items = append(items, fmt.Sprintf("%d:%d", item.ID, item.Qty))
+	}
+	sort.Strings(items)
+	return fmt.Sprintf("%d|%t|%d|%d|%s", ple.Entry.Log, ple.Armory, ple.Entry.Timestamp, ple.Entry.Data.Receiver, strings.Join(items, ","))
+}
+
+// dedupeLogEntries keeps one entry per send signature so a send credits exactly one
+// provider. The provider whose name sorts first keeps the entry, whatever order the
+// providers were loaded in.
+func dedupeLogEntries(entries []ProviderLogEntry) []ProviderLogEntry {
+	kept := make(map[string]int, len(entries))
+	var result []ProviderLogEntry
+	for _, ple := range entries {
+		sig := logSignature(ple)
+		i, seen := kept[sig]
+		if !seen {
+			kept[sig] = len(result)
+			result = append(result, ple)
+			continue
+		}
+
+		winner, dropped := result[i].ProviderName, ple.ProviderName
+		if dropped < winner {
+			result[i], winner, dropped = ple, dropped, winner
+		}
+		slog.Info("Collapsed duplicate log entry reported by more than one provider",
+			"kept_provider", winner,
+			"dropped_provider", dropped,
+			"receiver", ple.Entry.Data.Receiver,
+			"timestamp", ple.Entry.Timestamp,
+		)
+	}
+	return result
+}
+
+// ArmoryMatchingEnabled reports whether MATCH_ARMORY credits providers for depositing
+// needed items into the faction armory
+func ArmoryMatchingEnabled() bool {
+	return os.Getenv("MATCH_ARMORY") == "true"
+}
+
+// providerResolveAttempts reads PROVIDER_RESOLVE_ATTEMPTS, the attempts to resolve each
+// provider key before skipping it
+func providerResolveAttempts() int {
+	value := os.Getenv("PROVIDER_RESOLVE_ATTEMPTS")
+	if value == "" {
+		return DefaultResolveAttempts
+	}
+	attempts, err := strconv.Atoi(value)
+	if err != nil || attempts < 1 {
+		slog.Warn("Invalid PROVIDER_RESOLVE_ATTEMPTS, using default", "value", value, "default", DefaultResolveAttempts)
+		return DefaultResolveAttempts
+	}
+	return attempts
+}
+
+// armoryLogType reads ARMORY_LOG_TYPE, the log type ID of armory deposits
+func armoryLogType() int {
+	value := os.Getenv("ARMORY_LOG_TYPE")
+	if value == "" {
+		return torn.DefaultArmoryDepositLogType
+	}
+	logType, err := strconv.Atoi(value)
+	if err != nil || logType <= 0 {
+		slog.Warn("Invalid ARMORY_LOG_TYPE, using default", "value", value, "default", torn.DefaultArmoryDepositLogType)
+		return torn.DefaultArmoryDepositLogType
+	}
+	return logType
+}
+
+// maxCombinedLogEntries reads MAX_COMBINED_LOG_ENTRIES; zero or unset means unlimited
+func maxCombinedLogEntries() int {
+	value := os.Getenv("MAX_COMBINED_LOG_ENTRIES")
+	if value == "" {
+		return 0
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		slog.Warn("Invalid MAX_COMBINED_LOG_ENTRIES, not capping logs", "value", value)
+		return 0
+	}
+	return limit
+}
+
+// capLogEntries keeps at most limit entries, evicting the oldest first
+func capLogEntries(entries []ProviderLogEntry, limit int) []ProviderLogEntry {
+	if limit <= 0 || len(entries) <= limit {
+		return entries
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Entry.Timestamp > entries[j].Entry.Timestamp
+	})
+	evicted := len(entries) - limit
+	slog.Warn("Combined provider logs exceed cap, evicting oldest entries",
+		"combined_log_entries", len(entries),
+		"max_entries", limit,
+		"evicted", evicted,
+		"oldest_kept_timestamp", entries[limit-1].Entry.Timestamp,
+	)
+	return entries[:limit:limit]
 }
@@ -0,0 +1,231 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"torn_oc_items/internal/config"
+	"torn_oc_items/internal/retry"
+	"torn_oc_items/internal/torn"
+)
+
+func TestCapLogEntriesEvictsOldest(t *testing.T) {
+	entries := []ProviderLogEntry{
+		{ProviderName: "Alice", Entry: torn.LogEntry{Timestamp: 100}},
+		{ProviderName: "Bob", Entry: torn.LogEntry{Timestamp: 300}},
+		{ProviderName: "Alice", Entry: torn.LogEntry{Timestamp: 200}},
+	}
+
+	capped := capLogEntries(entries, 2)
+	if len(capped) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(capped))
+	}
+	if capped[0].Entry.Timestamp != 300 || capped[1].Entry.Timestamp != 200 {
+		t.Errorf("Expected newest entries kept, got %+v", capped)
+	}
+}
+
+func TestCapLogEntriesUnlimited(t *testing.T) {
+	entries := []ProviderLogEntry{{}, {}, {}}
+	if capped := capLogEntries(entries, 0); len(capped) != 3 {
+		t.Errorf("Expected no cap with limit 0, got %d entries", len(capped))
+	}
+}
+
+func TestDedupeLogEntries(t *testing.T) {
+	send := func(provider string, timestamp int64, receiver int, items ...torn.LogItem) ProviderLogEntry {
+		return ProviderLogEntry{
+			ProviderName: provider,
+			Entry:        torn.LogEntry{Log: torn.ItemSendLogType, Timestamp: timestamp, Data: torn.ItemSendData{Receiver: receiver, Items: items}},
+		}
+	}
+	jemmy := torn.LogItem{ID: 568, Qty: 1}
+	binoculars := torn.LogItem{ID: 1258, Qty: 1}
+
+	entries := []ProviderLogEntry{
+		send("Erin", 100, 2002, jemmy, binoculars),
+		send("Carol", 100, 2002, binoculars, jemmy),
+		send("Carol", 100, 2003, jemmy),
+		send("Erin", 200, 2002, jemmy),
+	}
+	entries = append(entries, ProviderLogEntry{ProviderName: "Erin", Entry: entries[3].Entry, Armory: true})
+
+	deduped := dedupeLogEntries(entries)
+	if len(deduped) != 4 {
+		t.Fatalf("Expected 4 entries after collapsing one duplicate, got %d: %+v", len(deduped), deduped)
+	}
+	if deduped[0].ProviderName != "Carol" || deduped[0].Entry.Data.Receiver != 2002 {
+		t.Errorf("Expected Carol to keep the shared send, got %+v", deduped[0])
+	}
+}
+
+func TestProviderLabelsNameTheSheetProvider(t *testing.T) {
+	t.Setenv("PROVIDER_LABELS", "Carol=carol#1234, malformed")
+
+	provs := LoadMockProviders("../../test/testdata/mock")
+	if len(provs) != 1 || provs[0].Name != "Carol" || provs[0].DisplayName() != "carol#1234" {
+		t.Fatalf("Expected Carol labelled carol#1234, got %+v", provs)
+	}
+
+	entries := AggregateLogs(context.Background(), provs)
+	if len(entries) == 0 {
+		t.Fatal("Expected log entries from the Carol fixture")
+	}
+	for _, e := range entries {
+		if e.ProviderName != "carol#1234" {
+			t.Errorf("Expected entries credited to the label, got %q", e.ProviderName)
+		}
+	}
+}
+
+func TestParseProviderLabels(t *testing.T) {
+	labels := parseProviderLabels(" key123 = Alice (Discord) ,Bob=bobby,=nobody,noequals")
+	if len(labels) != 2 || labels["key123"] != "Alice (Discord)" || labels["Bob"] != "bobby" {
+		t.Errorf("Unexpected labels %v", labels)
+	}
+}
+
+// fakeResolver resolves a key to name, or fails every attempt when name is empty
+type fakeResolver struct {
+	torn.TornAPI
+	name     string
+	attempts int
+}
+
+func (f *fakeResolver) WhoAmIWithRetry(ctx context.Context, cfg retry.Config) (string, error) {
+	return retry.WithRetry(ctx, cfg, func(ctx context.Context) (string, error) {
+		f.attempts++
+		if f.name == "" {
+			return "", errors.New("incorrect key")
+		}
+		return f.name, nil
+	})
+}
+
+func TestLoadProvidersSkipsUnresolvableKeyAfterCap(t *testing.T) {
+	saved := config.DefaultResilienceConfig.APIRequest
+	defer func() { config.DefaultResilienceConfig.APIRequest = saved }()
+	config.DefaultResilienceConfig.APIRequest = retry.Config{MaxRetries: 100, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Timeout: time.Second}
+
+	clients := map[string]*fakeResolver{
+		"revoked": {},
+		"good":    {name: "Alice"},
+	}
+	provs := loadProviders(context.Background(), []string{"revoked", " good "}, 3, func(key string) nameResolver {
+		return clients[key]
+	})
+
+	if len(provs) != 1 || provs[0].Name != "Alice" {
+		t.Fatalf("Expected only Alice to load, got %+v", provs)
+	}
+	if got := clients["revoked"].attempts; got != 3 {
+		t.Errorf("Expected the revoked key to be tried 3 times, got %d", got)
+	}
+	if got := clients["good"].attempts; got != 1 {
+		t.Errorf("Expected the good key to resolve first time, got %d attempts", got)
+	}
+}
+
+// newEnvelopeServer serves the 200 error envelope Torn returns for a key that can't read
+// the selection, counting requests
+func newEnvelopeServer(t *testing.T, code int, message string) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = fmt.Fprintf(w, `{"error":{"code":%d,"error":%q}}`, code, message)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// fastAPIRetries makes clients created during the test retry quickly
+func fastAPIRetries(t *testing.T) {
+	t.Helper()
+	saved := config.DefaultResilienceConfig.APIRequest
+	t.Cleanup(func() { config.DefaultResilienceConfig.APIRequest = saved })
+	config.DefaultResilienceConfig.APIRequest = retry.Config{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Timeout: time.Second}
+}
+
+func TestAggregateLogsBacksOffKeyWithoutLogAccess(t *testing.T) {
+	fastAPIRetries(t)
+	server, calls := newEnvelopeServer(t, 16, "Access level of this key is not high enough")
+	client := torn.NewClient("no-log-access", "", "torn-oc-items/test", nil)
+	client.SetBaseURL(server.URL)
+	provider := Provider{Name: "Carol", Client: client, Health: &Health{}}
+
+	var logs bytes.Buffer
+	saved := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(saved)
+
+	for i := 0; i < failureAlertEvery; i++ {
+		if entries := AggregateLogs(context.Background(), []Provider{provider}); len(entries) != 0 {
+			t.Fatalf("Expected no entries from a key without log access, got %d", len(entries))
+		}
+		if !provider.Health.IsBackingOff() {
+			t.Fatalf("Fetch %d: expected the provider to back off", i+1)
+		}
+		provider.Health.backoffUntil = time.Time{}
+	}
+
+	if got := atomic.LoadInt32(calls); got != failureAlertEvery {
+		t.Errorf("Expected the access error not to be retried, got %d requests for %d fetches", got, failureAlertEvery)
+	}
+	if status := provider.Health.Snapshot("Carol"); !status.LastSuccess.IsZero() || status.ConsecutiveFailures != failureAlertEvery {
+		t.Errorf("Expected only failures recorded, got %+v", status)
+	}
+	if !strings.Contains(logs.String(), "level=ERROR") || !strings.Contains(logs.String(), "keeps failing") {
+		t.Errorf("Expected an ERROR once the failures added up, got:\n%s", logs.String())
+	}
+}
+
+func TestLoadProvidersCapsRequestsPerKey(t *testing.T) {
+	fastAPIRetries(t)
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		mu.Lock()
+		requests[key]++
+		mu.Unlock()
+		switch key {
+		case "revoked":
+			_, _ = fmt.Fprint(w, `{"error":{"code":2,"error":"Incorrect key"}}`)
+		case "nameless":
+			_, _ = fmt.Fprint(w, `{}`)
+		case "down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = fmt.Fprint(w, `{"name":"Alice","player_id":2001}`)
+		}
+	}))
+	defer server.Close()
+
+	provs := loadProviders(context.Background(), []string{"revoked", "nameless", "down", "good"}, 3, func(key string) nameResolver {
+		client := torn.NewClient(key, "", "torn-oc-items/test", nil)
+		client.SetBaseURL(server.URL)
+		return client
+	})
+
+	if len(provs) != 1 || provs[0].Name != "Alice" {
+		t.Fatalf("Expected only Alice to load, got %+v", provs)
+	}
+	// The client's own TORN_API_* retries must not multiply the resolve attempts
+	want := map[string]int{"revoked": 1, "nameless": 3, "down": 3, "good": 1}
+	for key, n := range want {
+		if requests[key] != n {
+			t.Errorf("Key %q: expected %d requests, got %d", key, n, requests[key])
+		}
+	}
+}
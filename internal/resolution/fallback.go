@@ -0,0 +1,95 @@
+package resolution
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Default fallback names written when an item or user can't be resolved
+const (
+	DefaultItemFallbackFormat = "Item ID: %d"
+	DefaultUserFallbackFormat = "User ID: %d"
+)
+
+// The fallback templates in use, set once at startup by SetFallbackFormats
+var (
+	itemFallbackFormat = DefaultItemFallbackFormat
+	userFallbackFormat = DefaultUserFallbackFormat
+)
+
+// SetFallbackFormats sets the templates for unresolved item and user names from
+// ITEM_FALLBACK_FORMAT and USER_FALLBACK_FORMAT. Each must hold exactly one %d for the ID;
+// an empty format keeps the default. It must be called before the loops start.
+func SetFallbackFormats(itemFormat, userFormat string) error {
+	if itemFormat == "" {
+		itemFormat = DefaultItemFallbackFormat
+	}
+	if userFormat == "" {
+		userFormat = DefaultUserFallbackFormat
+	}
+	if err := validateFallbackFormat(itemFormat); err != nil {
+		return fmt.Errorf("invalid item fallback format: %w", err)
+	}
+	if err := validateFallbackFormat(userFormat); err != nil {
+		return fmt.Errorf("invalid user fallback format: %w", err)
+	}
+	itemFallbackFormat = itemFormat
+	userFallbackFormat = userFormat
+	return nil
+}
+
+// validateFallbackFormat checks that format has exactly one %d and no other verbs, so the
+// ID can be read back out of the names it produces
+func validateFallbackFormat(format string) error {
+	if strings.Count(format, "%d") != 1 {
+		return fmt.Errorf("%q must contain exactly one %%d", format)
+	}
+	if strings.Count(format, "%") != 1 {
+		return fmt.Errorf("%q must not contain other %% verbs", format)
+	}
+	return nil
+}
+
+// ItemFallback returns the fallback name for an item that couldn't be resolved
+func ItemFallback(itemID int) string {
+	return fmt.Sprintf(itemFallbackFormat, itemID)
+}
+
+// UserFallback returns the fallback name for a user that couldn't be resolved
+func UserFallback(userID int) string {
+	return fmt.Sprintf(userFallbackFormat, userID)
+}
+
+// ParseItemFallback returns the ID from an item fallback name, or 0 when value is a real
+// name. Names written with the default template are recognized too, so rows added before
+// the template changed still match.
+func ParseItemFallback(value string) int {
+	return parseFallback(value, itemFallbackFormat, DefaultItemFallbackFormat)
+}
+
+// ParseUserFallback returns the ID from a user fallback name, or 0 when value is a real
+// name. Names written with the default template are recognized too.
+func ParseUserFallback(value string) int {
+	return parseFallback(value, userFallbackFormat, DefaultUserFallbackFormat)
+}
+
+func parseFallback(value string, formats ...string) int {
+	value = strings.TrimSpace(value)
+	for _, format := range formats {
+		prefix, suffix, _ := strings.Cut(format, "%d")
+		rest, ok := strings.CutPrefix(value, prefix)
+		if !ok {
+			continue
+		}
+		rest, ok = strings.CutSuffix(rest, suffix)
+		if !ok {
+			continue
+		}
+		id, err := strconv.Atoi(rest)
+		if err == nil && id > 0 {
+			return id
+		}
+	}
+	return 0
+}
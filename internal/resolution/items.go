@@ -5,49 +5,78 @@ import (
 	"fmt"
 	"log/slog"
 
+	"torn_oc_items/internal/log"
 	"torn_oc_items/internal/torn"
 )
 
 // GetItemNameByID retrieves an item's name by its ID, with error handling
-func GetItemNameByID(ctx context.Context, tornClient *torn.Client, itemID int) string {
-	slog.Debug("Getting item details", "item_id", itemID)
+func GetItemNameByID(ctx context.Context, tornClient torn.TornAPI, itemID int) string {
+	log.DebugSampled("Getting item details", "item_id", itemID)
 	itemDetails, err := tornClient.GetItem(ctx, fmt.Sprintf("%d", itemID))
 	if err != nil {
 		slog.Debug("Failed to get item details for matching", "item_id", itemID, "error", err)
 		return ""
 	}
-	slog.Debug("Retrieved item details", "item_id", itemID, "name", itemDetails.Name)
+	log.DebugSampled("Retrieved item details", "item_id", itemID, "name", itemDetails.Name)
 	return itemDetails.Name
 }
 
 // GetItemDetails retrieves item details with fallback to ID format on error
-func GetItemDetails(ctx context.Context, tornClient *torn.Client, itemID int) string {
-	slog.Debug("Getting item details", "item_id", itemID)
+func GetItemDetails(ctx context.Context, tornClient torn.TornAPI, itemID int) string {
+	log.DebugSampled("Getting item details", "item_id", itemID)
 	itemDetails, err := tornClient.GetItem(ctx, fmt.Sprintf("%d", itemID))
 	if err == nil {
-		slog.Debug("Retrieved item details", "item_id", itemID, "name", itemDetails.Name)
+		log.DebugSampled("Retrieved item details", "item_id", itemID, "name", itemDetails.Name)
 		return itemDetails.Name
 	}
 	slog.Warn("Failed to get item details", "item_id", itemID, "error", err)
-	return fmt.Sprintf("Item ID: %d", itemID)
+	return ItemFallback(itemID)
 }
 
-// GetItemMarketValue retrieves the market value of an item by its ID
-func GetItemMarketValue(ctx context.Context, tornClient *torn.Client, itemID int) float64 {
-	slog.Debug("Getting item market value", "item_id", itemID)
+// GetItemMarketValue retrieves the market value of an item by its ID. ok is false when
+// the lookup failed, so callers can tell an unknown value from an item worth nothing.
+func GetItemMarketValue(ctx context.Context, tornClient torn.TornAPI, itemID int) (value float64, ok bool) {
+	log.DebugSampled("Getting item market value", "item_id", itemID)
 	item, err := tornClient.GetItem(ctx, fmt.Sprintf("%d", itemID))
 	if err != nil {
 		slog.Warn("Failed to get item market value", "item_id", itemID, "error", err)
+		return 0, false
+	}
+	return item.MarketValue, true
+}
+
+// GetItemType retrieves the type of an item by its ID, e.g. "Tool" or "Drug"
+func GetItemType(ctx context.Context, tornClient torn.TornAPI, itemID int) string {
+	item, err := tornClient.GetItem(ctx, fmt.Sprintf("%d", itemID))
+	if err != nil {
+		slog.Debug("Failed to get item type", "item_id", itemID, "error", err)
+		return ""
+	}
+	return item.Type
+}
+
+// GetItemCirculation retrieves how many of an item exist in Torn, or 0 when unknown
+func GetItemCirculation(ctx context.Context, tornClient torn.TornAPI, itemID int) int {
+	item, err := tornClient.GetItem(ctx, fmt.Sprintf("%d", itemID))
+	if err != nil {
+		slog.Debug("Failed to get item circulation", "item_id", itemID, "error", err)
 		return 0
 	}
-	return item.MarketValue
+	return int(item.Circulation)
 }
 
-// MatchesItem checks if a sheet item name matches a log item name or ID
-func MatchesItem(sheetItemName, logItemName string, logItemID int) bool {
-	if sheetItemName == logItemName {
-		return true
+// IsItemNonTradeable reports whether an item is known to be non-tradeable, so providers
+// can't send it. Lookup failures and responses without the field count as tradeable.
+func IsItemNonTradeable(ctx context.Context, tornClient torn.TornAPI, itemID int) bool {
+	item, err := tornClient.GetItem(ctx, fmt.Sprintf("%d", itemID))
+	if err != nil {
+		slog.Debug("Failed to get item tradeability", "item_id", itemID, "error", err)
+		return false
 	}
-	expectedFallback := fmt.Sprintf("Item ID: %d", logItemID)
-	return sheetItemName == expectedFallback
+	return item.Tradeable != nil && !*item.Tradeable
+}
+
+// MatchesItem checks if a sheet item name matches a log item name or ID under the strategy
+func MatchesItem(strategy MatchStrategy, sheetItemName, logItemName string, logItemID int) bool {
+	return ItemMatchRank(strategy, sheetItemName, logItemName, logItemID) > NoMatch
 }
@@ -0,0 +1,77 @@
+package resolution
+
+import "strings"
+
+// MatchStrategy decides how sheet names are compared with provider log entries. Rows
+// hold either the resolved name or an ID fallback such as "Item ID: X" when the name
+// couldn't be resolved, set with MATCH_STRATEGY.
+type MatchStrategy string
+
+const (
+	// MatchNameFirst matches rows by name or ID fallback alike, taking the latest as
+	// matching always has
+	MatchNameFirst MatchStrategy = "name_first"
+	// MatchIDFirst prefers rows holding the ID fallback, then rows whose name matches
+	MatchIDFirst MatchStrategy = "id_first"
+	// MatchIDOnly ignores names entirely, so renamed members and items can't mismatch. The
+	// sheet has no ID columns, so only rows still holding an ID fallback can match.
+	MatchIDOnly MatchStrategy = "id_only"
+)
+
+// Match ranks returned by the rank functions; a higher rank is a better match
+const (
+	NoMatch        = 0
+	FallbackMatch  = 1
+	PreferredMatch = 2
+)
+
+// ParseMatchStrategy maps a MATCH_STRATEGY value to a strategy. An empty value is
+// name_first; anything else unrecognized is reported as not ok. Note id_only never
+// matches a row whose name was resolved, since IDs only appear in the fallback text.
+func ParseMatchStrategy(value string) (MatchStrategy, bool) {
+	switch s := MatchStrategy(strings.ToLower(strings.TrimSpace(value))); s {
+	case "":
+		return MatchNameFirst, true
+	case MatchNameFirst, MatchIDFirst, MatchIDOnly:
+		return s, true
+	default:
+		return MatchNameFirst, false
+	}
+}
+
+// rank compares a sheet value with a log entry's name; byID reports whether the sheet value
+// is the log entry's ID fallback
+func (s MatchStrategy) rank(sheetValue, logName string, byID bool) int {
+	byName := logName != "" && sheetValue == logName
+	switch s {
+	case MatchIDOnly:
+		if byID {
+			return PreferredMatch
+		}
+	case MatchIDFirst:
+		if byID {
+			return PreferredMatch
+		}
+		if byName {
+			return FallbackMatch
+		}
+	default:
+		if byName {
+			return PreferredMatch
+		}
+		if byID {
+			return FallbackMatch
+		}
+	}
+	return NoMatch
+}
+
+// UserMatchRank ranks how well a sheet user name matches a log user under the strategy
+func UserMatchRank(strategy MatchStrategy, sheetUserName, logUserName string, logUserID int) int {
+	return strategy.rank(sheetUserName, logUserName, ParseUserFallback(sheetUserName) == logUserID)
+}
+
+// ItemMatchRank ranks how well a sheet item name matches a log item under the strategy
+func ItemMatchRank(strategy MatchStrategy, sheetItemName, logItemName string, logItemID int) int {
+	return strategy.rank(sheetItemName, logItemName, ParseItemFallback(sheetItemName) == logItemID)
+}
@@ -0,0 +1,90 @@
+package resolution
+
+import "testing"
+
+func TestMatchRanksByStrategy(t *testing.T) {
+	// Alice (ID 1) was renamed from Alicia; one row holds her old name, one her ID fallback
+	cases := []struct {
+		strategy  MatchStrategy
+		sheetName string
+		want      int
+	}{
+		{MatchNameFirst, "Alice", PreferredMatch},
+		{MatchNameFirst, "User ID: 1", FallbackMatch},
+		{MatchNameFirst, "Alicia", NoMatch},
+		{MatchIDFirst, "Alice", FallbackMatch},
+		{MatchIDFirst, "User ID: 1", PreferredMatch},
+		{MatchIDFirst, "User ID: 2", NoMatch},
+		{MatchIDOnly, "Alice", NoMatch},
+		{MatchIDOnly, "User ID: 1", PreferredMatch},
+	}
+	for _, c := range cases {
+		if got := UserMatchRank(c.strategy, c.sheetName, "Alice", 1); got != c.want {
+			t.Errorf("%s: UserMatchRank(%q) = %d, want %d", c.strategy, c.sheetName, got, c.want)
+		}
+	}
+
+	if !MatchesItem(MatchNameFirst, "Item ID: 206", "Xanax", 206) {
+		t.Error("Expected name_first to fall back to the item ID")
+	}
+	if MatchesItem(MatchIDOnly, "Xanax", "Xanax", 206) {
+		t.Error("Expected id_only to ignore a matching item name")
+	}
+}
+
+func TestParseMatchStrategy(t *testing.T) {
+	cases := []struct {
+		value string
+		want  MatchStrategy
+		ok    bool
+	}{
+		{"", MatchNameFirst, true},
+		{"id_first", MatchIDFirst, true},
+		{" ID_ONLY ", MatchIDOnly, true},
+		{"fuzzy", MatchNameFirst, false},
+	}
+	for _, c := range cases {
+		got, ok := ParseMatchStrategy(c.value)
+		if got != c.want || ok != c.ok {
+			t.Errorf("ParseMatchStrategy(%q) = %q, %v; want %q, %v", c.value, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestMatchesWithCustomFallbackFormat(t *testing.T) {
+	if err := SetFallbackFormats("Unknown item #%d", "Member [%d]"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer SetFallbackFormats("", "")
+
+	if got := ItemFallback(206); got != "Unknown item #206" {
+		t.Errorf("ItemFallback(206) = %q", got)
+	}
+	if !MatchesItem(MatchNameFirst, ItemFallback(206), "Xanax", 206) {
+		t.Error("Expected the custom item fallback to match by ID")
+	}
+	if !MatchesUser(MatchIDOnly, UserFallback(1), "Alice", 1) {
+		t.Error("Expected the custom user fallback to match by ID")
+	}
+	if MatchesUser(MatchIDOnly, UserFallback(12), "Alice", 1) {
+		t.Error("Expected a different user ID not to match")
+	}
+	if !MatchesItem(MatchIDOnly, "Item ID: 206", "Xanax", 206) {
+		t.Error("Expected rows written with the default format to keep matching")
+	}
+	if ParseItemFallback("Unknown item #abc") != 0 || ParseUserFallback("Alice") != 0 {
+		t.Error("Expected real names not to parse as fallbacks")
+	}
+}
+
+func TestSetFallbackFormatsRejectsInvalidTemplates(t *testing.T) {
+	defer SetFallbackFormats("", "")
+	for _, format := range []string{"Unknown item", "%d and %d", "%s #%d"} {
+		if err := SetFallbackFormats(format, ""); err == nil {
+			t.Errorf("Expected %q to be rejected", format)
+		}
+	}
+	if ItemFallback(1) != "Item ID: 1" {
+		t.Errorf("Expected a rejected format to keep the default, got %q", ItemFallback(1))
+	}
+}
@@ -2,6 +2,7 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/rand"
@@ -15,8 +16,105 @@ type Config struct {
 	Timeout    time.Duration
 }
 
+// MinBaseDelay replaces a zero or sub-millisecond BaseDelay so a misconfigured retry
+// can't spin in a hot loop on instantly failing operations
+const MinBaseDelay = 100 * time.Millisecond
+
+// Validate reports config values that WithRetry has to correct or that make every attempt fail
+func (c Config) Validate() error {
+	var errs []error
+	if c.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("MaxRetries %d is negative", c.MaxRetries))
+	}
+	if c.BaseDelay < time.Millisecond {
+		errs = append(errs, fmt.Errorf("BaseDelay %v is below 1ms, %v is used instead", c.BaseDelay, MinBaseDelay))
+	}
+	if c.MaxDelay < c.BaseDelay {
+		errs = append(errs, fmt.Errorf("MaxDelay %v is less than BaseDelay %v", c.MaxDelay, c.BaseDelay))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("Timeout %v is not positive, every attempt would time out immediately", c.Timeout))
+	}
+	return errors.Join(errs...)
+}
+
+// normalize replaces values that would make WithRetry spin or misbehave with safe ones
+func (c Config) normalize() Config {
+	if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	}
+	if c.BaseDelay < time.Millisecond {
+		c.BaseDelay = MinBaseDelay
+	}
+	if c.MaxDelay < c.BaseDelay {
+		c.MaxDelay = c.BaseDelay
+	}
+	return c
+}
+
+// PermanentError marks an error that should not be retried
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps err so that WithRetry returns it immediately instead of retrying
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent reports whether err, or any error it wraps, was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// DelayError asks WithRetry to wait at least Delay before the next attempt, such as a
+// server's Retry-After or a quota window
+type DelayError struct {
+	Err   error
+	Delay time.Duration
+}
+
+func (e *DelayError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DelayError) Unwrap() error {
+	return e.Err
+}
+
+// After wraps err so that WithRetry waits at least delay before retrying it, even past
+// MaxDelay; the context still bounds the wait
+func After(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &DelayError{Err: err, Delay: delay}
+}
+
+// minDelay returns the delay requested by After anywhere in err's chain, or 0
+func minDelay(err error) time.Duration {
+	var delayErr *DelayError
+	if errors.As(err, &delayErr) {
+		return delayErr.Delay
+	}
+	return 0
+}
+
 func WithRetry[T any](ctx context.Context, config Config, operation func(context.Context) (T, error)) (T, error) {
 	var zero T
+	config = config.normalize()
 	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
 		select {
 		case <-ctx.Done():
@@ -35,6 +133,14 @@ func WithRetry[T any](ctx context.Context, config Config, operation func(context
 			return result, nil
 		}
 
+		if IsPermanent(err) {
+			slog.Debug("Operation failed with permanent error, not retrying",
+				"error", err,
+				"attempt", attempt+1,
+			)
+			return zero, err
+		}
+
 		slog.Debug("Operation failed",
 			"error", err,
 			"attempt", attempt+1,
@@ -43,6 +149,10 @@ func WithRetry[T any](ctx context.Context, config Config, operation func(context
 
 		if attempt < config.MaxRetries {
 			delay := calculateBackoffDelay(attempt, config.BaseDelay, config.MaxDelay)
+			if requested := minDelay(err); requested > delay {
+				slog.Info("Operation asked to wait before retrying", "delay", requested, "error", err)
+				delay = requested
+			}
 			slog.Debug("Retrying after delay",
 				"delay", delay,
 				"next_attempt", attempt+2,
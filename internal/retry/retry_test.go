@@ -88,6 +88,33 @@ func TestWithRetryFailureAfterMaxRetries(t *testing.T) {
 	}
 }
 
+func TestWithRetryPermanentError(t *testing.T) {
+	config := Config{
+		MaxRetries: 3,
+		BaseDelay:  10 * time.Millisecond,
+		MaxDelay:   100 * time.Millisecond,
+		Timeout:    1 * time.Second,
+	}
+
+	underlying := errors.New("not found")
+	callCount := 0
+	operation := func(ctx context.Context) (string, error) {
+		callCount++
+		return "", Permanent(underlying)
+	}
+
+	_, err := WithRetry(context.Background(), config, operation)
+	if !errors.Is(err, underlying) {
+		t.Errorf("Expected underlying error, got %v", err)
+	}
+	if !IsPermanent(err) {
+		t.Error("Expected error to be marked permanent")
+	}
+	if callCount != 1 {
+		t.Errorf("Expected 1 call, got %d", callCount)
+	}
+}
+
 func TestWithRetryContextCancellation(t *testing.T) {
 	config := Config{
 		MaxRetries: 5,
@@ -215,3 +242,67 @@ func TestWithRetryDifferentTypes(t *testing.T) {
 		t.Errorf("Expected 'test', got %s", structResult.Value)
 	}
 }
+
+func TestWithRetryZeroBaseDelayUsesFloor(t *testing.T) {
+	config := Config{
+		MaxRetries: 1,
+		BaseDelay:  0,
+		MaxDelay:   0,
+		Timeout:    1 * time.Second,
+	}
+
+	start := time.Now()
+	_, err := WithRetry(context.Background(), config, func(ctx context.Context) (string, error) {
+		return "", errors.New("instant failure")
+	})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed < MinBaseDelay/2 {
+		t.Errorf("Expected retry to wait at least %v, took %v", MinBaseDelay/2, elapsed)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{MaxRetries: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second, Timeout: time.Second}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+
+	invalid := []Config{
+		{MaxRetries: -1, BaseDelay: time.Second, MaxDelay: time.Second, Timeout: time.Second},
+		{MaxRetries: 3, BaseDelay: 0, MaxDelay: time.Second, Timeout: time.Second},
+		{MaxRetries: 3, BaseDelay: time.Second, MaxDelay: time.Millisecond, Timeout: time.Second},
+		{MaxRetries: 3, BaseDelay: time.Second, MaxDelay: time.Second, Timeout: 0},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected validation error for %+v", config)
+		}
+	}
+}
+
+func TestWithRetryWaitsRequestedDelay(t *testing.T) {
+	config := Config{
+		MaxRetries: 1,
+		BaseDelay:  time.Millisecond,
+		MaxDelay:   time.Millisecond,
+		Timeout:    time.Second,
+	}
+
+	callCount := 0
+	start := time.Now()
+	_, err := WithRetry(context.Background(), config, func(ctx context.Context) (string, error) {
+		callCount++
+		if callCount == 1 {
+			return "", After(errors.New("rate limited"), 50*time.Millisecond)
+		}
+		return "ok", nil
+	})
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected the requested delay past MaxDelay, retried after %v", elapsed)
+	}
+}
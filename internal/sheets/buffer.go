@@ -0,0 +1,97 @@
+package sheets
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"torn_oc_items/internal/notifications"
+)
+
+// AppendBuffer holds newly detected rows for a coalescing window so rows found across
+// quick successive loops are written with a single append. Rows stay buffered until
+// an append succeeds, so a failed flush is retried on the next one.
+type AppendBuffer struct {
+	mu         sync.Mutex
+	window     time.Duration
+	rows       [][]interface{}
+	items      []notifications.ItemInfo
+	totalItems int
+	oldest     time.Time
+}
+
+// NewAppendBuffer creates a buffer that holds rows for at least the given window
+func NewAppendBuffer(window time.Duration) *AppendBuffer {
+	return &AppendBuffer{window: window}
+}
+
+// Add buffers rows along with their notification details. totalItems is the number
+// of supplied items the rows were drawn from and is only used for logging.
+func (b *AppendBuffer) Add(rows [][]interface{}, items []notifications.ItemInfo, totalItems int, now time.Time) {
+	if len(rows) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.rows) == 0 {
+		b.oldest = now
+	}
+	b.rows = append(b.rows, rows...)
+	b.items = append(b.items, items...)
+	b.totalItems += totalItems
+	slog.Debug("Buffered rows for coalesced append", "added", len(rows), "pending", len(b.rows))
+}
+
+// Len returns the number of buffered rows
+func (b *AppendBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.rows)
+}
+
+// Due reports whether the oldest buffered row has waited out the coalescing window
+func (b *AppendBuffer) Due(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.rows) > 0 && now.Sub(b.oldest) >= b.window
+}
+
+// MarkPending adds the duplicate-detection key of every buffered row to existing so
+// rows waiting to be written aren't detected as new again
+func (b *AppendBuffer) MarkPending(existing map[string]bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, item := range b.items {
+		existing[ExistingKey(item.CrimeURL, item.UserName, item.ItemName)] = true
+	}
+	// Rows added without a notification have no item, so their keys come from the row
+	for _, row := range b.rows {
+		existing[ExistingKey(extractStringField(row, 2), extractStringField(row, 5), extractStringField(row, 4))] = true
+	}
+}
+
+// Flush appends all buffered rows in one call and sends their notifications, returning
+// the notification details of the rows written. The buffer is only cleared once the
+// append succeeds.
+func (b *AppendBuffer) Flush(ctx context.Context, sheetsClient *Client, notificationClient *notifications.Client) ([]notifications.ItemInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.rows) == 0 {
+		return nil, nil
+	}
+
+	slog.Debug("Flushing coalesced rows", "rows", len(b.rows), "waited", time.Since(b.oldest))
+	if err := UpdateSheet(ctx, sheetsClient, b.rows, b.items, b.totalItems, notificationClient); err != nil {
+		return nil, err
+	}
+
+	written := b.items
+	b.rows = nil
+	b.items = nil
+	b.totalItems = 0
+	return written, nil
+}
@@ -0,0 +1,45 @@
+package sheets
+
+import (
+	"testing"
+	"time"
+
+	"torn_oc_items/internal/notifications"
+)
+
+func TestAppendBufferDue(t *testing.T) {
+	buffer := NewAppendBuffer(30 * time.Second)
+	start := time.Now()
+
+	if buffer.Due(start.Add(time.Hour)) {
+		t.Error("Expected empty buffer to never be due")
+	}
+
+	buffer.Add([][]interface{}{{"Needed"}}, []notifications.ItemInfo{{ItemName: "Xanax"}}, 1, start)
+	buffer.Add([][]interface{}{{"Needed"}}, []notifications.ItemInfo{{ItemName: "Vicodin"}}, 1, start.Add(20*time.Second))
+
+	if buffer.Len() != 2 {
+		t.Errorf("Expected 2 buffered rows, got %d", buffer.Len())
+	}
+	if buffer.Due(start.Add(29 * time.Second)) {
+		t.Error("Expected buffer not to be due inside the window")
+	}
+	if !buffer.Due(start.Add(30 * time.Second)) {
+		t.Error("Expected buffer to be due once the oldest row has waited out the window")
+	}
+}
+
+func TestAppendBufferMarkPending(t *testing.T) {
+	buffer := NewAppendBuffer(time.Minute)
+	buffer.Add([][]interface{}{{"Needed"}}, []notifications.ItemInfo{
+		{ItemName: "Xanax", UserName: "Alice", CrimeURL: "http://www.torn.com/factions.php?step=your#/tab=crimes&crimeId=1"},
+	}, 1, time.Now())
+
+	existing := BuildExistingMap(nil)
+	buffer.MarkPending(existing)
+
+	key := "1|Alice|Xanax"
+	if !existing[key] {
+		t.Errorf("Expected buffered row %q to be marked as existing", key)
+	}
+}
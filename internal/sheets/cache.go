@@ -0,0 +1,60 @@
+package sheets
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// readCache keeps the last full-sheet read for the rest of a process loop so the phases
+// that each need the sheet share one read. Any write through the client invalidates it,
+// since the next phase must see the rows just written.
+type readCache struct {
+	mu      sync.Mutex
+	enabled bool
+	range_  string
+	data    [][]interface{}
+	valid   bool
+}
+
+// EnableReadCache turns on sharing existing-data reads within a loop; see ResetReadCache
+func (c *Client) EnableReadCache(enabled bool) {
+	c.cache.mu.Lock()
+	c.cache.enabled = enabled
+	c.cache.valid = false
+	c.cache.mu.Unlock()
+}
+
+// ResetReadCache drops any cached read so the loop starting now sees edits made to the
+// sheet by hand since the last loop
+func (c *Client) ResetReadCache() {
+	c.invalidateReadCache()
+}
+
+func (c *Client) invalidateReadCache() {
+	c.cache.mu.Lock()
+	c.cache.valid = false
+	c.cache.data = nil
+	c.cache.mu.Unlock()
+}
+
+// cachedRead returns the cached data for range_ when the cache holds it
+func (c *Client) cachedRead(range_ string) ([][]interface{}, bool) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	if !c.cache.enabled || !c.cache.valid || c.cache.range_ != range_ {
+		return nil, false
+	}
+	slog.Debug("Using cached sheet read", "range", range_, "rows", len(c.cache.data))
+	return c.cache.data, true
+}
+
+func (c *Client) storeRead(range_ string, data [][]interface{}) {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	if !c.cache.enabled {
+		return
+	}
+	c.cache.range_ = range_
+	c.cache.data = data
+	c.cache.valid = true
+}
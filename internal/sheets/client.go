@@ -3,13 +3,24 @@ package sheets
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
+	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
 )
 
 type Client struct {
 	service *sheets.Service
+	drive   *drive.Service // reads the spreadsheet's last-modified time
+	memory  *memorySheet   // set instead of service in MOCK_MODE
+	cache   readCache
+	edits   editTracker
+	// sheet title resolved from SPREADSHEET_GID, replacing the name in SPREADSHEET_RANGE
+	gidTitle string
+	readOnly bool // MONITOR_ONLY: writes are skipped
+	// spreadsheet ID set at construction; SPREADSHEET_ID is read when empty
+	id string
 }
 
 func NewClient(ctx context.Context, credentialsFile string) (*Client, error) {
@@ -17,22 +28,73 @@ func NewClient(ctx context.Context, credentialsFile string) (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create sheets service: %w", err)
 	}
+	client := &Client{service: service}
 
-	return &Client{
-		service: service,
-	}, nil
+	// Only the edit check reads Drive metadata, so the Drive API needn't be enabled otherwise
+	if EditCheckEnabled() {
+		client.drive, err = drive.NewService(ctx,
+			option.WithAuthCredentialsFile(option.ServiceAccount, credentialsFile),
+			option.WithScopes(drive.DriveMetadataReadonlyScope),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create drive service: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+// spreadsheetID returns the ID of the spreadsheet the client works on
+func (c *Client) spreadsheetID() string {
+	if c.id != "" {
+		return c.id
+	}
+	return getRequiredEnv("SPREADSHEET_ID")
+}
+
+// SetReadOnly makes every write a no-op that reports success, for MONITOR_ONLY. Reads are
+// unaffected, so each loop still sees the sheet as it really is.
+func (c *Client) SetReadOnly(readOnly bool) {
+	c.readOnly = readOnly
+}
+
+// ReadOnly reports whether writes are being skipped
+func (c *Client) ReadOnly() bool {
+	return c.readOnly
+}
+
+// skipWrite reports whether a write should be skipped because the client is read-only
+func (c *Client) skipWrite(operation, range_ string, rows int) bool {
+	if !c.readOnly {
+		return false
+	}
+	slog.Debug("Monitor-only, skipping sheet write", "operation", operation, "range", range_, "rows", rows)
+	return true
 }
 
 func (c *Client) ReadSheet(ctx context.Context, spreadsheetID, range_ string) ([][]interface{}, error) {
+	if c.memory != nil {
+		return c.memory.tab(range_).read(range_)
+	}
+
 	resp, err := c.service.Spreadsheets.Values.Get(spreadsheetID, range_).Context(ctx).Do()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read sheet: %w", err)
+		return nil, classifyError("failed to read sheet", err)
 	}
 
 	return resp.Values, nil
 }
 
 func (c *Client) AppendRows(ctx context.Context, spreadsheetID, range_ string, rows [][]interface{}) error {
+	if c.skipWrite("append", range_, len(rows)) {
+		return nil
+	}
+	c.invalidateReadCache()
+	if c.memory != nil {
+		c.memory.tab(range_).append(rows)
+		return nil
+	}
+
 	valueRange := &sheets.ValueRange{
 		Values: rows,
 	}
@@ -43,13 +105,57 @@ func (c *Client) AppendRows(ctx context.Context, spreadsheetID, range_ string, r
 		Context(ctx).
 		Do()
 	if err != nil {
-		return fmt.Errorf("failed to append rows: %w", err)
+		return classifyError("failed to append rows", err)
 	}
 
 	return nil
 }
 
+// InsertRows inserts rows directly below the first headerRows rows of the named sheet,
+// shifting existing rows down, then writes the values into the new rows
+func (c *Client) InsertRows(ctx context.Context, spreadsheetID, sheetName string, headerRows int64, rows [][]interface{}) error {
+	if c.skipWrite("insert", sheetName, len(rows)) {
+		return nil
+	}
+	c.invalidateReadCache()
+	if c.memory != nil {
+		c.memory.insert(int(headerRows), rows)
+		return nil
+	}
+
+	sheetID, err := c.GetSheetID(ctx, spreadsheetID, sheetName)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.service.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: []*sheets.Request{{
+			InsertDimension: &sheets.InsertDimensionRequest{
+				Range: &sheets.DimensionRange{
+					SheetId:    sheetID,
+					Dimension:  "ROWS",
+					StartIndex: headerRows,
+					EndIndex:   headerRows + int64(len(rows)),
+				},
+			},
+		}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return classifyError("failed to insert rows", err)
+	}
+
+	return c.UpdateRange(ctx, spreadsheetID, fmt.Sprintf("%s!A%d", sheetName, headerRows+1), rows)
+}
+
 func (c *Client) UpdateRange(ctx context.Context, spreadsheetID, range_ string, values [][]interface{}) error {
+	if c.skipWrite("update", range_, len(values)) {
+		return nil
+	}
+	c.invalidateReadCache()
+	if c.memory != nil {
+		return c.memory.tab(range_).update(range_, values)
+	}
+
 	valueRange := &sheets.ValueRange{
 		Values: values,
 	}
@@ -59,7 +165,98 @@ func (c *Client) UpdateRange(ctx context.Context, spreadsheetID, range_ string,
 		Context(ctx).
 		Do()
 	if err != nil {
-		return fmt.Errorf("failed to update range: %w", err)
+		return classifyError("failed to update range", err)
+	}
+
+	return nil
+}
+
+// ColumnFormat describes a number format applied to an entire column
+type ColumnFormat struct {
+	Column  int64  // zero-based column index
+	Type    string // Sheets NumberFormat type, e.g. "CURRENCY" or "DATE_TIME"
+	Pattern string
+}
+
+// GetSheetID returns the numeric ID of the named sheet (tab) within a spreadsheet
+func (c *Client) GetSheetID(ctx context.Context, spreadsheetID, sheetName string) (int64, error) {
+	if c.memory != nil {
+		return 0, nil
+	}
+
+	spreadsheet, err := c.service.Spreadsheets.Get(spreadsheetID).
+		Fields("sheets.properties").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return 0, classifyError("failed to get spreadsheet", err)
+	}
+
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties != nil && sheet.Properties.Title == sheetName {
+			return sheet.Properties.SheetId, nil
+		}
+	}
+
+	return 0, fmt.Errorf("sheet %q: %w", sheetName, ErrNotFound)
+}
+
+// GetSheetTitle returns the current name of the sheet (tab) with the given numeric ID
+func (c *Client) GetSheetTitle(ctx context.Context, spreadsheetID string, sheetID int64) (string, error) {
+	if c.memory != nil {
+		return "", fmt.Errorf("sheet ID %d: sheet IDs are not supported by the mock sheet", sheetID)
+	}
+
+	spreadsheet, err := c.service.Spreadsheets.Get(spreadsheetID).
+		Fields("sheets.properties").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return "", classifyError("failed to get spreadsheet", err)
+	}
+
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties != nil && sheet.Properties.SheetId == sheetID {
+			return sheet.Properties.Title, nil
+		}
+	}
+
+	return "", fmt.Errorf("sheet ID %d: %w", sheetID, ErrNotFound)
+}
+
+// FormatColumns applies number formats to whole columns in a single batch update
+func (c *Client) FormatColumns(ctx context.Context, spreadsheetID string, sheetID int64, formats []ColumnFormat) error {
+	if c.memory != nil || c.skipWrite("format", fmt.Sprintf("sheet %d", sheetID), 0) {
+		return nil
+	}
+
+	var requests []*sheets.Request
+	for _, f := range formats {
+		requests = append(requests, &sheets.Request{
+			RepeatCell: &sheets.RepeatCellRequest{
+				Range: &sheets.GridRange{
+					SheetId:          sheetID,
+					StartColumnIndex: f.Column,
+					EndColumnIndex:   f.Column + 1,
+				},
+				Cell: &sheets.CellData{
+					UserEnteredFormat: &sheets.CellFormat{
+						NumberFormat: &sheets.NumberFormat{
+							Type:    f.Type,
+							Pattern: f.Pattern,
+						},
+					},
+				},
+				Fields: "userEnteredFormat.numberFormat",
+			},
+		})
+	}
+
+	_, err := c.service.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{
+		Requests: requests,
+	}).Context(ctx).Do()
+	if err != nil {
+		return classifyError("failed to format columns", err)
 	}
 
 	return nil
@@ -1,11 +1,21 @@
 package sheets
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"log/slog"
 )
 
+// HeaderRows is the number of header rows above the data; top insertion places new rows
+// directly below them
+const HeaderRows = 1
+
 // getRequiredEnv fetches a required environment variable or exits if not set.
 func getRequiredEnv(key string) string {
 	value := os.Getenv(key)
@@ -24,3 +34,149 @@ func getEnvWithDefault(key, defaultValue string) string {
 	}
 	return value
 }
+
+// sheetRange returns SPREADSHEET_RANGE, with the sheet name replaced by the title resolved
+// from SPREADSHEET_GID when ResolveSheetGID found one
+func (c *Client) sheetRange() string {
+	sheetRange := getEnvWithDefault("SPREADSHEET_RANGE", "Test Sheet!A1")
+	if c.gidTitle == "" {
+		return sheetRange
+	}
+	_, cell, found := strings.Cut(sheetRange, "!")
+	if !found {
+		cell = "A1"
+	}
+	return c.gidTitle + "!" + cell
+}
+
+// sheetName returns the name of the sheet (tab) the tool reads and writes
+func (c *Client) sheetName() string {
+	return strings.Split(c.sheetRange(), "!")[0]
+}
+
+// ResolveSheetGID looks up the current title of the sheet with ID SPREADSHEET_GID, the
+// gid= number in the sheet's URL, so renaming the tab doesn't break the tool. It does
+// nothing when SPREADSHEET_GID is unset, leaving the name in SPREADSHEET_RANGE in use.
+func ResolveSheetGID(ctx context.Context, sheetsClient *Client) error {
+	value := strings.TrimSpace(os.Getenv("SPREADSHEET_GID"))
+	if value == "" {
+		return nil
+	}
+	gid, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || gid < 0 {
+		return fmt.Errorf("SPREADSHEET_GID %q is not a sheet ID", value)
+	}
+
+	title, err := sheetsClient.GetSheetTitle(ctx, sheetsClient.spreadsheetID(), gid)
+	if err != nil {
+		return err
+	}
+	sheetsClient.gidTitle = title
+	slog.Info("Resolved sheet from SPREADSHEET_GID", "gid", gid, "sheet", title)
+	return nil
+}
+
+// InsertAtTop reports whether SHEET_INSERT=top, which inserts new rows below the header so
+// the newest rows are on top. The default, bottom, appends them.
+func InsertAtTop() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("SHEET_INSERT")), "top")
+}
+
+// NewestFirst returns the indexes of n sheet items in row order, ordered from the newest
+// row to the oldest: bottom-up when appending, top-down when inserting at the top
+func NewestFirst(n int) []int {
+	order := make([]int, n)
+	top := InsertAtTop()
+	for i := range order {
+		if top {
+			order[i] = i
+		} else {
+			order[i] = n - 1 - i
+		}
+	}
+	return order
+}
+
+// SkipMarketValue reports whether SKIP_MARKET_VALUE=true, for factions that don't use the
+// value column: provided rows skip the market value lookup and leave column G untouched
+func SkipMarketValue() bool {
+	return os.Getenv("SKIP_MARKET_VALUE") == "true"
+}
+
+// EditCheckEnabled reports whether SHEET_EDIT_CHECK=true, which tracks the spreadsheet's
+// last-modified time through the Drive API
+func EditCheckEnabled() bool {
+	return os.Getenv("SHEET_EDIT_CHECK") == "true"
+}
+
+// CombineRowWrites reports whether SHEET_ROW_WRITES=combined, which writes a provided
+// row's status and provider (A:B) in one call, three calls per row instead of four
+func CombineRowWrites() bool {
+	return os.Getenv("SHEET_ROW_WRITES") == "combined"
+}
+
+// Values of MARKET_VALUE_UNAVAILABLE, for provided rows whose market value lookup failed
+const (
+	MarketValueBlank = "blank" // leave column G empty to be filled in by hand
+	MarketValueZero  = "zero"  // write 0, as if the item were worthless
+	MarketValueDefer = "defer" // leave the row needed and match it again next loop
+)
+
+var marketValueWarnOnce sync.Once
+
+// MarketValueUnavailable returns MARKET_VALUE_UNAVAILABLE, warning once and using blank
+// when the value is unknown
+func MarketValueUnavailable() string {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv("MARKET_VALUE_UNAVAILABLE")))
+	switch value {
+	case MarketValueBlank, MarketValueZero, MarketValueDefer:
+		return value
+	case "":
+		return MarketValueBlank
+	default:
+		marketValueWarnOnce.Do(func() {
+			slog.Warn("Unknown MARKET_VALUE_UNAVAILABLE, leaving unknown values blank", "market_value_unavailable", value)
+		})
+		return MarketValueBlank
+	}
+}
+
+// AuditSheetRange returns AUDIT_SHEET_RANGE, e.g. "Audit!A1", the tab that gets an
+// append-only record of every provided match; empty disables the audit log
+func AuditSheetRange() string {
+	return strings.TrimSpace(os.Getenv("AUDIT_SHEET_RANGE"))
+}
+
+// DedupeWindow returns DEDUPE_WINDOW, a Go duration such as "720h" after which fulfilled
+// rows stop suppressing the same need; zero (unset or invalid) keeps them forever
+func DedupeWindow() time.Duration {
+	value := os.Getenv("DEDUPE_WINDOW")
+	if value == "" {
+		return 0
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil || window < 0 {
+		slog.Warn("Invalid DEDUPE_WINDOW, keeping duplicates forever", "dedupe_window", value)
+		return 0
+	}
+	return window
+}
+
+// DefaultRateLimitBackoff is the wait after a rate-limited request without a Retry-After,
+// about half of the per-minute quota window
+const DefaultRateLimitBackoff = 30 * time.Second
+
+// RateLimitBackoff returns SHEET_RATE_LIMIT_BACKOFF, a Go duration such as "45s" to wait
+// before retrying a request the Sheets API rejected with 429 and no Retry-After
+func RateLimitBackoff() time.Duration {
+	value := os.Getenv("SHEET_RATE_LIMIT_BACKOFF")
+	if value == "" {
+		return DefaultRateLimitBackoff
+	}
+	backoff, err := time.ParseDuration(value)
+	if err != nil || backoff <= 0 {
+		slog.Warn("Invalid SHEET_RATE_LIMIT_BACKOFF, using default", "sheet_rate_limit_backoff", value, "default", DefaultRateLimitBackoff)
+		return DefaultRateLimitBackoff
+	}
+	return backoff
+}
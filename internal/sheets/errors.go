@@ -0,0 +1,99 @@
+package sheets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/api/googleapi"
+
+	"torn_oc_items/internal/retry"
+)
+
+// Typed errors returned (wrapped) by Client methods so callers can decide whether to retry
+var (
+	ErrPermission  = errors.New("sheets permission denied")
+	ErrRateLimited = errors.New("sheets rate limited")
+	ErrNotFound    = errors.New("sheets resource not found")
+	ErrTransient   = errors.New("sheets transient error")
+)
+
+// IsRetryable reports whether a Client error is worth retrying
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrTransient)
+}
+
+// classifyError wraps a Sheets API error with a typed error describing its cause.
+// Errors that are not retryable are also marked permanent so retry.WithRetry gives up
+// immediately instead of retrying a permission or not-found failure.
+func classifyError(operation string, err error) error {
+	kind := errorKind(err)
+	if kind == nil {
+		err = fmt.Errorf("%s: %w", operation, err)
+	} else {
+		err = fmt.Errorf("%s: %w: %w", operation, kind, err)
+	}
+	if !IsRetryable(err) {
+		return retry.Permanent(err)
+	}
+	if errors.Is(kind, ErrRateLimited) {
+		// Quotas are per minute, so retrying on the usual short backoff only burns more of it
+		return retry.After(err, rateLimitDelay(err))
+	}
+	return err
+}
+
+// rateLimitDelay returns how long to wait after a 429: the response's Retry-After when
+// present, otherwise RateLimitBackoff
+func rateLimitDelay(err error) time.Duration {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Header != nil {
+		if delay, ok := parseRetryAfter(apiErr.Header.Get("Retry-After"), time.Now()); ok {
+			return delay
+		}
+	}
+	return RateLimitBackoff()
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// errorKind maps an error from the Sheets API to one of the typed errors, or nil for
+// client errors that don't fit a category
+func errorKind(err error) error {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		// Network failures and timeouts surface without an API error
+		return ErrTransient
+	}
+
+	switch {
+	case apiErr.Code == http.StatusUnauthorized || apiErr.Code == http.StatusForbidden:
+		return ErrPermission
+	case apiErr.Code == http.StatusNotFound:
+		return ErrNotFound
+	case apiErr.Code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case apiErr.Code == http.StatusRequestTimeout || apiErr.Code >= 500:
+		return ErrTransient
+	default:
+		return nil
+	}
+}
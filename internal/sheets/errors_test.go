@@ -0,0 +1,89 @@
+package sheets
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+
+	"torn_oc_items/internal/retry"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		kind      error
+		retryable bool
+	}{
+		{"forbidden", &googleapi.Error{Code: 403}, ErrPermission, false},
+		{"unauthorized", &googleapi.Error{Code: 401}, ErrPermission, false},
+		{"not found", &googleapi.Error{Code: 404}, ErrNotFound, false},
+		{"rate limited", &googleapi.Error{Code: 429}, ErrRateLimited, true},
+		{"server error", &googleapi.Error{Code: 503}, ErrTransient, true},
+		{"network error", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrTransient, true},
+	}
+
+	for _, test := range tests {
+		err := classifyError("failed to read sheet", test.err)
+		if !errors.Is(err, test.kind) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.kind, err)
+		}
+		if IsRetryable(err) != test.retryable {
+			t.Errorf("%s: expected retryable=%v", test.name, test.retryable)
+		}
+		if retry.IsPermanent(err) == test.retryable {
+			t.Errorf("%s: expected permanent=%v", test.name, !test.retryable)
+		}
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: expected underlying error to be preserved", test.name)
+		}
+	}
+}
+
+func TestClassifyErrorRateLimitDelay(t *testing.T) {
+	t.Setenv("SHEET_RATE_LIMIT_BACKOFF", "45s")
+
+	var delayErr *retry.DelayError
+	err := classifyError("failed to update sheet", &googleapi.Error{Code: 429})
+	if !errors.As(err, &delayErr) || delayErr.Delay != 45*time.Second {
+		t.Errorf("Expected a 45s quota backoff without Retry-After, got %v", err)
+	}
+
+	header := http.Header{"Retry-After": []string{"12"}}
+	err = classifyError("failed to update sheet", &googleapi.Error{Code: 429, Header: header})
+	if !errors.As(err, &delayErr) || delayErr.Delay != 12*time.Second {
+		t.Errorf("Expected the Retry-After delay, got %v", err)
+	}
+	if !errors.Is(err, ErrRateLimited) || retry.IsPermanent(err) {
+		t.Errorf("Expected a retryable rate limit error, got %v", err)
+	}
+
+	if err := classifyError("failed to read sheet", &googleapi.Error{Code: 503}); errors.As(err, &delayErr) {
+		t.Errorf("Expected transient errors to keep the usual backoff, got %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		delay time.Duration
+		ok    bool
+	}{
+		{"30", 30 * time.Second, true},
+		{"Mon, 01 Jan 2024 12:00:20 GMT", 20 * time.Second, true},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0, true},
+		{"", 0, false},
+		{"soon", 0, false},
+	}
+	for _, test := range tests {
+		delay, ok := parseRetryAfter(test.value, now)
+		if delay != test.delay || ok != test.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", test.value, delay, ok, test.delay, test.ok)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package sheets
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memorySheet is an in-memory stand-in for the main sheet, used by MOCK_MODE. It ignores
+// spreadsheet IDs and only understands A1-style cell ranges. Ranges naming another sheet,
+// such as the audit tab, go to a separate in-memory tab created on first use.
+type memorySheet struct {
+	mu      sync.Mutex
+	rows    [][]interface{}
+	tabs    map[string]*memorySheet
+	version int64 // bumped by every write, standing in for the last-modified time
+}
+
+// MockSpreadsheetID is the spreadsheet ID of clients created by NewMockClient, which don't
+// need SPREADSHEET_ID
+const MockSpreadsheetID = "mock"
+
+// NewMockClient creates a client backed by an in-memory sheet. When seedFile is set the
+// sheet starts with the rows in that JSON file, a list of rows of cell values.
+func NewMockClient(seedFile string) (*Client, error) {
+	sheet := &memorySheet{}
+	if seedFile != "" {
+		data, err := os.ReadFile(seedFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mock sheet: %w", err)
+		}
+		if err := json.Unmarshal(data, &sheet.rows); err != nil {
+			return nil, fmt.Errorf("failed to decode mock sheet: %w", err)
+		}
+	}
+	return &Client{memory: sheet, id: MockSpreadsheetID}, nil
+}
+
+// tab returns the sheet a range refers to: m itself for the SPREADSHEET_RANGE sheet or a
+// range without a sheet name, otherwise the named tab
+func (m *memorySheet) tab(range_ string) *memorySheet {
+	name, _, found := strings.Cut(range_, "!")
+	if !found || name == strings.Split(getEnvWithDefault("SPREADSHEET_RANGE", "Test Sheet!A1"), "!")[0] {
+		return m
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tabs == nil {
+		m.tabs = make(map[string]*memorySheet)
+	}
+	if m.tabs[name] == nil {
+		m.tabs[name] = &memorySheet{}
+	}
+	return m.tabs[name]
+}
+
+// modifiedTime returns a stand-in last-modified time that advances with every write to
+// any tab, as the real spreadsheet's does
+func (m *memorySheet) modifiedTime() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	version := m.version
+	for _, tab := range m.tabs {
+		version += tab.modifiedTime().UnixNano()
+	}
+	return time.Unix(0, version)
+}
+
+func (m *memorySheet) read(range_ string) ([][]interface{}, error) {
+	startRow, startCol, endRow, endCol, err := parseA1Range(range_)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var values [][]interface{}
+	for r := startRow; r < len(m.rows) && (endRow < 0 || r <= endRow); r++ {
+		var row []interface{}
+		for c := startCol; c < len(m.rows[r]) && (endCol < 0 || c <= endCol); c++ {
+			row = append(row, m.rows[r][c])
+		}
+		values = append(values, row)
+	}
+	return values, nil
+}
+
+func (m *memorySheet) append(rows [][]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, row := range rows {
+		m.rows = append(m.rows, append([]interface{}(nil), row...))
+	}
+	m.version++
+	slog.Info("Mock sheet rows appended", "added", len(rows), "total_rows", len(m.rows))
+}
+
+func (m *memorySheet) insert(at int, rows [][]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if at > len(m.rows) {
+		at = len(m.rows)
+	}
+	inserted := make([][]interface{}, 0, len(m.rows)+len(rows))
+	inserted = append(inserted, m.rows[:at]...)
+	for _, row := range rows {
+		inserted = append(inserted, append([]interface{}(nil), row...))
+	}
+	m.rows = append(inserted, m.rows[at:]...)
+	m.version++
+	slog.Info("Mock sheet rows inserted", "added", len(rows), "at_row", at+1, "total_rows", len(m.rows))
+}
+
+func (m *memorySheet) update(range_ string, values [][]interface{}) error {
+	startRow, startCol, _, _, err := parseA1Range(range_)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, row := range values {
+		r := startRow + i
+		for len(m.rows) <= r {
+			m.rows = append(m.rows, nil)
+		}
+		for j, value := range row {
+			c := startCol + j
+			for len(m.rows[r]) <= c {
+				m.rows[r] = append(m.rows[r], "")
+			}
+			m.rows[r][c] = value
+		}
+	}
+	m.version++
+	slog.Info("Mock sheet range updated", "range", range_, "values", values)
+	return nil
+}
+
+// parseA1Range parses ranges like "Sheet!B5" or "Sheet!A1:Z1000" into zero-based bounds;
+// a single cell range has no upper bound
+func parseA1Range(range_ string) (startRow, startCol, endRow, endCol int, err error) {
+	cells := range_
+	if i := strings.LastIndex(range_, "!"); i >= 0 {
+		cells = range_[i+1:]
+	}
+
+	start, end, hasEnd := strings.Cut(cells, ":")
+	if startRow, startCol, err = parseA1Cell(start); err != nil {
+		return 0, 0, 0, 0, err
+	}
+	if !hasEnd {
+		return startRow, startCol, -1, -1, nil
+	}
+	if endRow, endCol, err = parseA1Cell(end); err != nil {
+		return 0, 0, 0, 0, err
+	}
+	return startRow, startCol, endRow, endCol, nil
+}
+
+func parseA1Cell(cell string) (row, col int, err error) {
+	letters := strings.TrimRightFunc(cell, func(r rune) bool { return r >= '0' && r <= '9' })
+	if letters == "" || len(letters) == len(cell) {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", cell)
+	}
+
+	for _, r := range strings.ToUpper(letters) {
+		if r < 'A' || r > 'Z' {
+			return 0, 0, fmt.Errorf("invalid cell reference %q", cell)
+		}
+		col = col*26 + int(r-'A') + 1
+	}
+
+	rowNum, err := strconv.Atoi(cell[len(letters):])
+	if err != nil || rowNum < 1 {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", cell)
+	}
+	return rowNum - 1, col - 1, nil
+}
@@ -0,0 +1,121 @@
+package sheets
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseA1Range(t *testing.T) {
+	tests := []struct {
+		range_                             string
+		startRow, startCol, endRow, endCol int
+	}{
+		{"Test Sheet!A1:Z1000", 0, 0, 999, 25},
+		{"Test Sheet!B5", 4, 1, -1, -1},
+		{"G12", 11, 6, -1, -1},
+		{"Sheet!AA3:AB4", 2, 26, 3, 27},
+	}
+
+	for _, test := range tests {
+		startRow, startCol, endRow, endCol, err := parseA1Range(test.range_)
+		if err != nil {
+			t.Errorf("parseA1Range(%q) returned error: %v", test.range_, err)
+			continue
+		}
+		if startRow != test.startRow || startCol != test.startCol || endRow != test.endRow || endCol != test.endCol {
+			t.Errorf("parseA1Range(%q) = %d,%d,%d,%d, want %d,%d,%d,%d", test.range_,
+				startRow, startCol, endRow, endCol, test.startRow, test.startCol, test.endRow, test.endCol)
+		}
+	}
+
+	for _, invalid := range []string{"Sheet!5", "Sheet!B", "Sheet!B0"} {
+		if _, _, _, _, err := parseA1Range(invalid); err == nil {
+			t.Errorf("parseA1Range(%q) expected error", invalid)
+		}
+	}
+}
+
+func TestUpdateSheetInsertsBelowHeaderWithTopInsert(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "mock")
+	t.Setenv("SPREADSHEET_RANGE", "Mock Sheet!A1")
+	t.Setenv("SHEET_INSERT", "top")
+
+	client := &Client{memory: &memorySheet{rows: [][]interface{}{{"Status"}, {"Needed", "", "old"}}}}
+	if err := UpdateSheet(context.Background(), client, [][]interface{}{{"Needed", "", "new"}}, nil, 1, nil); err != nil {
+		t.Fatalf("UpdateSheet failed: %v", err)
+	}
+
+	rows := client.memory.rows
+	if len(rows) != 3 || rows[1][2] != "new" || rows[2][2] != "old" {
+		t.Errorf("Expected the new row directly below the header, got %v", rows)
+	}
+}
+
+func TestMockClientNeedsNoSpreadsheetID(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "")
+	t.Setenv("SPREADSHEET_RANGE", "Mock Sheet!A1")
+
+	client, err := NewMockClient("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateSheet(context.Background(), client, [][]interface{}{{"Needed"}}, nil, 1, nil); err != nil {
+		t.Fatalf("UpdateSheet failed: %v", err)
+	}
+	if data, err := ReadExistingSheetData(context.Background(), client); err != nil || len(data) != 1 {
+		t.Errorf("Expected the written row back, got %v (err %v)", data, err)
+	}
+}
+
+func TestReadCacheInvalidatedByWrites(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "mock")
+	t.Setenv("SPREADSHEET_RANGE", "Mock Sheet!A1")
+	ctx := context.Background()
+
+	client := &Client{memory: &memorySheet{rows: [][]interface{}{{"Status"}}}}
+	client.EnableReadCache(true)
+	if _, err := ReadExistingSheetData(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+
+	// A change made outside the client, like a manual edit, isn't seen until the cache resets
+	client.memory.rows = append(client.memory.rows, []interface{}{"Needed"})
+	if data, _ := ReadExistingSheetData(ctx, client); len(data) != 1 {
+		t.Errorf("Expected the cached read with 1 row, got %d", len(data))
+	}
+	client.ResetReadCache()
+	if data, _ := ReadExistingSheetData(ctx, client); len(data) != 2 {
+		t.Errorf("Expected a fresh read with 2 rows after reset, got %d", len(data))
+	}
+
+	if err := client.AppendRows(ctx, "mock", "Mock Sheet!A1", [][]interface{}{{"Needed"}}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := ReadExistingSheetData(ctx, client); len(data) != 3 {
+		t.Errorf("Expected the appended row to be read after a write, got %d rows", len(data))
+	}
+}
+
+func TestReadOnlyClientSkipsWrites(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "mock")
+	t.Setenv("SPREADSHEET_RANGE", "Mock Sheet!A1")
+	ctx := context.Background()
+
+	client := &Client{memory: &memorySheet{rows: [][]interface{}{{"Status"}, {"Needed"}}}}
+	client.SetReadOnly(true)
+
+	if err := UpdateSheet(ctx, client, [][]interface{}{{"Needed", "", "url"}}, nil, 1, nil); err != nil {
+		t.Fatalf("Expected a skipped write to report success, got %v", err)
+	}
+	if updated := UpdateRowStatuses(ctx, client, []int{2}, "Provided"); updated != 1 {
+		t.Errorf("Expected the skipped status write to count as updated, got %d", updated)
+	}
+
+	data, err := ReadExistingSheetData(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 2 || data[1][0] != "Needed" {
+		t.Errorf("Expected the sheet to be untouched, got %v", data)
+	}
+}
@@ -0,0 +1,98 @@
+package sheets
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// editTracker remembers the spreadsheet's last-modified time as of our last read or write,
+// so an edit made by hand in between can be detected before we write over it
+type editTracker struct {
+	mu       sync.Mutex
+	enabled  bool
+	baseline time.Time
+}
+
+// EnableEditCheck turns on last-modified tracking, set by SHEET_EDIT_CHECK. The real
+// client reads the time through the Drive API, which must be enabled for the project.
+func (c *Client) EnableEditCheck(enabled bool) {
+	c.edits.mu.Lock()
+	c.edits.enabled = enabled
+	c.edits.baseline = time.Time{}
+	c.edits.mu.Unlock()
+}
+
+func (c *Client) editCheckEnabled() bool {
+	c.edits.mu.Lock()
+	defer c.edits.mu.Unlock()
+	return c.edits.enabled
+}
+
+// ModifiedTime returns when the spreadsheet was last modified, by anyone
+func (c *Client) ModifiedTime(ctx context.Context, spreadsheetID string) (time.Time, error) {
+	if c.memory != nil {
+		return c.memory.modifiedTime(), nil
+	}
+	if c.drive == nil {
+		return time.Time{}, fmt.Errorf("drive service not configured")
+	}
+
+	file, err := c.drive.Files.Get(spreadsheetID).Fields("modifiedTime").SupportsAllDrives(true).Context(ctx).Do()
+	if err != nil {
+		return time.Time{}, classifyError("failed to get spreadsheet metadata", err)
+	}
+	modified, err := time.Parse(time.RFC3339Nano, file.ModifiedTime)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse modified time %q: %w", file.ModifiedTime, err)
+	}
+	return modified, nil
+}
+
+// markSynced records the current last-modified time as the state we know about
+func (c *Client) markSynced(ctx context.Context, spreadsheetID string) {
+	if !c.editCheckEnabled() {
+		return
+	}
+	modified, err := c.ModifiedTime(ctx, spreadsheetID)
+	if err != nil {
+		slog.Warn("Failed to read spreadsheet modified time", "error", err)
+		return
+	}
+	c.edits.mu.Lock()
+	c.edits.baseline = modified
+	c.edits.mu.Unlock()
+}
+
+// editedSinceSync reports whether the spreadsheet changed after our last read or write.
+// Errors and a missing baseline count as unchanged so a metadata outage never blocks writes.
+func (c *Client) editedSinceSync(ctx context.Context, spreadsheetID string) bool {
+	if !c.editCheckEnabled() {
+		return false
+	}
+	c.edits.mu.Lock()
+	baseline := c.edits.baseline
+	c.edits.mu.Unlock()
+	if baseline.IsZero() {
+		return false
+	}
+
+	modified, err := c.ModifiedTime(ctx, spreadsheetID)
+	if err != nil {
+		slog.Warn("Failed to read spreadsheet modified time", "error", err)
+		return false
+	}
+	if modified.After(baseline) {
+		slog.Debug("Spreadsheet modified since last sync", "baseline", baseline, "modified", modified)
+		return true
+	}
+	return false
+}
+
+// EditedSinceRead reports whether the sheet was edited by someone else since this loop
+// read it or last wrote to it. Always false unless SHEET_EDIT_CHECK is on.
+func EditedSinceRead(ctx context.Context, sheetsClient *Client) bool {
+	return sheetsClient.editedSinceSync(ctx, sheetsClient.spreadsheetID())
+}
@@ -4,40 +4,76 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
+	"time"
 
+	"torn_oc_items/internal/clock"
 	"torn_oc_items/internal/notifications"
+	"torn_oc_items/internal/resolution"
+	"torn_oc_items/internal/retry"
 )
 
+// DateTimeLayout is the timestamp format written to the sheet's datetime columns
+const DateTimeLayout = "15:04:05 - 02/01/06"
+
 // SheetItem represents a parsed item from the spreadsheet
 type SheetItem struct {
 	RowIndex    int
+	Status      string
 	CrimeURL    string
 	ItemName    string
 	UserName    string
 	Provider    string
 	HasProvider bool
+	AddedAt     time.Time // Column I, zero when the row predates the column or was cleared
+	// IDs from fallback names such as "Item ID: X" written when resolution failed;
+	// zero when the column holds a real name
+	ItemIDFallback int
+	UserIDFallback int
 }
 
-// ReadExistingSheetData reads all existing data from the spreadsheet
+// ReadExistingSheetData reads all existing data from the spreadsheet, reusing this loop's
+// earlier read when the read cache is enabled and nothing has been written since
 func ReadExistingSheetData(ctx context.Context, sheetsClient *Client) ([][]interface{}, error) {
 	slog.Debug("Reading existing sheet data")
-	spreadsheetID := getRequiredEnv("SPREADSHEET_ID")
-	sheetRange := getEnvWithDefault("SPREADSHEET_RANGE", "Test Sheet!A1")
-	readRange := strings.Split(sheetRange, "!")[0] + "!A1:Z1000"
+	spreadsheetID := sheetsClient.spreadsheetID()
+	readRange := sheetsClient.sheetName() + "!A1:Z1000"
+	if existingData, ok := sheetsClient.cachedRead(readRange); ok {
+		return existingData, nil
+	}
 	existingData, err := sheetsClient.ReadSheet(ctx, spreadsheetID, readRange)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read existing sheet data: %w", err)
 	}
+	sheetsClient.markSynced(ctx, spreadsheetID)
+	sheetsClient.storeRead(readRange, existingData)
 	slog.Debug("Retrieved existing sheet data", "rows", len(existingData))
 	return existingData, nil
 }
 
-// BuildExistingMap creates a map of existing items for duplicate detection
+// ReadExistingSheetDataWithRetry reads the sheet like ReadExistingSheetData, retrying
+// transient failures per cfg. Permission and not-found errors are returned at once.
+func ReadExistingSheetDataWithRetry(ctx context.Context, sheetsClient *Client, cfg retry.Config) ([][]interface{}, error) {
+	return retry.WithRetry(ctx, cfg, func(ctx context.Context) ([][]interface{}, error) {
+		return ReadExistingSheetData(ctx, sheetsClient)
+	})
+}
+
+// BuildExistingMap creates a map of existing items for duplicate detection. With a
+// DEDUPE_WINDOW, fulfilled rows added longer ago than the window no longer count, so an
+// item needed again in a later crime cycle is re-added.
 func BuildExistingMap(existingData [][]interface{}) map[string]bool {
 	slog.Debug("Building existing items map")
 	existing := make(map[string]bool)
+	window := DedupeWindow()
+	now := clock.Now()
+	expired := 0
 	for _, row := range existingData {
+		if window > 0 && rowExpired(row, now, window) {
+			expired++
+			continue
+		}
 		if len(row) >= 6 {
 			crimeURL := ""
 			userName := ""
@@ -52,15 +88,25 @@ func BuildExistingMap(existingData [][]interface{}) map[string]bool {
 				userName = fmt.Sprintf("%v", row[5])
 			}
 			if crimeURL != "" && userName != "" && itemName != "" {
-				key := fmt.Sprintf("%s|%s|%s", crimeURL, userName, itemName)
-				existing[key] = true
+				existing[ExistingKey(crimeURL, userName, itemName)] = true
 			}
 		}
 	}
-	slog.Debug("Built existing items map", "entries", len(existing))
+	slog.Debug("Built existing items map", "entries", len(existing), "expired_rows", expired)
 	return existing
 }
 
+// rowExpired reports whether a row no longer blocks a fresh need: it has been fulfilled and
+// its added time (column I) is older than window. Open rows and rows without an added
+// time always count as duplicates.
+func rowExpired(row []interface{}, now time.Time, window time.Duration) bool {
+	if strings.TrimSpace(extractStringField(row, 0)) == "Needed" {
+		return false
+	}
+	addedAt, ok := ParseSheetDateTime(extractStringField(row, 8))
+	return ok && now.Sub(addedAt) > window
+}
+
 // ParseSheetItems parses raw sheet data into structured SheetItem objects
 func ParseSheetItems(existingData [][]interface{}) []SheetItem {
 	slog.Debug("Parsing sheet items", "rows", len(existingData))
@@ -98,18 +144,88 @@ func extractSheetItemFromRow(row []interface{}, rowIndex int) SheetItem {
 		hasProvider = provider != ""
 	}
 
+	status := strings.TrimSpace(extractStringField(row, 0))
 	crimeURL := extractStringField(row, 2)
 	itemName := extractStringField(row, 4)
 	userName := extractStringField(row, 5)
+	addedAt, _ := ParseSheetDateTime(extractStringField(row, 8))
 
 	return SheetItem{
-		RowIndex:    rowIndex,
-		CrimeURL:    crimeURL,
-		ItemName:    itemName,
-		UserName:    userName,
-		Provider:    provider,
-		HasProvider: hasProvider,
+		RowIndex:       rowIndex,
+		Status:         status,
+		CrimeURL:       crimeURL,
+		ItemName:       itemName,
+		UserName:       userName,
+		Provider:       provider,
+		HasProvider:    hasProvider,
+		AddedAt:        addedAt,
+		ItemIDFallback: resolution.ParseItemFallback(itemName),
+		UserIDFallback: resolution.ParseUserFallback(userName),
+	}
+}
+
+// ParseSheetDateTime parses a timestamp written with DateTimeLayout in clock.Location,
+// the zone it was written in
+func ParseSheetDateTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(DateTimeLayout, value, clock.Location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// ExistingKey is the duplicate-detection key for a row. It uses the crime ID rather than
+// the full URL so rows still match after the crime URL form changes, e.g. when FACTION_ID
+// is set on a sheet that already has rows. Names are trimmed, since a cell edited by hand
+// can pick up stray spaces that would otherwise let a provided item be added again.
+func ExistingKey(crimeURL, userName, itemName string) string {
+	userName = strings.TrimSpace(userName)
+	itemName = strings.TrimSpace(itemName)
+	if crimeID, ok := ParseCrimeID(crimeURL); ok {
+		return fmt.Sprintf("%d|%s|%s", crimeID, userName, itemName)
 	}
+	return fmt.Sprintf("%s|%s|%s", crimeURL, userName, itemName)
+}
+
+// Columns the provided-value formula (column H) reads. There is no configurable column
+// layout yet; a custom one would pass its own letters to ProvidedValueFormula.
+const (
+	StatusColumn      = "A"
+	MarketValueColumn = "G"
+)
+
+// ProvidedValueFormula builds the formula written to each new row that counts the row's
+// market value once it has been provided or paid in cash, and 0 before then
+func ProvidedValueFormula(statusColumn, valueColumn string) string {
+	status := fmt.Sprintf("INDIRECT(%q&ROW())", statusColumn)
+	return fmt.Sprintf("=IF(OR(%s=\"Provided\",%s=\"Cash Sent\"), INDIRECT(%q&ROW()), 0)", status, status, valueColumn)
+}
+
+// CrimeURL builds the link to a crime. With a faction ID the link points at that
+// faction's crimes page so it resolves for people outside the faction; otherwise it uses
+// the "your faction" form, which only works for the faction's own members.
+func CrimeURL(factionID, crimeID int) string {
+	if factionID > 0 {
+		return fmt.Sprintf("http://www.torn.com/factions.php?step=profile&ID=%d#/tab=crimes&crimeId=%d", factionID, crimeID)
+	}
+	return fmt.Sprintf("http://www.torn.com/factions.php?step=your#/tab=crimes&crimeId=%d", crimeID)
+}
+
+// ParseCrimeID extracts the crime ID from a crime URL ending in "crimeId=<id>"
+func ParseCrimeID(crimeURL string) (int, bool) {
+	idx := strings.LastIndex(crimeURL, "crimeId=")
+	if idx == -1 {
+		return 0, false
+	}
+	crimeID, err := strconv.Atoi(crimeURL[idx+len("crimeId="):])
+	if err != nil {
+		return 0, false
+	}
+	return crimeID, true
 }
 
 // extractStringField safely extracts a string field from a row at the given index
@@ -134,8 +250,8 @@ func validateSheetItem(item SheetItem, rowNum int) bool {
 	return false
 }
 
-// UpdateSheet appends new rows to the spreadsheet and sends notifications
-func UpdateSheet(ctx context.Context, sheetsClient *Client, rows [][]interface{}, totalItems int, notificationClient *notifications.Client) error {
+// UpdateSheet appends new rows to the spreadsheet and sends notifications for the given items
+func UpdateSheet(ctx context.Context, sheetsClient *Client, rows [][]interface{}, items []notifications.ItemInfo, totalItems int, notificationClient *notifications.Client) error {
 	slog.Debug("Updating sheet", "rows", len(rows), "total_items", totalItems)
 
 	if len(rows) == 0 {
@@ -143,49 +259,95 @@ func UpdateSheet(ctx context.Context, sheetsClient *Client, rows [][]interface{}
 		return nil
 	}
 
-	spreadsheetID := getRequiredEnv("SPREADSHEET_ID")
-	sheetRange := getEnvWithDefault("SPREADSHEET_RANGE", "Test Sheet!A1")
+	spreadsheetID := sheetsClient.spreadsheetID()
+	sheetRange := sheetsClient.sheetRange()
+	// Our own write moves the last-modified time on, so the baseline follows it unless
+	// someone else had already edited the sheet
+	editedBefore := sheetsClient.editedSinceSync(ctx, spreadsheetID)
 
-	if err := sheetsClient.AppendRows(ctx, spreadsheetID, sheetRange, rows); err != nil {
+	// Rows are re-read by every later phase, so inserting at the top never leaves stale
+	// row indexes behind
+	if InsertAtTop() {
+		sheetName := strings.Split(sheetRange, "!")[0]
+		if err := sheetsClient.InsertRows(ctx, spreadsheetID, sheetName, HeaderRows, rows); err != nil {
+			return fmt.Errorf("failed to insert rows into sheet: %w", err)
+		}
+	} else if err := sheetsClient.AppendRows(ctx, spreadsheetID, sheetRange, rows); err != nil {
 		return fmt.Errorf("failed to append rows to sheet: %w", err)
 	}
 
+	if !editedBefore {
+		sheetsClient.markSynced(ctx, spreadsheetID)
+	}
+
 	skipped := totalItems - len(rows)
 	slog.Info("Sheet update complete", "added", len(rows), "skipped", skipped)
 
-	if notificationClient != nil && len(rows) > 0 {
-		items := extractNotificationItems(rows)
-		notificationClient.NotifyNewItems(ctx, items, len(rows))
+	if notificationClient != nil && len(items) > 0 {
+		notificationClient.NotifyNewItems(ctx, items, len(items))
 	}
 
 	return nil
 }
 
-// extractNotificationItems converts sheet rows to notification items
-func extractNotificationItems(rows [][]interface{}) []notifications.ItemInfo {
-	var items []notifications.ItemInfo
-	for _, row := range rows {
-		if len(row) >= 6 {
-			crimeURL := ""
-			itemName := ""
-			userName := ""
-			if row[2] != nil {
-				crimeURL = fmt.Sprintf("%v", row[2])
-			}
-			if row[4] != nil {
-				itemName = fmt.Sprintf("%v", row[4])
-			}
-			if row[5] != nil {
-				userName = fmt.Sprintf("%v", row[5])
-			}
-			if itemName != "" && userName != "" {
-				items = append(items, notifications.ItemInfo{
-					ItemName: itemName,
-					UserName: userName,
-					CrimeURL: crimeURL,
-				})
-			}
-		}
+// MergeWrittenRows returns existingData with rows placed where UpdateSheet wrote them, so
+// later phases see the sheet as it now is without reading it again
+func MergeWrittenRows(existingData, rows [][]interface{}) [][]interface{} {
+	merged := make([][]interface{}, 0, len(existingData)+len(rows))
+	if InsertAtTop() {
+		header := min(HeaderRows, len(existingData))
+		merged = append(merged, existingData[:header]...)
+		merged = append(merged, rows...)
+		return append(merged, existingData[header:]...)
 	}
-	return items
+	merged = append(merged, existingData...)
+	return append(merged, rows...)
+}
+
+// CheckWritable writes a marker to a scratch cell, reads it back and clears it, verifying
+// the service account can both read and write the sheet
+func CheckWritable(ctx context.Context, sheetsClient *Client, cell string) error {
+	spreadsheetID := sheetsClient.spreadsheetID()
+	cellRange := sheetsClient.sheetName() + "!" + cell
+
+	marker := "torn-oc-items self-check " + time.Now().Format(DateTimeLayout)
+	if err := sheetsClient.UpdateRange(ctx, spreadsheetID, cellRange, [][]interface{}{{marker}}); err != nil {
+		return fmt.Errorf("failed to write scratch cell %s: %w", cellRange, err)
+	}
+
+	values, err := sheetsClient.ReadSheet(ctx, spreadsheetID, cellRange)
+	if err != nil {
+		return fmt.Errorf("failed to read scratch cell %s: %w", cellRange, err)
+	}
+	if len(values) == 0 || len(values[0]) == 0 || fmt.Sprintf("%v", values[0][0]) != marker {
+		return fmt.Errorf("scratch cell %s did not read back what was written", cellRange)
+	}
+
+	if err := sheetsClient.UpdateRange(ctx, spreadsheetID, cellRange, [][]interface{}{{""}}); err != nil {
+		return fmt.Errorf("failed to clear scratch cell %s: %w", cellRange, err)
+	}
+	return nil
+}
+
+// FormatSheetColumns renders the datetime column (D) as a date and the market value column (G)
+// as currency so values written by the tool display nicely
+func FormatSheetColumns(ctx context.Context, sheetsClient *Client) error {
+	spreadsheetID := sheetsClient.spreadsheetID()
+	sheetName := sheetsClient.sheetName()
+
+	sheetID, err := sheetsClient.GetSheetID(ctx, spreadsheetID, sheetName)
+	if err != nil {
+		return fmt.Errorf("failed to look up sheet %q: %w", sheetName, err)
+	}
+
+	formats := []ColumnFormat{
+		{Column: 3, Type: "DATE_TIME", Pattern: "hh:mm:ss - dd/mm/yy"},
+		{Column: 6, Type: "CURRENCY", Pattern: "$#,##0"},
+	}
+	if err := sheetsClient.FormatColumns(ctx, spreadsheetID, sheetID, formats); err != nil {
+		return err
+	}
+
+	slog.Info("Formatted sheet columns", "sheet", sheetName, "columns", len(formats))
+	return nil
 }
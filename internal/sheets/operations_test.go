@@ -0,0 +1,262 @@
+package sheets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+
+	"torn_oc_items/internal/clock"
+	"torn_oc_items/internal/retry"
+)
+
+func TestParseSheetItemsReadsAddedAt(t *testing.T) {
+	added := time.Date(2025, 3, 14, 15, 9, 26, 0, time.Local)
+	rows := [][]interface{}{
+		{"Needed", "", testCrimeURL + "100", "", "Xanax", "Alice", "", "", added.Format(DateTimeLayout)},
+		{"Needed", "", testCrimeURL + "100", "", "Vicodin", "Bob"},
+	}
+
+	items := ParseSheetItems(rows)
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if !items[0].AddedAt.Equal(added) {
+		t.Errorf("Expected added time %v, got %v", added, items[0].AddedAt)
+	}
+	if !items[1].AddedAt.IsZero() {
+		t.Errorf("Expected zero added time for row without column I, got %v", items[1].AddedAt)
+	}
+}
+
+func TestParseSheetDateTimeUsesFixedNowZone(t *testing.T) {
+	// A zone other than the host's, as a FIXED_NOW with an offset gives
+	zone := time.FixedZone("UTC+5:30", 5*3600+1800)
+	if _, offset := time.Now().In(time.Local).Zone(); offset == 5*3600+1800 {
+		zone = time.FixedZone("UTC-7", -7*3600)
+	}
+	fixed := time.Date(2025, 3, 14, 15, 9, 26, 0, zone)
+	clock.SetFixed(fixed)
+	defer clock.SetFixed(time.Time{})
+
+	written := clock.Now().Format(DateTimeLayout)
+	parsed, ok := ParseSheetDateTime(written)
+	if !ok || !parsed.Equal(fixed) {
+		t.Errorf("Expected %q to read back as %v, got %v (ok=%v)", written, fixed, parsed, ok)
+	}
+}
+
+func TestCrimeURLWithFactionID(t *testing.T) {
+	if got := CrimeURL(0, 42); got != testCrimeURL+"42" {
+		t.Errorf("Expected the your-faction link, got %q", got)
+	}
+
+	got := CrimeURL(9001, 42)
+	if got != "http://www.torn.com/factions.php?step=profile&ID=9001#/tab=crimes&crimeId=42" {
+		t.Errorf("Unexpected faction link %q", got)
+	}
+	if crimeID, ok := ParseCrimeID(got); !ok || crimeID != 42 {
+		t.Errorf("Expected crime ID 42 from faction link, got %d, %v", crimeID, ok)
+	}
+}
+
+func TestBuildExistingMapMatchesAcrossURLForms(t *testing.T) {
+	existing := BuildExistingMap([][]interface{}{
+		{"Needed", "", testCrimeURL + "42", "", "Xanax", "Alice"},
+	})
+
+	if !existing[ExistingKey(CrimeURL(9001, 42), "Alice", "Xanax")] {
+		t.Error("Expected a row with the your-faction link to match the faction link for the same crime")
+	}
+	if existing[ExistingKey(CrimeURL(9001, 43), "Alice", "Xanax")] {
+		t.Error("Expected a different crime not to match")
+	}
+}
+
+func TestBuildExistingMapIncludesFulfilledRows(t *testing.T) {
+	existing := BuildExistingMap([][]interface{}{
+		{"Provided", "Carol", testCrimeURL + "42", "12:00:00 - 16/10/26", "Xanax", "Alice", 830000.0},
+		{"Cash Sent", "Carol", testCrimeURL + "43", "", "Jemmy ", " Bob"},
+	})
+
+	if !existing[ExistingKey(testCrimeURL+"42", "Alice", "Xanax")] {
+		t.Error("Expected a provided row to suppress the same need")
+	}
+	if !existing[ExistingKey(testCrimeURL+"43", "Bob", "Jemmy")] {
+		t.Error("Expected names with stray spaces to match")
+	}
+}
+
+func TestProvidedValueFormula(t *testing.T) {
+	want := `=IF(OR(INDIRECT("A"&ROW())="Provided",INDIRECT("A"&ROW())="Cash Sent"), INDIRECT("G"&ROW()), 0)`
+	if got := ProvidedValueFormula(StatusColumn, MarketValueColumn); got != want {
+		t.Errorf("Expected the default layout formula %s, got %s", want, got)
+	}
+
+	want = `=IF(OR(INDIRECT("C"&ROW())="Provided",INDIRECT("C"&ROW())="Cash Sent"), INDIRECT("K"&ROW()), 0)`
+	if got := ProvidedValueFormula("C", "K"); got != want {
+		t.Errorf("Expected %s for a custom layout, got %s", want, got)
+	}
+}
+
+func TestParseSheetItemsReadsFallbackIDs(t *testing.T) {
+	items := ParseSheetItems([][]interface{}{
+		{"Needed", "", testCrimeURL + "100", "", "Item ID: 206", "User ID: 2001"},
+		{"Needed", "", testCrimeURL + "100", "", "Xanax", "Alice"},
+	})
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if items[0].ItemIDFallback != 206 || items[0].UserIDFallback != 2001 {
+		t.Errorf("Expected fallback IDs 206 and 2001, got %d and %d", items[0].ItemIDFallback, items[0].UserIDFallback)
+	}
+	if items[1].ItemIDFallback != 0 || items[1].UserIDFallback != 0 {
+		t.Errorf("Expected no fallback IDs for named row, got %d and %d", items[1].ItemIDFallback, items[1].UserIDFallback)
+	}
+}
+
+func TestBuildExistingMapDedupeWindow(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour).Format(DateTimeLayout)
+	recent := time.Now().Add(-time.Hour).Format(DateTimeLayout)
+	rows := [][]interface{}{
+		{"Provided", "Carol", testCrimeURL + "1", "", "Xanax", "Alice", "", "", old},
+		{"Needed", "", testCrimeURL + "2", "", "Xanax", "Alice", "", "", old},
+		{"Provided", "Carol", testCrimeURL + "3", "", "Xanax", "Alice", "", "", recent},
+		{"Provided", "Carol", testCrimeURL + "4", "", "Xanax", "Alice"},
+	}
+
+	existing := BuildExistingMap(rows)
+	if len(existing) != 4 {
+		t.Errorf("Expected every row to count without a window, got %d", len(existing))
+	}
+
+	t.Setenv("DEDUPE_WINDOW", "24h")
+	existing = BuildExistingMap(rows)
+	if existing["1|Alice|Xanax"] {
+		t.Error("Expected the old fulfilled row to expire")
+	}
+	for _, key := range []string{"2|Alice|Xanax", "3|Alice|Xanax", "4|Alice|Xanax"} {
+		if !existing[key] {
+			t.Errorf("Expected %s to still count as a duplicate", key)
+		}
+	}
+}
+
+func TestMergeWrittenRows(t *testing.T) {
+	existing := [][]interface{}{{"Status"}, {"old"}}
+	rows := [][]interface{}{{"new"}}
+
+	merged := MergeWrittenRows(existing, rows)
+	if len(merged) != 3 || merged[2][0] != "new" {
+		t.Errorf("Expected the new row appended at the bottom, got %v", merged)
+	}
+
+	t.Setenv("SHEET_INSERT", "top")
+	merged = MergeWrittenRows(existing, rows)
+	if len(merged) != 3 || merged[1][0] != "new" || merged[2][0] != "old" {
+		t.Errorf("Expected the new row below the header, got %v", merged)
+	}
+	if existing[1][0] != "old" || len(existing) != 2 {
+		t.Errorf("Expected the existing data to be left unchanged, got %v", existing)
+	}
+}
+
+// newServerClient returns a Client whose Sheets API requests go to a test server that
+// answers with the given status codes in turn, then with a single row
+func newServerClient(t *testing.T, statuses ...int) (*Client, *int) {
+	t.Helper()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= len(statuses) {
+			w.WriteHeader(statuses[requests-1])
+			return
+		}
+		_, _ = w.Write([]byte(`{"range":"Sheet!A1:Z1000","values":[["Needed"]]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	service, err := sheets.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create sheets service: %v", err)
+	}
+	return &Client{service: service}, &requests
+}
+
+func TestReadExistingSheetDataWithRetry(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "test")
+	t.Setenv("SPREADSHEET_RANGE", "Sheet!A1")
+	ctx := context.Background()
+	cfg := retry.Config{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Timeout: time.Second}
+
+	client, requests := newServerClient(t, http.StatusServiceUnavailable)
+	data, err := ReadExistingSheetDataWithRetry(ctx, client, cfg)
+	if err != nil {
+		t.Fatalf("Expected the transient failure to be retried, got %v", err)
+	}
+	if len(data) != 1 || *requests != 2 {
+		t.Errorf("Expected 1 row after 2 requests, got %d rows after %d", len(data), *requests)
+	}
+
+	client, requests = newServerClient(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	if _, err := ReadExistingSheetDataWithRetry(ctx, client, cfg); !errors.Is(err, ErrTransient) {
+		t.Errorf("Expected a transient error once retries ran out, got %v", err)
+	}
+	if *requests != 3 {
+		t.Errorf("Expected 3 attempts, got %d", *requests)
+	}
+
+	client, requests = newServerClient(t, http.StatusForbidden)
+	if _, err := ReadExistingSheetDataWithRetry(ctx, client, cfg); !errors.Is(err, ErrPermission) {
+		t.Errorf("Expected a permission error, got %v", err)
+	}
+	if *requests != 1 {
+		t.Errorf("Expected a permission error not to be retried, got %d attempts", *requests)
+	}
+}
+
+func TestResolveSheetGIDUsesCurrentTitle(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "test")
+	t.Setenv("SPREADSHEET_RANGE", "Old Name!A1")
+	t.Setenv("SPREADSHEET_GID", "123")
+
+	var readPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v4/spreadsheets/test" {
+			_, _ = w.Write([]byte(`{"sheets":[{"properties":{"sheetId":0,"title":"Old Name"}},{"properties":{"sheetId":123,"title":"Renamed"}}]}`))
+			return
+		}
+		readPath = r.URL.Path
+		_, _ = w.Write([]byte(`{"values":[["Needed"]]}`))
+	}))
+	defer server.Close()
+
+	service, err := sheets.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("Failed to create sheets service: %v", err)
+	}
+	client := &Client{service: service}
+
+	if err := ResolveSheetGID(context.Background(), client); err != nil {
+		t.Fatalf("Expected the GID to resolve, got %v", err)
+	}
+	if got := client.sheetRange(); got != "Renamed!A1" {
+		t.Errorf("Expected the range on the renamed sheet, got %q", got)
+	}
+	if _, err := ReadExistingSheetData(context.Background(), client); err != nil {
+		t.Fatalf("Expected the read to succeed, got %v", err)
+	}
+	if readPath != "/v4/spreadsheets/test/values/Renamed!A1:Z1000" {
+		t.Errorf("Expected the read to address the renamed sheet, got %q", readPath)
+	}
+
+	t.Setenv("SPREADSHEET_GID", "456")
+	if err := ResolveSheetGID(context.Background(), client); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected an unknown GID to be reported as not found, got %v", err)
+	}
+}
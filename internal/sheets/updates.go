@@ -4,7 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"strings"
+
+	"torn_oc_items/internal/notifications"
 )
 
 // SheetRowUpdate represents an update to be made to a sheet row
@@ -13,16 +14,29 @@ type SheetRowUpdate struct {
 	Provider    string
 	DateTime    string
 	MarketValue float64
+	// MarketValueUnknown leaves column G blank because the lookup failed
+	MarketValueUnknown bool
+}
+
+// marketValueCell returns what column G gets for the update
+func (u SheetRowUpdate) marketValueCell() interface{} {
+	if u.MarketValueUnknown {
+		return ""
+	}
+	return u.MarketValue
 }
 
 // UpdateProvidedItemRows updates multiple rows in the sheet with provider information
-func UpdateProvidedItemRows(ctx context.Context, sheetsClient *Client, updates []SheetRowUpdate) {
+// and notifies for any crime whose last needed item was provided by these updates. It
+// returns the updates that were written; a row whose write failed is left for next loop.
+func UpdateProvidedItemRows(ctx context.Context, sheetsClient *Client, sheetItems []SheetItem, updates []SheetRowUpdate, notificationClient *notifications.Client) []SheetRowUpdate {
 	slog.Debug("Updating provided item rows", "updates", len(updates))
 
-	spreadsheetID := getRequiredEnv("SPREADSHEET_ID")
-	sheetRange := getEnvWithDefault("SPREADSHEET_RANGE", "Test Sheet!A1")
-	sheetName := strings.Split(sheetRange, "!")[0]
+	spreadsheetID := sheetsClient.spreadsheetID()
+	sheetName := sheetsClient.sheetName()
 
+	var providedRows []int
+	var provided []SheetRowUpdate
 	for _, update := range updates {
 		slog.Debug("Updating row",
 			"row", update.RowIndex,
@@ -32,6 +46,8 @@ func UpdateProvidedItemRows(ctx context.Context, sheetsClient *Client, updates [
 		)
 
 		if updateAllSheetCells(ctx, sheetsClient, spreadsheetID, sheetName, update) {
+			providedRows = append(providedRows, update.RowIndex)
+			provided = append(provided, update)
 			slog.Info("Updated provided item row",
 				"row", update.RowIndex,
 				"provider", update.Provider,
@@ -41,19 +57,170 @@ func UpdateProvidedItemRows(ctx context.Context, sheetsClient *Client, updates [
 		}
 	}
 
-	slog.Debug("Finished updating provided item rows", "updates", len(updates))
+	if auditRange := AuditSheetRange(); auditRange != "" && len(provided) > 0 {
+		appendAuditRecords(ctx, sheetsClient, spreadsheetID, auditRange, sheetItems, provided)
+	}
+
+	if notificationClient != nil {
+		for _, crime := range FindCompletedCrimes(sheetItems, providedRows) {
+			notificationClient.NotifyCrimeFullySupplied(ctx, crime.CrimeID, crime.CrimeURL, crime.ItemCount)
+		}
+	}
+
+	slog.Debug("Finished updating provided item rows", "updates", len(updates), "written", len(provided))
+	return provided
+}
+
+// appendAuditRecords appends one record per provided match to the audit tab, so the
+// history survives later edits to the main sheet. A failed append is logged and otherwise
+// ignored; the main rows are already updated.
+func appendAuditRecords(ctx context.Context, sheetsClient *Client, spreadsheetID, auditRange string, sheetItems []SheetItem, updates []SheetRowUpdate) {
+	byRow := make(map[int]SheetItem, len(sheetItems))
+	for _, item := range sheetItems {
+		byRow[item.RowIndex] = item
+	}
+
+	records := make([][]interface{}, 0, len(updates))
+	for _, update := range updates {
+		item := byRow[update.RowIndex]
+		records = append(records, []interface{}{
+			update.DateTime,
+			update.Provider,
+			item.CrimeURL,
+			item.ItemName,
+			item.UserName,
+			update.marketValueCell(),
+			update.RowIndex,
+		})
+	}
+
+	if err := sheetsClient.AppendRows(ctx, spreadsheetID, auditRange, records); err != nil {
+		slog.Error("Failed to append provided matches to the audit sheet", "error", err, "records", len(records), "range", auditRange)
+		return
+	}
+	slog.Debug("Appended provided matches to the audit sheet", "records", len(records), "range", auditRange)
+}
+
+// CompletedCrime describes a crime whose needed items have all been provided
+type CompletedCrime struct {
+	CrimeID   int
+	CrimeURL  string
+	ItemCount int
+}
+
+// FindCompletedCrimes returns the crimes that still had needed items before the given
+// rows were marked provided and have none remaining afterwards
+func FindCompletedCrimes(sheetItems []SheetItem, providedRows []int) []CompletedCrime {
+	provided := make(map[int]bool, len(providedRows))
+	for _, row := range providedRows {
+		provided[row] = true
+	}
+
+	type crimeProgress struct {
+		crimeURL  string
+		items     int
+		remaining int
+		flipped   bool
+	}
+	progress := make(map[int]*crimeProgress)
+	var order []int
+
+	for _, item := range sheetItems {
+		crimeID, ok := ParseCrimeID(item.CrimeURL)
+		if !ok {
+			continue
+		}
+		p, exists := progress[crimeID]
+		if !exists {
+			p = &crimeProgress{crimeURL: item.CrimeURL}
+			progress[crimeID] = p
+			order = append(order, crimeID)
+		}
+		p.items++
+		if item.Status != "Needed" {
+			continue
+		}
+		if provided[item.RowIndex] {
+			p.flipped = true
+		} else {
+			p.remaining++
+		}
+	}
+
+	var completed []CompletedCrime
+	for _, crimeID := range order {
+		p := progress[crimeID]
+		if p.flipped && p.remaining == 0 {
+			completed = append(completed, CompletedCrime{
+				CrimeID:   crimeID,
+				CrimeURL:  p.crimeURL,
+				ItemCount: p.items,
+			})
+		}
+	}
+	return completed
+}
+
+// UpdateRowStatuses sets the status column (A) of each row and returns how many were updated
+func UpdateRowStatuses(ctx context.Context, sheetsClient *Client, rowIndexes []int, status string) int {
+	spreadsheetID := sheetsClient.spreadsheetID()
+	sheetName := sheetsClient.sheetName()
+
+	updated := 0
+	for _, rowIndex := range rowIndexes {
+		if updateSheetCell(ctx, sheetsClient, spreadsheetID, sheetName, "A", rowIndex, status, "status") {
+			updated++
+		}
+	}
+	return updated
+}
+
+// NameUpdate replaces the item and user names (columns E and F) of a row
+type NameUpdate struct {
+	RowIndex int
+	ItemName string
+	UserName string
+}
+
+// UpdateRowNames writes each row's item and user names in a single range update and
+// returns how many rows were updated
+func UpdateRowNames(ctx context.Context, sheetsClient *Client, updates []NameUpdate) int {
+	spreadsheetID := sheetsClient.spreadsheetID()
+	sheetName := sheetsClient.sheetName()
+
+	updated := 0
+	for _, update := range updates {
+		cellRange := fmt.Sprintf("%s!E%d:F%d", sheetName, update.RowIndex, update.RowIndex)
+		values := [][]interface{}{{update.ItemName, update.UserName}}
+		if err := sheetsClient.UpdateRange(ctx, spreadsheetID, cellRange, values); err != nil {
+			slog.Error("Failed to update item and user names", "error", err, "row", update.RowIndex)
+			continue
+		}
+		updated++
+	}
+	return updated
 }
 
 // updateAllSheetCells updates all required cells for a provided item row
 func updateAllSheetCells(ctx context.Context, sheetsClient *Client, spreadsheetID, sheetName string, update SheetRowUpdate) bool {
-	// Update status column (A)
-	if !updateSheetCell(ctx, sheetsClient, spreadsheetID, sheetName, "A", update.RowIndex, "Provided", "status") {
-		return false
-	}
+	if CombineRowWrites() {
+		// Update status and provider columns (A:B) in one call
+		cellRange := fmt.Sprintf("%s!A%d:B%d", sheetName, update.RowIndex, update.RowIndex)
+		values := [][]interface{}{{"Provided", update.Provider}}
+		if err := sheetsClient.UpdateRange(ctx, spreadsheetID, cellRange, values); err != nil {
+			slog.Error("Failed to update status and provider columns", "error", err, "row", update.RowIndex)
+			return false
+		}
+	} else {
+		// Update status column (A)
+		if !updateSheetCell(ctx, sheetsClient, spreadsheetID, sheetName, "A", update.RowIndex, "Provided", "status") {
+			return false
+		}
 
-	// Update provider column (B)
-	if !updateSheetCell(ctx, sheetsClient, spreadsheetID, sheetName, "B", update.RowIndex, update.Provider, "provider") {
-		return false
+		// Update provider column (B)
+		if !updateSheetCell(ctx, sheetsClient, spreadsheetID, sheetName, "B", update.RowIndex, update.Provider, "provider") {
+			return false
+		}
 	}
 
 	// Update datetime column (D)
@@ -62,7 +229,10 @@ func updateAllSheetCells(ctx context.Context, sheetsClient *Client, spreadsheetI
 	}
 
 	// Update market value column (G)
-	if !updateSheetCell(ctx, sheetsClient, spreadsheetID, sheetName, "G", update.RowIndex, update.MarketValue, "market value") {
+	if SkipMarketValue() {
+		return true
+	}
+	if !updateSheetCell(ctx, sheetsClient, spreadsheetID, sheetName, "G", update.RowIndex, update.marketValueCell(), "market value") {
 		return false
 	}
 
@@ -0,0 +1,154 @@
+package sheets
+
+import (
+	"context"
+	"testing"
+)
+
+const testCrimeURL = "http://www.torn.com/factions.php?step=your#/tab=crimes&crimeId="
+
+func TestParseCrimeID(t *testing.T) {
+	crimeID, ok := ParseCrimeID(testCrimeURL + "12345")
+	if !ok || crimeID != 12345 {
+		t.Errorf("Expected crime ID 12345, got %d (ok=%v)", crimeID, ok)
+	}
+
+	if _, ok := ParseCrimeID("not a crime url"); ok {
+		t.Error("Expected parse failure for URL without crime ID")
+	}
+}
+
+func TestFindCompletedCrimes(t *testing.T) {
+	sheetItems := []SheetItem{
+		{RowIndex: 2, Status: "Provided", CrimeURL: testCrimeURL + "100"},
+		{RowIndex: 3, Status: "Needed", CrimeURL: testCrimeURL + "100"},
+		{RowIndex: 4, Status: "Needed", CrimeURL: testCrimeURL + "200"},
+		{RowIndex: 5, Status: "Needed", CrimeURL: testCrimeURL + "200"},
+		{RowIndex: 6, Status: "Provided", CrimeURL: testCrimeURL + "300"},
+	}
+
+	completed := FindCompletedCrimes(sheetItems, []int{3, 4})
+	if len(completed) != 1 {
+		t.Fatalf("Expected 1 completed crime, got %d", len(completed))
+	}
+	if completed[0].CrimeID != 100 || completed[0].ItemCount != 2 {
+		t.Errorf("Unexpected completed crime: %+v", completed[0])
+	}
+}
+
+func TestFindCompletedCrimes_NoUpdatesForCrime(t *testing.T) {
+	sheetItems := []SheetItem{
+		{RowIndex: 2, Status: "Provided", CrimeURL: testCrimeURL + "100"},
+	}
+
+	if completed := FindCompletedCrimes(sheetItems, nil); len(completed) != 0 {
+		t.Errorf("Expected no completed crimes for already-supplied crime, got %+v", completed)
+	}
+}
+
+func TestUpdateProvidedItemRowsReturnsWrittenUpdates(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "mock")
+	t.Setenv("SPREADSHEET_RANGE", "Mock Sheet!A1")
+
+	client := &Client{memory: &memorySheet{rows: [][]interface{}{
+		{"Status", "Provider", "Crime", "Time", "Item", "User", "Value"},
+		{"Needed", "", testCrimeURL + "100", "", "Jemmy", "Bob", ""},
+	}}}
+	// Row 0 is not a valid cell, so its write fails
+	updates := []SheetRowUpdate{
+		{RowIndex: 2, Provider: "Carol", DateTime: "12:00:00 - 16/10/26"},
+		{RowIndex: 0, Provider: "Carol", DateTime: "12:00:00 - 16/10/26"},
+	}
+
+	written := UpdateProvidedItemRows(context.Background(), client, nil, updates, nil)
+	if len(written) != 1 || written[0].RowIndex != 2 {
+		t.Errorf("Expected only the row 2 update to be reported written, got %+v", written)
+	}
+}
+
+func TestUpdateProvidedItemRowsAppendsAuditRecords(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "mock")
+	t.Setenv("SPREADSHEET_RANGE", "Mock Sheet!A1")
+	t.Setenv("AUDIT_SHEET_RANGE", "Audit!A1")
+
+	client := &Client{memory: &memorySheet{rows: [][]interface{}{
+		{"Status", "Provider", "Crime", "Time", "Item", "User", "Value"},
+		{"Needed", "", testCrimeURL + "100", "", "Jemmy", "Bob", ""},
+	}}}
+	sheetItems := []SheetItem{{RowIndex: 2, Status: "Needed", CrimeURL: testCrimeURL + "100", ItemName: "Jemmy", UserName: "Bob"}}
+	updates := []SheetRowUpdate{{RowIndex: 2, Provider: "Carol", DateTime: "12:00:00 - 16/10/26", MarketValue: 1750000}}
+
+	UpdateProvidedItemRows(context.Background(), client, sheetItems, updates, nil)
+
+	if got := client.memory.rows[1][1]; got != "Carol" {
+		t.Errorf("Expected the main row to be credited to Carol, got %v", got)
+	}
+	audit, err := client.ReadSheet(context.Background(), "mock", "Audit!A1:G10")
+	if err != nil {
+		t.Fatalf("Failed to read the audit tab: %v", err)
+	}
+	if len(audit) != 1 {
+		t.Fatalf("Expected 1 audit record, got %v", audit)
+	}
+	want := []interface{}{"12:00:00 - 16/10/26", "Carol", testCrimeURL + "100", "Jemmy", "Bob", 1750000.0, 2}
+	for i := range want {
+		if audit[0][i] != want[i] {
+			t.Errorf("Audit column %d: got %v, want %v", i, audit[0][i], want[i])
+		}
+	}
+	if len(client.memory.rows) != 2 {
+		t.Errorf("Expected the main sheet to keep 2 rows, got %d", len(client.memory.rows))
+	}
+}
+
+func TestUpdateProvidedItemRowsLeavesUnknownMarketValueBlank(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "mock")
+	t.Setenv("SPREADSHEET_RANGE", "Mock Sheet!A1")
+
+	client := &Client{memory: &memorySheet{rows: [][]interface{}{
+		{"Status", "Provider", "Crime", "Time", "Item", "User", "Value"},
+		{"Needed", "", testCrimeURL + "100", "", "Jemmy", "Bob", ""},
+	}}}
+	sheetItems := []SheetItem{{RowIndex: 2, Status: "Needed", CrimeURL: testCrimeURL + "100", ItemName: "Jemmy", UserName: "Bob"}}
+	updates := []SheetRowUpdate{{RowIndex: 2, Provider: "Carol", DateTime: "12:00:00 - 16/10/26", MarketValueUnknown: true}}
+
+	UpdateProvidedItemRows(context.Background(), client, sheetItems, updates, nil)
+
+	if got := client.memory.rows[1][0]; got != "Provided" {
+		t.Errorf("Expected the row to be marked provided, got %v", got)
+	}
+	if got := client.memory.rows[1][6]; got != "" {
+		t.Errorf("Expected the market value to be left blank rather than 0, got %#v", got)
+	}
+}
+
+func TestUpdateProvidedItemRowsCombinesStatusAndProvider(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "mock")
+	t.Setenv("SPREADSHEET_RANGE", "Mock Sheet!A1")
+
+	for _, mode := range []string{"cells", "combined"} {
+		t.Run(mode, func(t *testing.T) {
+			t.Setenv("SHEET_ROW_WRITES", mode)
+			client := &Client{memory: &memorySheet{rows: [][]interface{}{
+				{"Status", "Provider", "Crime", "Time", "Item", "User", "Value"},
+				{"Needed", "", testCrimeURL + "100", "", "Jemmy", "Bob", ""},
+			}}}
+			sheetItems := []SheetItem{{RowIndex: 2, Status: "Needed", CrimeURL: testCrimeURL + "100", ItemName: "Jemmy", UserName: "Bob"}}
+			updates := []SheetRowUpdate{{RowIndex: 2, Provider: "Carol", DateTime: "12:00:00 - 16/10/26", MarketValue: 1750000}}
+
+			UpdateProvidedItemRows(context.Background(), client, sheetItems, updates, nil)
+
+			row := client.memory.rows[1]
+			if row[0] != "Provided" || row[1] != "Carol" || row[3] != "12:00:00 - 16/10/26" || row[6] != 1750000.0 {
+				t.Errorf("Unexpected row after update: %v", row)
+			}
+			want := int64(4)
+			if mode == "combined" {
+				want = 3
+			}
+			if client.memory.version != want {
+				t.Errorf("Expected %d write calls, got %d", want, client.memory.version)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package status
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Server is a small HTTP server exposing read-only JSON status endpoints
+type Server struct {
+	mux    *http.ServeMux
+	server *http.Server
+}
+
+func NewServer(addr string) *Server {
+	mux := http.NewServeMux()
+	return &Server{
+		mux: mux,
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}
+}
+
+// HandleJSON registers a GET endpoint that serves the value returned by fn as JSON
+func (s *Server) HandleJSON(path string, fn func() any) {
+	s.mux.HandleFunc("GET "+path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(fn()); err != nil {
+			slog.Warn("Failed to encode status response", "path", path, "error", err)
+		}
+	})
+}
+
+// HandleCheck registers a GET endpoint for probes such as /readyz. It serves the body
+// returned by fn as JSON, with status 200 when ok and 503 otherwise.
+func (s *Server) HandleCheck(path string, fn func() (ok bool, body any)) {
+	s.mux.HandleFunc("GET "+path, func(w http.ResponseWriter, r *http.Request) {
+		ok, body := fn()
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			slog.Warn("Failed to encode status response", "path", path, "error", err)
+		}
+	})
+}
+
+// Start serves requests in the background until the process exits
+func (s *Server) Start() {
+	go func() {
+		slog.Info("Status server listening", "addr", s.server.Addr)
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("Status server stopped", "error", err)
+		}
+	}()
+}
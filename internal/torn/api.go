@@ -0,0 +1,29 @@
+package torn
+
+import "context"
+
+// TornAPI is the Torn API surface used by processing, so the HTTP client can be swapped
+// for fixtures in MOCK_MODE
+type TornAPI interface {
+	GetItem(ctx context.Context, itemID string) (*Item, error)
+	GetUser(ctx context.Context, userID string) (*UserInfo, error)
+	GetUserStatus(ctx context.Context, userID string) (*UserStatus, error)
+	GetFactionCrimes(ctx context.Context, category string, offset int) (*CrimesResponse, error)
+	GetSuppliedItems(ctx context.Context) ([]SuppliedItem, error)
+	UnassignedNeeds() []UnassignedNeed
+	GetPlanningCrimes(ctx context.Context) (*CrimesResponse, error)
+	GetCompletedCrimes(ctx context.Context) (*CrimesResponse, error)
+	GetItemSendLogs(ctx context.Context) (*LogResponse, error)
+	GetArmoryDepositLogs(ctx context.Context, logType int) (*LogResponse, error)
+	WhoAmI(ctx context.Context) (string, error)
+	CrimeCategories() []string
+
+	GetAPICallCount() int64
+	ResetAPICallCount()
+	BudgetExhausted() bool
+}
+
+var (
+	_ TornAPI = (*Client)(nil)
+	_ TornAPI = (*MockClient)(nil)
+)
@@ -0,0 +1,44 @@
+package torn
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultItemCacheTTL is how long item details are cached when no TTL is configured
+const DefaultItemCacheTTL = time.Hour
+
+// Catalog is a concurrency-safe item cache that can be shared by every Client so an
+// item resolved by one client serves all of them
+type Catalog struct {
+	items sync.Map
+	ttl   time.Duration
+}
+
+func NewCatalog(ttl time.Duration) *Catalog {
+	if ttl <= 0 {
+		ttl = DefaultItemCacheTTL
+	}
+	return &Catalog{ttl: ttl}
+}
+
+// Get returns a cached item if present and not older than the TTL
+func (c *Catalog) Get(itemID string) (*Item, bool) {
+	cached, ok := c.items.Load(itemID)
+	if !ok {
+		return nil, false
+	}
+	entry := cached.(cachedItem)
+	if time.Since(entry.timestamp) >= c.ttl {
+		return nil, false
+	}
+	return entry.item, true
+}
+
+// Store caches an item under its ID
+func (c *Catalog) Store(itemID string, item *Item) {
+	c.items.Store(itemID, cachedItem{
+		item:      item,
+		timestamp: time.Now(),
+	})
+}
@@ -1,28 +1,97 @@
 package torn
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
+	appclock "torn_oc_items/internal/clock"
 	"torn_oc_items/internal/config"
+	"torn_oc_items/internal/log"
 	"torn_oc_items/internal/retry"
 
 	"log/slog"
 )
 
+const defaultBaseURL = "https://api.torn.com"
+
+// DefaultCrimeCategories are the faction crime categories scanned for supplied items
+var DefaultCrimeCategories = []string{"planning"}
+
+// maxDrainBytes bounds how much of an unread response body is discarded before closing
+const maxDrainBytes = 64 << 10
+
 type Client struct {
-	apiKey        string
-	factionApiKey string
-	client        *http.Client
-	itemCache     sync.Map
-	userCache     sync.Map
-	apiCallCount  int64
-	apiCallMutex  sync.Mutex
+	apiKey            string
+	factionApiKey     string
+	client            *http.Client
+	catalog           *Catalog
+	userCache         sync.Map
+	statusCache       sync.Map // user statuses, kept for statusTTL rather than the name's hour
+	statusTTL         time.Duration
+	apiCallCount      int64
+	apiCallMutex      sync.Mutex
+	retryableStatuses map[int]bool
+	baseURL           string
+	retryConfig       retry.Config
+	itemAllowlist     map[int]bool
+	itemBlocklist     map[int]bool
+	userAgent         string
+	crimeCategories   []string
+	apiCallBudget     int64
+	trackUnassigned   bool
+	unassigned        []UnassignedNeed // from the last GetSuppliedItems
+}
+
+// APIError is returned when the Torn API responds with a non-200 status
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// Torn API error codes reported when a key can't read the requested selection
+const (
+	tornErrorIncorrectKey      = 2
+	tornErrorIncorrectRelation = 7
+	tornErrorKeyInactive       = 13
+	tornErrorAccessLevel       = 16
+	tornErrorKeyPaused         = 18
+)
+
+// TornError is the error envelope the Torn API returns with a 200 status, e.g.
+// {"error":{"code":16,"error":"Access level of this key is not high enough"}}
+type TornError struct {
+	Code    int    `json:"code"`
+	Message string `json:"error"`
+}
+
+func (e *TornError) Error() string {
+	return fmt.Sprintf("torn API error %d: %s", e.Code, e.Message)
+}
+
+// AccessDenied reports whether the key lacks the access level for the selection,
+// which won't change until the key itself is upgraded
+func (e *TornError) AccessDenied() bool {
+	return e.Code == tornErrorAccessLevel || e.Code == tornErrorIncorrectRelation
+}
+
+// KeyUnusable reports whether the key itself is wrong, disabled for inactivity or paused
+// by its owner, so no request made with it can succeed
+func (e *TornError) KeyUnusable() bool {
+	return e.Code == tornErrorIncorrectKey || e.Code == tornErrorKeyInactive || e.Code == tornErrorKeyPaused
 }
 
 type Item struct {
@@ -30,12 +99,12 @@ type Item struct {
 	Description string  `json:"description"`
 	Effect      string  `json:"effect"`
 	Type        string  `json:"type"`
-	BuyPrice    int     `json:"buy_price"`
-	SellPrice   int     `json:"sell_price"`
+	BuyPrice    FlexInt `json:"buy_price"`
+	SellPrice   FlexInt `json:"sell_price"`
 	MarketValue float64 `json:"market_value"`
-	Circulation int     `json:"circulation"`
+	Circulation FlexInt `json:"circulation"`
 	Image       string  `json:"image"`
-	Tradeable   bool    `json:"tradeable"`
+	Tradeable   *bool   `json:"tradeable"` // nil when the response omits it
 }
 
 type ItemsResponse struct {
@@ -76,7 +145,7 @@ type Slot struct {
 	Position           string           `json:"position"`
 	ItemRequirement    *ItemRequirement `json:"item_requirement"`
 	User               *User            `json:"user"`
-	CheckpointPassRate int              `json:"checkpoint_pass_rate"`
+	CheckpointPassRate FlexInt          `json:"checkpoint_pass_rate"`
 }
 
 type Crime struct {
@@ -88,12 +157,34 @@ type Crime struct {
 
 type CrimesResponse struct {
 	Crimes []Crime `json:"crimes"`
+	// HasCrimesKey is false when the response had no "crimes" key (or a null one), as opposed
+	// to an explicit empty array; that can mean a malformed response rather than no crimes
+	HasCrimesKey bool `json:"-"`
+}
+
+func (r *CrimesResponse) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Crimes *[]Crime `json:"crimes"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	r.HasCrimesKey = raw.Crimes != nil
+	r.Crimes = nil
+	if raw.Crimes != nil {
+		r.Crimes = *raw.Crimes
+	}
+	return nil
 }
 
 type SuppliedItem struct {
-	ItemID  int `json:"item_id"`
-	UserID  int `json:"user_id"`
-	CrimeID int `json:"crime_id"`
+	ItemID    int    `json:"item_id"`
+	UserID    int    `json:"user_id"`
+	CrimeID   int    `json:"crime_id"`
+	CrimeName string `json:"crime_name"`
+	Category  string `json:"category"`
+	// PassRate is the slot's checkpoint pass rate, 0-100
+	PassRate int `json:"pass_rate"`
 }
 
 type cachedItem struct {
@@ -106,6 +197,15 @@ type cachedUser struct {
 	timestamp time.Time
 }
 
+type cachedStatus struct {
+	status    UserStatus
+	timestamp time.Time
+}
+
+// DefaultUserStatusTTL is how long a user's status (okay, traveling, hospital...) is
+// reused before it is fetched again
+const DefaultUserStatusTTL = time.Minute
+
 // Log API types
 type LogItem struct {
 	ID  int `json:"id"`
@@ -138,14 +238,68 @@ func min(a, b int) int {
 	return b
 }
 
-func NewClient(apiKey string, factionApiKey string) *Client {
+// NewClient creates a Torn API client. Clients given the same catalog share item lookups;
+// a nil catalog gives the client its own.
+func NewClient(apiKey string, factionApiKey string, userAgent string, catalog *Catalog) *Client {
+	if catalog == nil {
+		catalog = NewCatalog(DefaultItemCacheTTL)
+	}
+
+	retryableStatuses := make(map[int]bool)
+	for _, code := range config.DefaultResilienceConfig.RetryableStatusCodes {
+		retryableStatuses[code] = true
+	}
+
 	return &Client{
 		apiKey:        apiKey,
 		factionApiKey: factionApiKey,
 		client:        &http.Client{
 			// No timeout - let retry logic's context handle all timeouts
 		},
+		retryableStatuses: retryableStatuses,
+		baseURL:           defaultBaseURL,
+		retryConfig:       config.DefaultResilienceConfig.APIRequest,
+		userAgent:         userAgent,
+		catalog:           catalog,
+		crimeCategories:   DefaultCrimeCategories,
+		statusTTL:         DefaultUserStatusTTL,
+	}
+}
+
+// SetBaseURL points the client at another Torn API host, such as a test server
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = baseURL
+}
+
+// SetUserStatusTTL sets how long GetUserStatus reuses a fetched status; zero always fetches
+func (c *Client) SetUserStatusTTL(ttl time.Duration) {
+	c.statusTTL = max(ttl, 0)
+}
+
+// SetItemFilters restricts which required items are tracked. Blocklisted items are never
+// tracked; when the allowlist is non-empty only allowlisted items are tracked.
+func (c *Client) SetItemFilters(allowlist, blocklist []int) {
+	c.itemAllowlist = make(map[int]bool, len(allowlist))
+	for _, id := range allowlist {
+		c.itemAllowlist[id] = true
+	}
+	c.itemBlocklist = make(map[int]bool, len(blocklist))
+	for _, id := range blocklist {
+		c.itemBlocklist[id] = true
+	}
+}
+
+// SetCrimeCategories sets the faction crime categories scanned by GetSuppliedItems
+func (c *Client) SetCrimeCategories(categories []string) {
+	if len(categories) == 0 {
+		categories = DefaultCrimeCategories
 	}
+	c.crimeCategories = categories
+}
+
+// CrimeCategories returns the faction crime categories scanned by GetSuppliedItems
+func (c *Client) CrimeCategories() []string {
+	return c.crimeCategories
 }
 
 // IncrementAPICall safely increments the API call counter
@@ -155,50 +309,82 @@ func (c *Client) IncrementAPICall() {
 	c.apiCallMutex.Unlock()
 }
 
-// makeAPIRequest creates and executes an HTTP GET request to the Torn API with retry logic
-func (c *Client) makeAPIRequest(ctx context.Context, url string) (*http.Response, error) {
-	return retry.WithRetry(ctx, config.DefaultResilienceConfig.APIRequest, func(ctx context.Context) (*http.Response, error) {
+// makeAPIRequest executes an HTTP GET request to the Torn API with retry logic and returns the
+// response body. Status handling happens inside the retry so retryable statuses are retried.
+func (c *Client) makeAPIRequest(ctx context.Context, url string) ([]byte, error) {
+	return c.makeAPIRequestWithRetry(ctx, c.retryConfig, url)
+}
+
+// makeAPIRequestWithRetry is makeAPIRequest retrying per cfg instead of the client's own
+// settings
+func (c *Client) makeAPIRequestWithRetry(ctx context.Context, cfg retry.Config, url string) ([]byte, error) {
+	return retry.WithRetry(ctx, cfg, func(ctx context.Context) ([]byte, error) {
 		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
+			return nil, retry.Permanent(fmt.Errorf("failed to create request: %w", &redactedError{err: err}))
+		}
+		applyExtraHeaders(req)
+		if c.userAgent != "" {
+			req.Header.Set("User-Agent", c.userAgent)
 		}
 
 		resp, err := c.client.Do(req)
 		if err != nil {
-			slog.Debug("API request failed", "error", err, "url", url)
+			err = &redactedError{err: err}
+			if dnsErr := dnsError(err); dnsErr != nil {
+				slog.Warn("DNS lookup for the Torn API failed; the network may not be ready yet",
+					"host", dnsErr.Name, "error", dnsErr.Err, "retry_after", dnsRetryDelay)
+				return nil, retry.After(fmt.Errorf("failed to resolve Torn API host: %w", err), dnsRetryDelay)
+			}
+			slog.Debug("API request failed", "error", err, "url", RedactURL(url))
 			return nil, fmt.Errorf("failed to make request: %w", err)
 		}
+		// Every response body is drained and closed here, including those for retried
+		// attempts, so connections are returned to the pool under retry storms
+		defer drainAndClose(resp.Body)
 
 		// Only increment API call counter after successful request
 		c.IncrementAPICall()
+		clock.observe(resp.Header, time.Now())
 
-		return resp, nil
-	})
-}
-
-// handleAPIResponse processes the HTTP response and returns the body bytes
-func (c *Client) handleAPIResponse(resp *http.Response) ([]byte, error) {
-	defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			slog.Debug("Failed to read response body - detailed error info", "error", err, "status_code", resp.StatusCode, "content_type", resp.Header.Get("Content-Type"))
+			return nil, fmt.Errorf("failed to read response body (status: %d): %w", resp.StatusCode, err)
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
+		if resp.StatusCode != http.StatusOK {
+			apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+			if c.retryableStatuses[resp.StatusCode] {
+				slog.Debug("API request returned retryable status", "status_code", resp.StatusCode, "url", RedactURL(url))
+				return nil, apiErr
+			}
+			return nil, retry.Permanent(apiErr)
+		}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		slog.Debug("Failed to read response body - detailed error info", "error", err, "status_code", resp.StatusCode, "content_type", resp.Header.Get("Content-Type"))
+		log.DebugSampled("Received API response", "status_code", resp.StatusCode, "content_type", resp.Header.Get("Content-Type"), "body_length", len(body))
+		return body, nil
+	})
+}
 
-		// If we successfully got headers but failed reading body, this is likely a network issue
-		// that should be retried rather than treated as a permanent failure
-		if resp.StatusCode == 200 && err.Error() == "context canceled" {
-			return nil, fmt.Errorf("network connection interrupted during body read: %w", err)
-		}
+// dnsRetryDelay is the least wait before retrying a failed DNS lookup, which on container
+// start usually means the network isn't up yet rather than that the API is down
+const dnsRetryDelay = 5 * time.Second
 
-		return nil, fmt.Errorf("failed to read response body (status: %d): %w", resp.StatusCode, err)
+// dnsError returns the DNS lookup failure behind a request error, or nil
+func dnsError(err error) *net.DNSError {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr
 	}
+	return nil
+}
 
-	return body, nil
+// drainAndClose discards any unread bytes from body before closing it so the
+// underlying keep-alive connection can be reused
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxDrainBytes))
+	_ = body.Close()
 }
 
 // GetAPICallCount returns the current API call count
@@ -208,6 +394,20 @@ func (c *Client) GetAPICallCount() int64 {
 	return c.apiCallCount
 }
 
+// SetAPICallBudget sets the maximum API calls per loop; zero disables the budget
+func (c *Client) SetAPICallBudget(budget int64) {
+	c.apiCallMutex.Lock()
+	c.apiCallBudget = budget
+	c.apiCallMutex.Unlock()
+}
+
+// BudgetExhausted reports whether this loop's API call count has reached the budget
+func (c *Client) BudgetExhausted() bool {
+	c.apiCallMutex.Lock()
+	defer c.apiCallMutex.Unlock()
+	return c.apiCallBudget > 0 && c.apiCallCount >= c.apiCallBudget
+}
+
 // ResetAPICallCount resets the API call counter to zero
 func (c *Client) ResetAPICallCount() {
 	c.apiCallMutex.Lock()
@@ -216,23 +416,14 @@ func (c *Client) ResetAPICallCount() {
 }
 
 func (c *Client) GetItem(ctx context.Context, itemID string) (*Item, error) {
-	// Check cache first
-	if cached, ok := c.itemCache.Load(itemID); ok {
-		cachedItem := cached.(cachedItem)
-		// Cache valid for 1 hour
-		if time.Since(cachedItem.timestamp) < time.Hour {
-			return cachedItem.item, nil
-		}
+	// Check the shared catalog first
+	if item, ok := c.catalog.Get(itemID); ok {
+		return item, nil
 	}
 
-	return retry.WithRetry(ctx, config.DefaultResilienceConfig.APIRequest, func(ctx context.Context) (*Item, error) {
-		url := fmt.Sprintf("https://api.torn.com/torn/%s?selections=items&key=%s", itemID, c.apiKey)
-		resp, err := c.makeAPIRequest(ctx, url)
-		if err != nil {
-			return nil, err
-		}
-
-		body, err := c.handleAPIResponse(resp)
+	return retry.WithRetry(ctx, c.retryConfig, func(ctx context.Context) (*Item, error) {
+		url := fmt.Sprintf("%s/torn/%s?selections=items&key=%s", c.baseURL, itemID, c.apiKey)
+		body, err := c.makeAPIRequest(ctx, url)
 		if err != nil {
 			return nil, err
 		}
@@ -250,15 +441,14 @@ func (c *Client) GetItem(ctx context.Context, itemID string) (*Item, error) {
 		}
 
 		// Cache the result
-		c.itemCache.Store(itemID, cachedItem{
-			item:      &item,
-			timestamp: time.Now(),
-		})
+		c.catalog.Store(itemID, &item)
 
 		return &item, nil
 	})
 }
 
+// GetUser returns a user's details, cached for an hour. Names rarely change, but the
+// status in a cached result can be as old, so use GetUserStatus for the current one.
 func (c *Client) GetUser(ctx context.Context, userID string) (*UserInfo, error) {
 	// Check cache first
 	if cached, ok := c.userCache.Load(userID); ok {
@@ -268,16 +458,32 @@ func (c *Client) GetUser(ctx context.Context, userID string) (*UserInfo, error)
 			return cachedUser.user, nil
 		}
 	}
+	return c.fetchUser(ctx, userID)
+}
 
-	return retry.WithRetry(ctx, config.DefaultResilienceConfig.APIRequest, func(ctx context.Context) (*UserInfo, error) {
-		url := fmt.Sprintf("https://api.torn.com/user/%s?selections=basic&key=%s", userID, c.apiKey)
-
-		resp, err := c.makeAPIRequest(ctx, url)
-		if err != nil {
-			return nil, err
+// GetUserStatus returns a user's current status, cached only for the status TTL so
+// decisions about whether they can receive items use fresh state
+func (c *Client) GetUserStatus(ctx context.Context, userID string) (*UserStatus, error) {
+	if cached, ok := c.statusCache.Load(userID); ok {
+		cachedStatus := cached.(cachedStatus)
+		if time.Since(cachedStatus.timestamp) < c.statusTTL {
+			return &cachedStatus.status, nil
 		}
+	}
+
+	userInfo, err := c.fetchUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &userInfo.Status, nil
+}
 
-		body, err := c.handleAPIResponse(resp)
+// fetchUser requests a user's details and refreshes both the user and status caches
+func (c *Client) fetchUser(ctx context.Context, userID string) (*UserInfo, error) {
+	return retry.WithRetry(ctx, c.retryConfig, func(ctx context.Context) (*UserInfo, error) {
+		url := fmt.Sprintf("%s/user/%s?selections=basic&key=%s", c.baseURL, userID, c.apiKey)
+
+		body, err := c.makeAPIRequest(ctx, url)
 		if err != nil {
 			return nil, err
 		}
@@ -288,26 +494,46 @@ func (c *Client) GetUser(ctx context.Context, userID string) (*UserInfo, error)
 		}
 
 		// Cache the result
+		now := time.Now()
 		c.userCache.Store(userID, cachedUser{
 			user:      &userInfo,
-			timestamp: time.Now(),
+			timestamp: now,
+		})
+		c.statusCache.Store(userID, cachedStatus{
+			status:    userInfo.Status,
+			timestamp: now,
 		})
 
 		return &userInfo, nil
 	})
 }
 
+// envelopeError returns the Torn error envelope in a 200 response body, or nil when there
+// is none. Access and key errors are permanent since retrying can't restore the key's
+// access.
+func envelopeError(body []byte) error {
+	var envelope struct {
+		Error *TornError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Error == nil {
+		return nil
+	}
+	if envelope.Error.AccessDenied() || envelope.Error.KeyUnusable() {
+		return retry.Permanent(envelope.Error)
+	}
+	return envelope.Error
+}
+
 func (c *Client) GetFactionCrimes(ctx context.Context, category string, offset int) (*CrimesResponse, error) {
-	return retry.WithRetry(ctx, config.DefaultResilienceConfig.APIRequest, func(ctx context.Context) (*CrimesResponse, error) {
-		url := fmt.Sprintf("https://api.torn.com/v2/faction/crimes?key=%s&cat=%s&offset=%d", c.factionApiKey, category, offset)
+	return retry.WithRetry(ctx, c.retryConfig, func(ctx context.Context) (*CrimesResponse, error) {
+		url := fmt.Sprintf("%s/v2/faction/crimes?key=%s&cat=%s&offset=%d", c.baseURL, c.factionApiKey, category, offset)
 
-		resp, err := c.makeAPIRequest(ctx, url)
+		body, err := c.makeAPIRequest(ctx, url)
 		if err != nil {
 			return nil, err
 		}
 
-		body, err := c.handleAPIResponse(resp)
-		if err != nil {
+		if err := envelopeError(body); err != nil {
 			return nil, err
 		}
 
@@ -321,16 +547,38 @@ func (c *Client) GetFactionCrimes(ctx context.Context, category string, offset i
 }
 
 func (c *Client) GetSuppliedItems(ctx context.Context) ([]SuppliedItem, error) {
-	slog.Debug("Fetching faction crimes for supplied items")
-	crimesResp, err := c.GetFactionCrimes(ctx, "planning", 0)
-	if err != nil {
-		slog.Error("Failed to get planning crimes", "error", err)
-		return nil, fmt.Errorf("failed to get planning crimes: %w", err)
-	}
+	return c.collectSuppliedItems(ctx, c.GetFactionCrimes)
+}
 
-	slog.Debug("Retrieved faction crimes", "total_crimes", len(crimesResp.Crimes))
+// collectSuppliedItems gathers supplied items across the configured crime categories using
+// fetch to load each category's crimes
+func (c *Client) collectSuppliedItems(ctx context.Context, fetch func(ctx context.Context, category string, offset int) (*CrimesResponse, error)) ([]SuppliedItem, error) {
+	slog.Debug("Fetching faction crimes for supplied items", "categories", c.crimeCategories)
 
-	suppliedItems := c.processCrimesForSuppliedItems(crimesResp.Crimes)
+	var suppliedItems []SuppliedItem
+	c.unassigned = nil
+	for _, category := range c.crimeCategories {
+		crimesResp, err := fetch(ctx, category, 0)
+		if err != nil {
+			slog.Error("Failed to get faction crimes", "category", category, "error", err)
+			return nil, fmt.Errorf("failed to get %s crimes: %w", category, err)
+		}
+
+		if !crimesResp.HasCrimesKey {
+			slog.Warn("Faction crimes response had no crimes key, possible API anomaly; treating as no crimes", "category", category)
+		}
+		slog.Debug("Retrieved faction crimes", "category", category, "total_crimes", len(crimesResp.Crimes))
+
+		unassignedBefore := len(c.unassigned)
+		categoryItems := c.processCrimesForSuppliedItems(crimesResp.Crimes)
+		for i := range categoryItems {
+			categoryItems[i].Category = category
+		}
+		for i := unassignedBefore; i < len(c.unassigned); i++ {
+			c.unassigned[i].Category = category
+		}
+		suppliedItems = append(suppliedItems, categoryItems...)
+	}
 
 	slog.Debug("Finished processing supplied items", "total_supplied_items", len(suppliedItems))
 
@@ -362,7 +610,7 @@ func (c *Client) processCrimesForSuppliedItems(crimes []Crime) []SuppliedItem {
 
 // logCrimeProcessing logs information about the crime being processed
 func (c *Client) logCrimeProcessing(crime Crime) {
-	slog.Debug("Processing crime", "crime_id", crime.ID, "crime_name", crime.Name, "crime_status", crime.Status, "slots", len(crime.Slots))
+	log.DebugSampled("Processing crime", "crime_id", crime.ID, "crime_name", crime.Name, "crime_status", crime.Status, "slots", len(crime.Slots))
 }
 
 // processCrimeSlots processes all slots in a crime and returns supplied items
@@ -373,7 +621,12 @@ func (c *Client) processCrimeSlots(crime Crime) []SuppliedItem {
 		c.logSlotProcessing(crime.ID, slotIndex, slot)
 
 		if suppliedItem := c.processSlotForSuppliedItem(crime.ID, slotIndex, slot); suppliedItem != nil {
+			suppliedItem.CrimeName = crime.Name
 			suppliedItems = append(suppliedItems, *suppliedItem)
+		} else if c.trackUnassigned {
+			if need := c.unassignedNeed(crime, slotIndex, slot); need != nil {
+				c.unassigned = append(c.unassigned, *need)
+			}
 		}
 	}
 
@@ -382,12 +635,12 @@ func (c *Client) processCrimeSlots(crime Crime) []SuppliedItem {
 
 // logSlotProcessing logs detailed information about slot processing
 func (c *Client) logSlotProcessing(crimeID, slotIndex int, slot Slot) {
-	slog.Debug("Processing slot", "crime_id", crimeID, "slot_index", slotIndex, "position", slot.Position, "has_item_requirement", slot.ItemRequirement != nil, "has_user", slot.User != nil)
+	log.DebugSampled("Processing slot", "crime_id", crimeID, "slot_index", slotIndex, "position", slot.Position, "has_item_requirement", slot.ItemRequirement != nil, "has_user", slot.User != nil)
 	if slot.ItemRequirement != nil {
-		slog.Debug("Item requirement details", "crime_id", crimeID, "slot_index", slotIndex, "item_id", slot.ItemRequirement.ID, "is_reusable", slot.ItemRequirement.IsReusable, "is_available", slot.ItemRequirement.IsAvailable)
+		log.DebugSampled("Item requirement details", "crime_id", crimeID, "slot_index", slotIndex, "item_id", slot.ItemRequirement.ID, "is_reusable", slot.ItemRequirement.IsReusable, "is_available", slot.ItemRequirement.IsAvailable)
 	}
 	if slot.User != nil {
-		slog.Debug("User details", "crime_id", crimeID, "slot_index", slotIndex, "user_id", slot.User.ID, "progress", slot.User.Progress)
+		log.DebugSampled("User details", "crime_id", crimeID, "slot_index", slotIndex, "user_id", slot.User.ID, "progress", slot.User.Progress)
 	}
 }
 
@@ -408,12 +661,18 @@ func (c *Client) processSlotForSuppliedItem(crimeID, slotIndex int, slot Slot) *
 		return nil
 	}
 
+	if !c.isItemTracked(slot.ItemRequirement.ID) {
+		slog.Debug("Skipping item excluded by item filters", "crime_id", crimeID, "slot_index", slotIndex, "item_id", slot.ItemRequirement.ID)
+		return nil
+	}
+
 	slog.Info("Found supplied item", "crime_id", crimeID, "slot_index", slotIndex, "item_id", slot.ItemRequirement.ID, "user_id", slot.User.ID)
 
 	return &SuppliedItem{
-		ItemID:  slot.ItemRequirement.ID,
-		UserID:  slot.User.ID,
-		CrimeID: crimeID,
+		ItemID:   slot.ItemRequirement.ID,
+		UserID:   slot.User.ID,
+		CrimeID:  crimeID,
+		PassRate: int(slot.CheckpointPassRate),
 	}
 }
 
@@ -424,36 +683,67 @@ func (c *Client) shouldSupplyItem(requirement *ItemRequirement) bool {
 	return !requirement.IsReusable || (requirement.IsReusable && !requirement.IsAvailable)
 }
 
+// isItemTracked checks an item ID against the configured allowlist and blocklist
+func (c *Client) isItemTracked(itemID int) bool {
+	if c.itemBlocklist[itemID] {
+		return false
+	}
+	return len(c.itemAllowlist) == 0 || c.itemAllowlist[itemID]
+}
+
+// Log type IDs for the user log selection
+const (
+	ItemSendLogType = 4102
+	// DefaultArmoryDepositLogType is the log type for depositing items into the faction armory
+	DefaultArmoryDepositLogType = 6729
+)
+
 func (c *Client) GetItemSendLogs(ctx context.Context) (*LogResponse, error) {
 	slog.Debug("Making request to item send logs API")
+	return c.getLogs(ctx, ItemSendLogType)
+}
 
+// GetArmoryDepositLogs returns the key owner's faction armory deposits for the last 48 hours
+func (c *Client) GetArmoryDepositLogs(ctx context.Context, logType int) (*LogResponse, error) {
+	slog.Debug("Making request to armory deposit logs API", "log_type", logType)
+	return c.getLogs(ctx, logType)
+}
+
+// getLogs fetches the key owner's log entries of one type for the last 48 hours
+func (c *Client) getLogs(ctx context.Context, logType int) (*LogResponse, error) {
 	// Calculate timestamps for last 48 hours
-	now := time.Now()
+	now := appclock.Now()
 	from := now.Add(-48 * time.Hour).Unix()
 	to := now.Unix()
 
-	return retry.WithRetry(ctx, config.DefaultResilienceConfig.APIRequest, func(ctx context.Context) (*LogResponse, error) {
-		url := fmt.Sprintf("https://api.torn.com/user?selections=log&log=4102&from=%d&to=%d&key=%s", from, to, c.apiKey)
+	return retry.WithRetry(ctx, c.retryConfig, func(ctx context.Context) (*LogResponse, error) {
+		url := fmt.Sprintf("%s/user?selections=log&log=%d&from=%d&to=%d&key=%s", c.baseURL, logType, from, to, c.apiKey)
 
-		slog.Debug("Querying logs for time range", "from_timestamp", from, "to_timestamp", to, "from_time", time.Unix(from, 0).Format("2006-01-02 15:04:05"), "to_time", time.Unix(to, 0).Format("2006-01-02 15:04:05"))
+		slog.Debug("Querying logs for time range",
+			"from_timestamp", from,
+			"to_timestamp", to,
+			"from_time", time.Unix(from, 0).Format("2006-01-02 15:04:05 MST"),
+			"to_time", time.Unix(to, 0).Format("2006-01-02 15:04:05 MST"),
+			"from_time_utc", time.Unix(from, 0).UTC().Format(time.RFC3339),
+			"to_time_utc", time.Unix(to, 0).UTC().Format(time.RFC3339),
+			"clock_skew", ClockSkew().Round(time.Second),
+		)
 
-		resp, err := c.makeAPIRequest(ctx, url)
+		body, err := c.makeAPIRequest(ctx, url)
 		if err != nil {
 			return nil, err
 		}
 
-		slog.Debug("Received API response", "status_code", resp.StatusCode, "content_type", resp.Header.Get("Content-Type"))
+		slog.Debug("Read response body", "body_length", len(body), "response_body_preview", bodyPreview(body))
 
-		body, err := c.handleAPIResponse(resp)
-		if err != nil {
+		// A key that lost log access gets a 200 with an error envelope and no log field
+		if err := envelopeError(body); err != nil {
 			return nil, err
 		}
 
-		slog.Debug("Read response body", "body_length", len(body), "response_body_preview", string(body[:min(500, len(body))]))
-
-		var logResp LogResponse
-		if err := json.Unmarshal(body, &logResp); err != nil {
-			slog.Debug("Failed to unmarshal JSON response", "error", err, "response_body", string(body))
+		logResp, err := decodeLogResponse(body)
+		if err != nil {
+			slog.Debug("Failed to unmarshal JSON response", "error", err, "response_body_preview", bodyPreview(body))
 			return nil, fmt.Errorf("failed to decode response: %w", err)
 		}
 
@@ -467,21 +757,85 @@ func (c *Client) GetItemSendLogs(ctx context.Context) (*LogResponse, error) {
 			}
 		}
 
-		return &logResp, nil
+		return logResp, nil
 	})
 }
 
+// decodeLogResponse decodes a log response one entry at a time so a single malformed entry
+// is skipped with a warning instead of discarding the whole batch. Torn returns the log
+// either as an array or as an object keyed by log ID; both shapes are accepted.
+func decodeLogResponse(body []byte) (*LogResponse, error) {
+	var raw struct {
+		Log json.RawMessage `json:"log"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+
+	logResp := &LogResponse{}
+	trimmed := bytes.TrimSpace(raw.Log)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return logResp, nil
+	}
+
+	var rawEntries []json.RawMessage
+	var entryIDs []string
+	switch trimmed[0] {
+	case '[':
+		if err := json.Unmarshal(trimmed, &rawEntries); err != nil {
+			return nil, err
+		}
+		for i := range rawEntries {
+			entryIDs = append(entryIDs, strconv.Itoa(i))
+		}
+	case '{':
+		var keyed map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &keyed); err != nil {
+			return nil, err
+		}
+		for id, entry := range keyed {
+			entryIDs = append(entryIDs, id)
+			rawEntries = append(rawEntries, entry)
+		}
+	default:
+		return nil, fmt.Errorf("unexpected log field type: %.20s", string(trimmed))
+	}
+
+	for i, rawEntry := range rawEntries {
+		var entry LogEntry
+		if err := json.Unmarshal(rawEntry, &entry); err != nil {
+			slog.Warn("Skipping malformed log entry", "log_id", entryIDs[i], "error", err)
+			continue
+		}
+		logResp.Log = append(logResp.Log, entry)
+	}
+
+	// Object keys have no order, so present entries newest first as the array form does
+	sort.SliceStable(logResp.Log, func(i, j int) bool {
+		return logResp.Log[i].Timestamp > logResp.Log[j].Timestamp
+	})
+
+	return logResp, nil
+}
+
 func (c *Client) WhoAmI(ctx context.Context) (string, error) {
-	return retry.WithRetry(ctx, config.DefaultResilienceConfig.APIRequest, func(ctx context.Context) (string, error) {
-		url := fmt.Sprintf("https://api.torn.com/user/?selections=basic&key=%s", c.apiKey)
+	return c.WhoAmIWithRetry(ctx, c.retryConfig)
+}
 
-		resp, err := c.makeAPIRequest(ctx, url)
+// WhoAmIWithRetry resolves the key owner's name like WhoAmI, retrying per cfg instead of
+// the client's own settings. Each attempt is a single request, so cfg alone caps how many
+// are made, and a wrong or revoked key fails on the first.
+func (c *Client) WhoAmIWithRetry(ctx context.Context, cfg retry.Config) (string, error) {
+	single := cfg
+	single.MaxRetries = 0
+	return retry.WithRetry(ctx, cfg, func(ctx context.Context) (string, error) {
+		url := fmt.Sprintf("%s/user/?selections=basic&key=%s", c.baseURL, c.apiKey)
+
+		body, err := c.makeAPIRequestWithRetry(ctx, single, url)
 		if err != nil {
 			return "", err
 		}
-
-		body, err := c.handleAPIResponse(resp)
-		if err != nil {
+		if err := envelopeError(body); err != nil {
 			return "", err
 		}
 
@@ -489,6 +843,9 @@ func (c *Client) WhoAmI(ctx context.Context) (string, error) {
 		if err := json.Unmarshal(body, &userInfo); err != nil {
 			return "", fmt.Errorf("failed to decode response: %w", err)
 		}
+		if userInfo.Name == "" {
+			return "", errors.New("response has no name for the key owner")
+		}
 
 		return userInfo.Name, nil
 	})
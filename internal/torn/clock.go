@@ -0,0 +1,76 @@
+package torn
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultClockSkewThreshold is how far local time may drift from the Torn API's clock
+// before a warning is logged
+const DefaultClockSkewThreshold = 2 * time.Minute
+
+// clockMonitor compares local time against the Date header of Torn API responses. Log
+// windows are computed from the local clock, so a skewed host misses recent sends. Skew
+// is a property of the host, so one monitor is shared by every client.
+type clockMonitor struct {
+	mu        sync.Mutex
+	threshold time.Duration
+	skew      time.Duration
+	warned    bool
+}
+
+var clock = &clockMonitor{threshold: DefaultClockSkewThreshold}
+
+// SetClockSkewThreshold sets the drift from the Torn API's clock that triggers a warning;
+// zero disables the check
+func SetClockSkewThreshold(threshold time.Duration) {
+	clock.mu.Lock()
+	clock.threshold = threshold
+	clock.mu.Unlock()
+}
+
+// ClockSkew returns how far local time was ahead of the Torn API's clock at the last
+// response; negative when local time is behind
+func ClockSkew() time.Duration {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.skew
+}
+
+// observe records the skew between received and the response's Date header, warning once
+// when it passes the threshold and again only after it has recovered. The header has
+// one-second resolution, which is well inside any useful threshold.
+func (m *clockMonitor) observe(header http.Header, received time.Time) {
+	serverTime, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		return
+	}
+	skew := received.Sub(serverTime)
+
+	m.mu.Lock()
+	m.skew = skew
+	if m.threshold <= 0 {
+		m.mu.Unlock()
+		return
+	}
+	skewed := skew > m.threshold || skew < -m.threshold
+	warn := skewed && !m.warned
+	recovered := !skewed && m.warned
+	m.warned = skewed
+	threshold := m.threshold
+	m.mu.Unlock()
+
+	if warn {
+		slog.Warn("Local clock differs from the Torn API clock; provider log windows may miss recent sends",
+			"skew", skew.Round(time.Second),
+			"threshold", threshold,
+			"local_time_utc", received.UTC().Format(time.RFC3339),
+			"torn_time_utc", serverTime.UTC().Format(time.RFC3339),
+		)
+	}
+	if recovered {
+		slog.Info("Local clock back in line with the Torn API clock", "skew", skew.Round(time.Second))
+	}
+}
@@ -0,0 +1,43 @@
+package torn
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// FlexInt is an int that unmarshals from either a JSON number or a quoted string,
+// since Torn returns some numeric fields in both representations
+type FlexInt int
+
+func (f *FlexInt) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			*f = 0
+			return nil
+		}
+		data = []byte(s)
+	}
+
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		// Accept whole numbers written with a fractional part, e.g. 5.0
+		fl, ferr := strconv.ParseFloat(string(data), 64)
+		if ferr != nil || fl != float64(int64(fl)) {
+			return fmt.Errorf("invalid integer value %q: %w", string(data), err)
+		}
+		n = int64(fl)
+	}
+	*f = FlexInt(n)
+	return nil
+}
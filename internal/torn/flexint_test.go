@@ -0,0 +1,55 @@
+package torn
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFlexIntUnmarshal(t *testing.T) {
+	cases := map[string]FlexInt{
+		`5`:    5,
+		`"5"`:  5,
+		`"-3"`: -3,
+		`""`:   0,
+		`null`: 0,
+		`5.0`:  5,
+	}
+	for input, want := range cases {
+		var got FlexInt
+		if err := json.Unmarshal([]byte(input), &got); err != nil {
+			t.Errorf("Unmarshal(%s) returned error: %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", input, got, want)
+		}
+	}
+
+	var invalid FlexInt
+	if err := json.Unmarshal([]byte(`"abc"`), &invalid); err == nil {
+		t.Error("Expected error for non-numeric string")
+	}
+}
+
+func TestItemUnmarshalQuotedNumbers(t *testing.T) {
+	var numeric, quoted Item
+	if err := json.Unmarshal([]byte(`{"name":"Binoculars","buy_price":5,"sell_price":2,"circulation":1000}`), &numeric); err != nil {
+		t.Fatalf("Failed to unmarshal numeric item: %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"name":"Binoculars","buy_price":"5","sell_price":"2","circulation":"1000"}`), &quoted); err != nil {
+		t.Fatalf("Failed to unmarshal quoted item: %v", err)
+	}
+	if numeric != quoted {
+		t.Errorf("Expected identical items, got %+v and %+v", numeric, quoted)
+	}
+}
+
+func TestSlotUnmarshalQuotedPassRate(t *testing.T) {
+	var slot Slot
+	if err := json.Unmarshal([]byte(`{"position":"Picklock","checkpoint_pass_rate":"72"}`), &slot); err != nil {
+		t.Fatalf("Failed to unmarshal slot: %v", err)
+	}
+	if slot.CheckpointPassRate != 72 {
+		t.Errorf("Expected pass rate 72, got %d", slot.CheckpointPassRate)
+	}
+}
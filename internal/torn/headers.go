@@ -0,0 +1,93 @@
+package torn
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// reservedHeaders are set by the client itself and can't be overridden
+var reservedHeaders = []string{"Host", "User-Agent", "Content-Length"}
+
+// extraHeaders are added to every Torn API request, nil for none
+var extraHeaders atomic.Pointer[http.Header]
+
+// ParseExtraHeaders parses TORN_EXTRA_HEADERS, comma separated "Name:value" pairs such as
+// "X-Gateway:torn,X-Request-Source:oc-items". Values may contain colons but not commas.
+// Errors name the offending header but never include a value, since values may be
+// gateway credentials.
+func ParseExtraHeaders(value string) (http.Header, error) {
+	headers := make(http.Header)
+	for i, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, headerValue, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("header %d is not in name:value form", i+1)
+		}
+		if !validHeaderName(name) {
+			return nil, fmt.Errorf("header %d has an invalid name %q", i+1, name)
+		}
+		if slices.Contains(reservedHeaders, http.CanonicalHeaderKey(name)) {
+			return nil, fmt.Errorf("header %q is set by the client and can't be overridden", name)
+		}
+		headerValue = strings.TrimSpace(headerValue)
+		if !validHeaderValue(headerValue) {
+			return nil, fmt.Errorf("header %q has control characters in its value", name)
+		}
+		headers.Add(name, headerValue)
+	}
+	return headers, nil
+}
+
+// SetExtraHeaders adds headers to every Torn API request from any client, including
+// provider clients; nil or empty removes them. The API key stays in the query string.
+func SetExtraHeaders(headers http.Header) {
+	if len(headers) == 0 {
+		extraHeaders.Store(nil)
+		return
+	}
+	headers = headers.Clone()
+	extraHeaders.Store(&headers)
+}
+
+// applyExtraHeaders copies the configured extra headers onto req
+func applyExtraHeaders(req *http.Request) {
+	headers := extraHeaders.Load()
+	if headers == nil {
+		return
+	}
+	for name, values := range *headers {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+}
+
+// validHeaderName reports whether name is an RFC 9110 token
+func validHeaderName(name string) bool {
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			continue
+		}
+		if !strings.ContainsRune("!#$%&'*+-.^_`|~", r) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// validHeaderValue reports whether value is free of control characters other than tab
+func validHeaderValue(value string) bool {
+	for _, r := range value {
+		if r < ' ' && r != '\t' || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
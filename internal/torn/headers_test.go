@@ -0,0 +1,67 @@
+package torn
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseExtraHeaders(t *testing.T) {
+	headers, err := ParseExtraHeaders(" X-Gateway: torn , x-trace-url:https://example.com/t,,")
+	if err != nil {
+		t.Fatalf("Expected valid headers, got %v", err)
+	}
+	if got := headers.Get("X-Gateway"); got != "torn" {
+		t.Errorf("Expected X-Gateway torn, got %q", got)
+	}
+	if got := headers.Get("X-Trace-Url"); got != "https://example.com/t" {
+		t.Errorf("Expected the value to keep its colons, got %q", got)
+	}
+
+	if headers, err := ParseExtraHeaders(""); err != nil || len(headers) != 0 {
+		t.Errorf("Expected no headers for an empty value, got %v, %v", headers, err)
+	}
+
+	for _, value := range []string{"X-Gateway", ":value", "X Gateway:value", "user-agent:other", "X-Gateway:a\x01b"} {
+		if _, err := ParseExtraHeaders(value); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+	if _, err := ParseExtraHeaders("X-Token:secret\x7f"); err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("Expected an error without the header value, got %v", err)
+	}
+}
+
+func TestExtraHeadersSentWithRequests(t *testing.T) {
+	var got http.Header
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		query = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"profile":{"name":"Alice"}}`))
+	}))
+	defer server.Close()
+
+	headers, err := ParseExtraHeaders("X-Gateway:torn,X-Correlation:abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetExtraHeaders(headers)
+	t.Cleanup(func() { SetExtraHeaders(nil) })
+
+	c := newTestClient(server.URL)
+	if _, err := c.makeAPIRequest(context.Background(), server.URL+"/v2/user/profile?key=test-key"); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if got.Get("X-Gateway") != "torn" || got.Get("X-Correlation") != "abc" {
+		t.Errorf("Expected the extra headers, got %v", got)
+	}
+	if got.Get("User-Agent") != "torn-oc-items/test" {
+		t.Errorf("Expected the user agent to be kept, got %q", got.Get("User-Agent"))
+	}
+	if !strings.Contains(query, "key=test-key") {
+		t.Errorf("Expected the API key in the query string, got %q", query)
+	}
+}
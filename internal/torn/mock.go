@@ -0,0 +1,191 @@
+package torn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	appclock "torn_oc_items/internal/clock"
+)
+
+// MockClient serves Torn API responses from JSON fixtures for MOCK_MODE. Fixtures are
+// re-read on every call so they can be edited while the process runs. The fixture
+// directory holds:
+//
+//   - crimes_<category>.json: a faction crimes response, e.g. crimes_planning.json
+//   - items.json: an items response keyed by item ID
+//   - users.json: user basic info keyed by user ID
+//   - logs_<provider>.json: an item send log response for the named provider
+//   - armory_<provider>.json: an armory deposit log response for the named provider
+//
+// Log timestamps at or below zero are taken as seconds before now, so fixtures stay
+// inside the 48h log window.
+type MockClient struct {
+	*Client
+	dir      string
+	name     string
+	logsFile string
+}
+
+// NewMockClient creates a fixture-backed client. name is returned by WhoAmI and
+// logsFile is the fixture served by GetItemSendLogs; it may be empty for the faction client.
+func NewMockClient(dir, name, logsFile string) *MockClient {
+	return &MockClient{
+		Client:   NewClient("", "", "", nil),
+		dir:      dir,
+		name:     name,
+		logsFile: logsFile,
+	}
+}
+
+// MockProviderNames returns the provider names with a logs_<provider>.json fixture in dir
+func MockProviderNames(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "logs_*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, match := range matches {
+		names = append(names, strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), "logs_"), ".json"))
+	}
+	return names, nil
+}
+
+// MockLogsFile returns the logs fixture name for a provider
+func MockLogsFile(provider string) string {
+	return "logs_" + provider + ".json"
+}
+
+// readFixture counts the call against the API budget like a real request and returns the
+// fixture's contents
+func (m *MockClient) readFixture(name string) ([]byte, error) {
+	m.IncrementAPICall()
+	body, err := os.ReadFile(filepath.Join(m.dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock fixture: %w", err)
+	}
+	return body, nil
+}
+
+func (m *MockClient) GetItem(ctx context.Context, itemID string) (*Item, error) {
+	body, err := m.readFixture("items.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var result ItemsResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	item, ok := result.Items[itemID]
+	if !ok {
+		return nil, fmt.Errorf("item %s not found", itemID)
+	}
+	return &item, nil
+}
+
+func (m *MockClient) GetUser(ctx context.Context, userID string) (*UserInfo, error) {
+	body, err := m.readFixture("users.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var users map[string]UserInfo
+	if err := json.Unmarshal(body, &users); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	user, ok := users[userID]
+	if !ok {
+		return nil, fmt.Errorf("user %s not found", userID)
+	}
+	return &user, nil
+}
+
+// GetUserStatus serves the status from users.json; fixtures never go stale, so nothing is cached
+func (m *MockClient) GetUserStatus(ctx context.Context, userID string) (*UserStatus, error) {
+	user, err := m.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &user.Status, nil
+}
+
+// GetFactionCrimes serves crimes_<category>.json; a missing fixture is an empty category
+func (m *MockClient) GetFactionCrimes(ctx context.Context, category string, offset int) (*CrimesResponse, error) {
+	body, err := m.readFixture("crimes_" + category + ".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return &CrimesResponse{HasCrimesKey: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var crimesResp CrimesResponse
+	if err := json.Unmarshal(body, &crimesResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &crimesResp, nil
+}
+
+func (m *MockClient) GetSuppliedItems(ctx context.Context) ([]SuppliedItem, error) {
+	return m.collectSuppliedItems(ctx, m.GetFactionCrimes)
+}
+
+func (m *MockClient) GetCompletedCrimes(ctx context.Context) (*CrimesResponse, error) {
+	return m.GetFactionCrimes(ctx, "completed", 0)
+}
+
+func (m *MockClient) GetPlanningCrimes(ctx context.Context) (*CrimesResponse, error) {
+	return m.GetFactionCrimes(ctx, "planning", 0)
+}
+
+func (m *MockClient) GetItemSendLogs(ctx context.Context) (*LogResponse, error) {
+	if m.logsFile == "" {
+		return &LogResponse{}, nil
+	}
+	return m.readLogFixture(m.logsFile)
+}
+
+// GetArmoryDepositLogs serves armory_<provider>.json; a missing fixture means no deposits
+func (m *MockClient) GetArmoryDepositLogs(ctx context.Context, logType int) (*LogResponse, error) {
+	logResp, err := m.readLogFixture("armory_" + m.name + ".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return &LogResponse{}, nil
+	}
+	return logResp, err
+}
+
+func (m *MockClient) readLogFixture(name string) (*LogResponse, error) {
+	body, err := m.readFixture(name)
+	if err != nil {
+		return nil, err
+	}
+
+	logResp, err := decodeLogResponse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	now := appclock.Now().Unix()
+	for i := range logResp.Log {
+		if logResp.Log[i].Timestamp <= 0 {
+			logResp.Log[i].Timestamp += now
+		}
+	}
+
+	slog.Debug("Served mock logs", "provider", m.name, "fixture", name, "log_entries_count", len(logResp.Log))
+	return logResp, nil
+}
+
+func (m *MockClient) WhoAmI(ctx context.Context) (string, error) {
+	return m.name, nil
+}
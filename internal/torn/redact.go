@@ -0,0 +1,49 @@
+package torn
+
+import (
+	"regexp"
+	"sync/atomic"
+)
+
+var (
+	apiKeyPattern   = regexp.MustCompile(`(?i)([?&]key=)[^&\s"']+`)
+	userinfoPattern = regexp.MustCompile(`(://[^/?#@\s"':]*:)[^/?#@\s"']+@`)
+)
+
+// RedactURL replaces the value of any key= query parameter in s, whatever its case, and
+// any userinfo password with ***, so request URLs and errors that embed them can be
+// logged without leaking API keys
+func RedactURL(s string) string {
+	s = apiKeyPattern.ReplaceAllString(s, "${1}***")
+	return userinfoPattern.ReplaceAllString(s, "${1}***@")
+}
+
+// redactedError hides API keys in an error's message while keeping it unwrappable, since
+// transport errors quote the full request URL
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return RedactURL(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// bodyPreviewLength caps how much of a response body is logged at DEBUG; 0 logs none
+var bodyPreviewLength atomic.Int64
+
+// SetBodyPreviewLength sets how many characters of response bodies debug logs include;
+// zero turns body previews off
+func SetBodyPreviewLength(n int) {
+	bodyPreviewLength.Store(int64(max(n, 0)))
+}
+
+// bodyPreview returns the start of body for debug logs, redacted and capped at the
+// configured length
+func bodyPreview(body []byte) string {
+	n := min(int(bodyPreviewLength.Load()), len(body))
+	return RedactURL(string(body[:n]))
+}
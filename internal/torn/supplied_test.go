@@ -0,0 +1,124 @@
+package torn
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTornServer serves canned Torn API responses keyed by request path, with the crimes
+// endpoint keyed by category as "/v2/faction/crimes?cat=<category>". Unknown requests get
+// a 404 so a test fails loudly when the client asks for something unexpected.
+func newTornServer(t *testing.T, responses map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Path
+		if cat := r.URL.Query().Get("cat"); cat != "" {
+			key += "?cat=" + cat
+		}
+		if r.URL.Query().Get("key") == "" {
+			t.Errorf("Request to %s has no API key", key)
+		}
+
+		body, ok := responses[key]
+		if !ok {
+			t.Errorf("Unexpected request to %s", key)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetSuppliedItemsSupplyRules(t *testing.T) {
+	server := newTornServer(t, map[string]string{
+		"/v2/faction/crimes?cat=planning": `{"crimes":[
+			{"id":1,"name":"Mob Mentality","status":"Planning","slots":[
+				{"position":"Looter","item_requirement":{"id":1258,"is_reusable":true,"is_available":true},"user":{"id":10}},
+				{"position":"Looter","item_requirement":{"id":568,"is_reusable":true,"is_available":false},"user":{"id":11},"checkpoint_pass_rate":72},
+				{"position":"Picklock","item_requirement":{"id":159,"is_reusable":false,"is_available":true},"user":{"id":12}},
+				{"position":"Picklock","item_requirement":{"id":159,"is_reusable":false,"is_available":false},"user":{"id":13}}
+			]},
+			{"id":2,"name":"Pet Project","status":"Planning","slots":[
+				{"position":"Kidnapper","item_requirement":{"id":206,"is_reusable":false,"is_available":false},"user":null},
+				{"position":"Muscle","item_requirement":null,"user":{"id":14}}
+			]}
+		]}`,
+	})
+
+	c := newTestClient(server.URL)
+	c.SetCrimeCategories([]string{"planning"})
+
+	items, err := c.GetSuppliedItems(context.Background())
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	// Reusable and available (user 10) is skipped, as are the slot without a user and the
+	// slot without an item requirement
+	expected := []SuppliedItem{
+		{ItemID: 568, UserID: 11, CrimeID: 1, CrimeName: "Mob Mentality", Category: "planning", PassRate: 72},
+		{ItemID: 159, UserID: 12, CrimeID: 1, CrimeName: "Mob Mentality", Category: "planning"},
+		{ItemID: 159, UserID: 13, CrimeID: 1, CrimeName: "Mob Mentality", Category: "planning"},
+	}
+	if len(items) != len(expected) {
+		t.Fatalf("Expected %d supplied items, got %d: %+v", len(expected), len(items), items)
+	}
+	for i, want := range expected {
+		if items[i] != want {
+			t.Errorf("Item %d: got %+v, want %+v", i, items[i], want)
+		}
+	}
+	if got := c.GetAPICallCount(); got != 1 {
+		t.Errorf("Expected 1 API call, got %d", got)
+	}
+}
+
+func TestShouldSupplyItem(t *testing.T) {
+	cases := []struct {
+		reusable, available, want bool
+	}{
+		{reusable: true, available: true, want: false},
+		{reusable: true, available: false, want: true},
+		{reusable: false, available: true, want: true},
+		{reusable: false, available: false, want: true},
+	}
+
+	c := NewClient("test-key", "test-faction-key", "torn-oc-items/test", nil)
+	for _, tc := range cases {
+		requirement := &ItemRequirement{ID: 1, IsReusable: tc.reusable, IsAvailable: tc.available}
+		if got := c.shouldSupplyItem(requirement); got != tc.want {
+			t.Errorf("shouldSupplyItem(reusable=%v, available=%v) = %v, want %v", tc.reusable, tc.available, got, tc.want)
+		}
+	}
+}
+
+func TestGetItemSendLogsFromServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/user" || query.Get("selections") != "log" || query.Get("log") != "4102" {
+			t.Errorf("Unexpected logs request %s", r.URL.String())
+		}
+		if query.Get("from") == "" || query.Get("to") == "" {
+			t.Error("Expected the logs request to carry a time range")
+		}
+		_, _ = w.Write([]byte(`{"log":{"a1":{"log":4102,"timestamp":200,"data":{"receiver":11,"items":[{"id":568,"qty":1}],"message":"OC 1"}}}}`))
+	}))
+	defer server.Close()
+
+	c := newTestClient(server.URL)
+	logs, err := c.GetItemSendLogs(context.Background())
+	if err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if len(logs.Log) != 1 {
+		t.Fatalf("Expected 1 log entry, got %d", len(logs.Log))
+	}
+	entry := logs.Log[0]
+	if entry.Data.Receiver != 11 || len(entry.Data.Items) != 1 || entry.Data.Items[0].ID != 568 || entry.Data.Message != "OC 1" {
+		t.Errorf("Unexpected log entry %+v", entry)
+	}
+}
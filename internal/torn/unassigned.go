@@ -0,0 +1,42 @@
+package torn
+
+// UnassignedNeed is an item a crime slot will need once a member joins it: the slot has
+// an item requirement that isn't met but no user yet
+type UnassignedNeed struct {
+	CrimeID   int    `json:"crime_id"`
+	CrimeName string `json:"crime_name"`
+	SlotIndex int    `json:"slot_index"`
+	Position  string `json:"position"`
+	ItemID    int    `json:"item_id"`
+	Category  string `json:"category"`
+}
+
+// SetTrackUnassigned makes GetSuppliedItems also collect item requirements of slots
+// without a user, read back with UnassignedNeeds
+func (c *Client) SetTrackUnassigned(enabled bool) {
+	c.trackUnassigned = enabled
+}
+
+// UnassignedNeeds returns the unassigned needs seen by the last GetSuppliedItems call;
+// always empty unless SetTrackUnassigned is on
+func (c *Client) UnassignedNeeds() []UnassignedNeed {
+	return c.unassigned
+}
+
+// unassignedNeed returns the need for a slot that requires an item the same way a
+// supplied item would, but has no user yet; nil otherwise
+func (c *Client) unassignedNeed(crime Crime, slotIndex int, slot Slot) *UnassignedNeed {
+	if slot.ItemRequirement == nil || slot.User != nil {
+		return nil
+	}
+	if !c.shouldSupplyItem(slot.ItemRequirement) || !c.isItemTracked(slot.ItemRequirement.ID) {
+		return nil
+	}
+	return &UnassignedNeed{
+		CrimeID:   crime.ID,
+		CrimeName: crime.Name,
+		SlotIndex: slotIndex,
+		Position:  slot.Position,
+		ItemID:    slot.ItemRequirement.ID,
+	}
+}
@@ -0,0 +1,42 @@
+package tracking
+
+import (
+	"sync"
+	"time"
+)
+
+// PanicTracker counts recurrences of the same panic within a sliding window so a
+// deterministic panic can be surfaced instead of being recovered forever
+type PanicTracker struct {
+	threshold int
+	window    time.Duration
+	panics    map[string][]time.Time
+	mutex     sync.Mutex
+}
+
+func NewPanicTracker(threshold int, window time.Duration) *PanicTracker {
+	return &PanicTracker{
+		threshold: threshold,
+		window:    window,
+		panics:    make(map[string][]time.Time),
+	}
+}
+
+// Record notes an occurrence of the given panic and returns how many times it has
+// occurred within the window and whether that count exceeds the threshold
+func (pt *PanicTracker) Record(panicValue string, now time.Time) (int, bool) {
+	pt.mutex.Lock()
+	defer pt.mutex.Unlock()
+
+	cutoff := now.Add(-pt.window)
+	recent := pt.panics[panicValue][:0]
+	for _, t := range pt.panics[panicValue] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	pt.panics[panicValue] = recent
+
+	return len(recent), pt.threshold > 0 && len(recent) > pt.threshold
+}
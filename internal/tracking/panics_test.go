@@ -0,0 +1,34 @@
+package tracking
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPanicTrackerTripsOnRepeatedPanic(t *testing.T) {
+	pt := NewPanicTracker(2, time.Minute)
+	start := time.Now()
+
+	if _, tripped := pt.Record("nil map", start); tripped {
+		t.Fatal("Expected first panic not to trip")
+	}
+	if _, tripped := pt.Record("other panic", start); tripped {
+		t.Fatal("Expected distinct panic not to trip")
+	}
+	if _, tripped := pt.Record("nil map", start.Add(time.Second)); tripped {
+		t.Fatal("Expected second panic not to trip at threshold 2")
+	}
+	if count, tripped := pt.Record("nil map", start.Add(2*time.Second)); !tripped || count != 3 {
+		t.Errorf("Expected third panic to trip with count 3, got count %d tripped %v", count, tripped)
+	}
+}
+
+func TestPanicTrackerForgetsPanicsOutsideWindow(t *testing.T) {
+	pt := NewPanicTracker(1, time.Minute)
+	start := time.Now()
+
+	pt.Record("nil map", start)
+	if count, tripped := pt.Record("nil map", start.Add(2*time.Minute)); tripped || count != 1 {
+		t.Errorf("Expected old panic to expire, got count %d tripped %v", count, tripped)
+	}
+}
@@ -2,11 +2,20 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"syscall"
 	"time"
 
 	"torn_oc_items/internal/app"
 	"torn_oc_items/internal/config"
+	"torn_oc_items/internal/events"
+	"torn_oc_items/internal/log"
 	"torn_oc_items/internal/notifications"
 	"torn_oc_items/internal/processing"
 	"torn_oc_items/internal/providers"
@@ -16,79 +25,339 @@ import (
 	"torn_oc_items/internal/tracking"
 )
 
+// shutdownNotifyTimeout bounds how long shutdown waits for pending notifications
+const shutdownNotifyTimeout = 10 * time.Second
+
+// panicFlushTimeout bounds the best-effort flush of buffered rows before exiting on a
+// recurring panic
+const panicFlushTimeout = 15 * time.Second
+
+// version is set at build time via -ldflags "-X main.version=..."
+var version = "dev"
+
 var providerList []providers.Provider
 var stateTracker *tracking.StateTracker
+var panicTracker *tracking.PanicTracker
+var pauseController *app.PauseController
+var appendBuffer *sheets.AppendBuffer
+var supplyConfirmer *processing.SupplyConfirmer
+var unassignedTracker *processing.UnassignedTracker
+var needReminder *processing.NeedReminder
+var eventSink events.EventSink
+var retryMode config.RetryMode
+var loopDeadline time.Duration
+var freshness *app.FreshnessMonitor
+var phaseSchedule *app.PhaseSchedule
 
 func main() {
+	formatSheet := flag.Bool("format-sheet", false, "apply currency and date formats to the sheet's market value and datetime columns, then exit")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration as JSON with secrets redacted, then exit")
+	listNeeded := flag.Bool("list-needed", false, "print the items the faction's crimes need right now, without touching the sheet or sending notifications, then exit")
+	ignoreSelfCheckFailures := flag.Bool("ignore-selfcheck-failures", false, "start even if a critical startup self-check fails")
+	flag.Parse()
+
 	slog.Debug("Starting application")
 	app.SetupEnvironment()
 
+	if *printConfig {
+		if err := app.PrintConfig(os.Stdout, version); err != nil {
+			slog.Error("Failed to print configuration", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	ctx := context.Background()
-	tornClient, sheetsClient := app.InitializeClients(ctx)
-	notificationClient := app.InitializeNotificationClient()
+	userAgent := app.BuildUserAgent(version)
+	slog.Info("Starting torn-oc-items", "version", version, "user_agent", userAgent)
+
+	if *listNeeded {
+		tornClient := app.InitializeTornClient(userAgent, app.InitializeItemCatalog())
+		if err := app.ListNeeded(ctx, os.Stdout, tornClient); err != nil {
+			slog.Error("Failed to list needed items", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	itemCatalog := app.InitializeItemCatalog()
+	tornClient, sheetsClient := app.InitializeClients(ctx, userAgent, itemCatalog)
+	notificationClient := app.InitializeNotificationClient(userAgent)
+	eventSink = app.InitializeEventSink(userAgent)
+
+	if *formatSheet {
+		if err := sheets.FormatSheetColumns(ctx, sheetsClient); err != nil {
+			slog.Error("Failed to format sheet columns", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	stateTracker = tracking.NewStateTracker()
-	providerList = providers.LoadProviders(ctx)
+	panicTracker = app.InitializePanicTracker()
+	pauseController = app.InitializePauseController()
+	appendBuffer = app.InitializeAppendBuffer()
+	supplyConfirmer = app.InitializeSupplyConfirmer()
+	unassignedTracker = app.InitializeUnassignedTracker()
+	needReminder = app.InitializeNeedReminder()
+	retryMode = app.GetRetryMode()
+	loopDeadline = app.GetLoopDeadline()
+	freshness = app.InitializeFreshnessMonitor()
+	if app.MockModeEnabled() {
+		providerList = providers.LoadMockProviders(app.MockDataDir())
+	} else {
+		providerList = providers.LoadProviders(ctx, userAgent, itemCatalog)
+	}
+
+	selfCheck := app.RunSelfCheck(ctx, tornClient, sheetsClient, notificationClient, providerList)
+	app.PrintSelfCheckReport(os.Stderr, selfCheck)
+	if app.SelfCheckFailed(selfCheck) {
+		if !*ignoreSelfCheckFailures {
+			slog.Error("Startup self-check failed; fix the failing checks above or pass --ignore-selfcheck-failures")
+			os.Exit(1)
+		}
+		slog.Warn("Startup self-check failed, continuing because --ignore-selfcheck-failures is set")
+	}
+
+	if statusServer := app.InitializeStatusServer(); statusServer != nil {
+		statusServer.HandleJSON("/providers", func() any {
+			return providers.HealthReport(providerList)
+		})
+		statusServer.HandleJSON("/notify-status", func() any {
+			return notificationClient.Status()
+		})
+		statusServer.HandleJSON("/status/matching", func() any {
+			return processing.LastMatchStats()
+		})
+		statusServer.HandleJSON("/events", func() any {
+			return log.RecentEvents()
+		})
+		statusServer.HandleCheck("/readyz", func() (bool, any) {
+			if freshness == nil {
+				return true, map[string]bool{"ready": true}
+			}
+			readiness := freshness.Readiness(time.Now())
+			return readiness.Ready, readiness
+		})
+		statusServer.Start()
+	}
+
+	if interval := app.GetProviderHealthInterval(); interval > 0 {
+		go runProviderHealthReports(interval)
+	}
 
 	slog.Info("Starting Torn OC Items monitor. Running immediately and then every minute...")
 
-	runProcessLoopWithRetry(ctx, tornClient, sheetsClient, notificationClient)
+	loopInterval := 1 * time.Minute
+	scheduler := app.InitializeLoopScheduler(loopInterval)
+	phaseSchedule = app.InitializePhaseSchedule(loopInterval)
+	if freshness != nil {
+		go runFreshnessChecks(ctx, loopInterval, notificationClient)
+	}
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	// Offset this instance's loop so copies started together don't tick in lockstep; the
+	// ticker starts after the delay, so the offset holds for every later loop
+	if splay := app.RandomSplay(app.GetStartupSplay()); splay > 0 {
+		slog.Info("Delaying first loop by startup splay", "splay", splay)
+		select {
+		case <-time.After(splay):
+		case sig := <-shutdown:
+			slog.Info("Shutting down", "signal", sig.String())
+			return
+		}
+	}
+
+	runProcessLoopWithRetry(ctx, tornClient, sheetsClient, notificationClient, scheduler)
+
+	ticker := time.NewTicker(loopInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !scheduler.Due() {
+				slog.Debug("Skipping tick while backing off after repeated loop failures")
+				continue
+			}
+			runProcessLoopWithRetry(ctx, tornClient, sheetsClient, notificationClient, scheduler)
+		case sig := <-shutdown:
+			slog.Info("Shutting down", "signal", sig.String())
+			flushAppendBuffer(ctx, sheetsClient, notificationClient)
+			notificationClient.FlushBatch(ctx, shutdownNotifyTimeout)
+			return
+		}
+	}
+}
+
+func runProviderHealthReports(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		providers.LogHealthReport(providerList)
+	}
+}
 
-	ticker := time.NewTicker(1 * time.Minute)
+// runFreshnessChecks alerts once when no loop has succeeded within LOOP_STALE_AFTER and
+// again when loops recover. It has its own ticker so a wedged loop can't hold it up.
+func runFreshnessChecks(ctx context.Context, interval time.Duration, notificationClient *notifications.Client) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	var staleFrom time.Time
 	for range ticker.C {
-		runProcessLoopWithRetry(ctx, tornClient, sheetsClient, notificationClient)
+		now := time.Now()
+		became, recovered := freshness.Check(now)
+		switch {
+		case became:
+			staleFrom = freshness.LastSuccess()
+			age := now.Sub(staleFrom)
+			slog.Error("No process loop has succeeded within LOOP_STALE_AFTER", "last_success", staleFrom, "age", age, "threshold", freshness.Threshold())
+			notificationClient.NotifyLoopStale(ctx, age, freshness.Threshold())
+		case recovered:
+			downtime := freshness.LastSuccess().Sub(staleFrom)
+			slog.Info("Process loop is succeeding again", "downtime", downtime)
+			notificationClient.NotifyLoopRecovered(ctx, downtime)
+		}
 	}
 }
 
-func runProcessLoopWithRetry(ctx context.Context, tornClient *torn.Client, sheetsClient *sheets.Client, notificationClient *notifications.Client) {
-	_, err := retry.WithRetry(ctx, config.DefaultResilienceConfig.ProcessLoop, func(ctx context.Context) (struct{}, error) {
+func runProcessLoopWithRetry(ctx context.Context, tornClient torn.TornAPI, sheetsClient *sheets.Client, notificationClient *notifications.Client, scheduler *app.LoopScheduler) {
+	if pauseController.Paused() {
+		slog.Info("Processing paused, skipping this cycle")
+		if freshness != nil {
+			// Pausing is deliberate, so a paused loop doesn't count as stale
+			freshness.RecordSuccess(time.Now())
+		}
+		return
+	}
+
+	// The deadline covers every attempt, so a loop wedged on an outage is abandoned and
+	// the next tick starts fresh rather than waiting on it forever
+	loopCtx := ctx
+	if loopDeadline > 0 {
+		var cancel context.CancelFunc
+		loopCtx, cancel = context.WithTimeout(ctx, loopDeadline)
+		defer cancel()
+	}
+
+	// Decided once per tick so retries of this loop run the same phases
+	phases := phaseSchedule.Next()
+
+	var loopErr error
+	_, err := retry.WithRetry(loopCtx, config.DefaultResilienceConfig.ProcessLoop, func(ctx context.Context) (struct{}, error) {
 		defer func() {
 			if r := recover(); r != nil {
-				slog.Error("Recovered from panic in process loop", "panic", r)
+				loopErr = fmt.Errorf("panic in process loop: %v", r)
+				slog.Error("Recovered from panic in process loop", "panic", r, "stack", string(debug.Stack()))
+				if count, tripped := panicTracker.Record(fmt.Sprint(r), time.Now()); tripped {
+					slog.Error("Same panic recurred too often, exiting", "panic", r, "occurrences", count)
+					flushCtx, cancel := context.WithTimeout(context.Background(), panicFlushTimeout)
+					flushAppendBuffer(flushCtx, sheetsClient, notificationClient)
+					cancel()
+					os.Exit(1)
+				}
 			}
 		}()
-		runProcessLoop(ctx, tornClient, sheetsClient, notificationClient)
+		loopErr = runProcessLoop(ctx, tornClient, sheetsClient, notificationClient, phases)
 		return struct{}{}, nil
 	})
 
 	if err != nil {
 		slog.Error("All retry attempts exhausted, skipping this cycle", "error", err)
+		loopErr = err
+	}
+	if errors.Is(loopCtx.Err(), context.DeadlineExceeded) {
+		slog.Error("Process loop exceeded LOOP_DEADLINE, abandoning it", "deadline", loopDeadline)
+		if loopErr == nil {
+			loopErr = loopCtx.Err()
+		}
+	}
+	scheduler.RecordResult(loopErr)
+	if loopErr == nil && freshness != nil {
+		freshness.RecordSuccess(time.Now())
+	}
+
+	if loopErr != nil && retryMode == config.RetryModeFinite {
+		slog.Error("Process loop failed and RETRY_MODE is finite, exiting", "error", loopErr)
+		flushAppendBuffer(ctx, sheetsClient, notificationClient)
+		notificationClient.FlushBatch(ctx, shutdownNotifyTimeout)
+		os.Exit(1)
 	}
 }
 
-func runProcessLoop(ctx context.Context, tornClient *torn.Client, sheetsClient *sheets.Client, notificationClient *notifications.Client) {
-	slog.Debug("Starting process loop")
+// runProcessLoop runs one pass of every phase, skipping the supplied and provided phases
+// when they aren't due. It returns an error when the loop failed: the crimes couldn't be
+// fetched, in which case the other phases still ran, or the sheet couldn't be reached.
+func runProcessLoop(ctx context.Context, tornClient torn.TornAPI, sheetsClient *sheets.Client, notificationClient *notifications.Client, phases app.Phases) error {
+	slog.Debug("Starting process loop", "supplied_phase", phases.Supplied, "provided_phase", phases.Provided)
 	tornClient.ResetAPICallCount()
+	sheetsClient.ResetReadCache()
+
+	processing.ReresolveFallbacks(ctx, tornClient, sheetsClient)
 
-	suppliedItems := processing.GetSuppliedItems(ctx, tornClient)
+	// A failed crimes fetch skips only the supplied phase; the provided, resolve and state
+	// phases still run, and the loop is reported as failed at the end
+	var loopErr error
+	var suppliedItems []torn.SuppliedItem
+	suppliedFetched := false
+	if phases.Supplied {
+		items, err := processing.GetSuppliedItems(ctx, tornClient)
+		if err != nil {
+			loopErr = err
+		} else {
+			suppliedItems = items
+			suppliedFetched = true
+			if unassignedTracker != nil {
+				unassignedTracker.Report(ctx, tornClient, notificationClient)
+			}
+		}
+	}
 	apiCallsAfterSupplied := tornClient.GetAPICallCount()
+	if supplyConfirmer != nil && suppliedFetched {
+		suppliedItems = supplyConfirmer.Confirm(suppliedItems)
+	}
+	runProvided := phases.Provided && len(providerList) > 0
+
+	// The sheet is read once here and shared with the provided phase; rows written in
+	// between are merged in rather than re-reading. Neither phase needs it when nothing
+	// was supplied and the provided phase isn't running.
+	var existingData [][]interface{}
+	var err error
+	if len(suppliedItems) > 0 || runProvided {
+		if existingData, err = readExistingSheetData(ctx, sheetsClient); err != nil {
+			return err
+		}
+	}
 
 	if len(suppliedItems) > 0 {
 		slog.Debug("Processing new supplied items", "count", len(suppliedItems))
 
-		existingData, err := retry.WithRetry(ctx, config.DefaultResilienceConfig.SheetRead, func(ctx context.Context) ([][]interface{}, error) {
-			return sheets.ReadExistingSheetData(ctx, sheetsClient)
-		})
-		if err != nil {
-			slog.Error("Failed to read existing sheet data after retries, skipping supplied items processing", "error", err)
-			return
-		}
-
 		existing := sheets.BuildExistingMap(existingData)
-		rows := processing.ProcessSuppliedItems(ctx, tornClient, suppliedItems, existing)
+		if appendBuffer != nil {
+			appendBuffer.MarkPending(existing)
+		}
+		rows, items := processing.ProcessSuppliedItems(ctx, tornClient, suppliedItems, existing)
 		apiCallsAfterProcessing := tornClient.GetAPICallCount()
 
-		if len(rows) > 0 {
+		if appendBuffer != nil {
+			appendBuffer.Add(rows, items, len(suppliedItems), time.Now())
+		} else if len(rows) > 0 {
 			slog.Debug("Updating sheet with new items", "rows", len(rows))
 			_, err := retry.WithRetry(ctx, config.DefaultResilienceConfig.SheetRead, func(ctx context.Context) (struct{}, error) {
-				return struct{}{}, sheets.UpdateSheet(ctx, sheetsClient, rows, len(suppliedItems), notificationClient)
+				return struct{}{}, sheets.UpdateSheet(ctx, sheetsClient, rows, items, len(suppliedItems), notificationClient)
 			})
 			if err != nil {
+				exitOnSheetPermissionError(err)
 				slog.Error("Failed to update sheet after retries", "error", err)
-				return
+				return err
 			}
+			existingData = sheets.MergeWrittenRows(existingData, rows)
+			// Buffered rows are published when the buffer flushes instead
+			events.PublishAll(ctx, eventSink, processing.SuppliedEvents(items))
 		} else {
 			slog.Debug("No new items to add to sheet")
 		}
@@ -98,11 +367,30 @@ func runProcessLoop(ctx context.Context, tornClient *torn.Client, sheetsClient *
 		slog.Debug("No supplied items found")
 	}
 
-	slog.Debug("Starting provided items processing")
+	if appendBuffer != nil && appendBuffer.Due(time.Now()) && flushAppendBuffer(ctx, sheetsClient, notificationClient) && runProvided {
+		// Buffered rows may come from earlier loops, so read them back rather than merging
+		if existingData, err = readExistingSheetData(ctx, sheetsClient); err != nil {
+			return err
+		}
+	}
+
 	apiCallsBeforeProvided := tornClient.GetAPICallCount()
-	processing.ProcessProvidedItems(ctx, tornClient, sheetsClient, providerList)
+	if phases.Provided {
+		slog.Debug("Starting provided items processing")
+		processing.ProcessProvidedItems(ctx, tornClient, sheetsClient, existingData, providerList, notificationClient, eventSink)
+	}
 	apiCallsAfterProvided := tornClient.GetAPICallCount()
 
+	processing.ResolveAvailableItems(ctx, tornClient, sheetsClient)
+
+	if needReminder != nil {
+		// Read again since the provided and resolved phases may have filled rows; this
+		// reuses the earlier read when the read cache is on and nothing was written
+		if existingData, err = readExistingSheetData(ctx, sheetsClient); err == nil {
+			needReminder.Remind(ctx, existingData, notificationClient)
+		}
+	}
+
 	slog.Debug("Starting state transition tracking")
 	apiCallsBeforeTracking := tornClient.GetAPICallCount()
 	processStateTransitions(ctx, tornClient, notificationClient)
@@ -115,9 +403,53 @@ func runProcessLoop(ctx context.Context, tornClient *torn.Client, sheetsClient *
 		"api_calls_state_tracking", apiCallsAfterTracking-apiCallsBeforeTracking,
 		"total_api_calls_this_loop", totalAPICalls,
 	)
+	return loopErr
+}
+
+// flushAppendBuffer writes any coalesced rows to the sheet and publishes their events;
+// rows stay buffered when the append fails so they are retried on the next flush
+func flushAppendBuffer(ctx context.Context, sheetsClient *sheets.Client, notificationClient *notifications.Client) bool {
+	if appendBuffer == nil || appendBuffer.Len() == 0 {
+		return false
+	}
+
+	written, err := retry.WithRetry(ctx, config.DefaultResilienceConfig.SheetRead, func(ctx context.Context) ([]notifications.ItemInfo, error) {
+		return appendBuffer.Flush(ctx, sheetsClient, notificationClient)
+	})
+	if err != nil {
+		exitOnSheetPermissionError(err)
+		slog.Error("Failed to flush coalesced rows after retries, keeping them buffered",
+			"error", err,
+			"pending", appendBuffer.Len(),
+		)
+		return false
+	}
+	events.PublishAll(ctx, eventSink, processing.SuppliedEvents(written))
+	return true
+}
+
+// readExistingSheetData reads the sheet with retries, stopping the process on a
+// permission error
+func readExistingSheetData(ctx context.Context, sheetsClient *sheets.Client) ([][]interface{}, error) {
+	existingData, err := sheets.ReadExistingSheetDataWithRetry(ctx, sheetsClient, config.DefaultResilienceConfig.SheetRead)
+	if err != nil {
+		exitOnSheetPermissionError(err)
+		slog.Error("Failed to read existing sheet data after retries, skipping this cycle", "error", err)
+		return nil, err
+	}
+	return existingData, nil
+}
+
+// exitOnSheetPermissionError stops the process when the service account can't access the
+// sheet, since retrying can never succeed until the sheet is shared with it
+func exitOnSheetPermissionError(err error) {
+	if errors.Is(err, sheets.ErrPermission) {
+		slog.Error("Permission denied accessing spreadsheet; share it with the service account in the credentials file", "credentials_file", app.CredentialsFile(), "error", err)
+		os.Exit(1)
+	}
 }
 
-func processStateTransitions(ctx context.Context, tornClient *torn.Client, notificationClient *notifications.Client) {
+func processStateTransitions(ctx context.Context, tornClient torn.TornAPI, notificationClient *notifications.Client) {
 	planningCrimes, err := retry.WithRetry(ctx, config.DefaultResilienceConfig.StateTracking, func(ctx context.Context) (*torn.CrimesResponse, error) {
 		return tornClient.GetPlanningCrimes(ctx)
 	})
@@ -0,0 +1,77 @@
+package mock_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"torn_oc_items/internal/clock"
+	"torn_oc_items/internal/events"
+	"torn_oc_items/internal/processing"
+	"torn_oc_items/internal/providers"
+	"torn_oc_items/internal/sheets"
+	"torn_oc_items/internal/torn"
+)
+
+const fixtureDir = "../testdata/mock"
+
+// TestMockPipeline runs the supplied and provided phases against the fixtures and an
+// in-memory sheet, checking which rows end up added and provided. The clock is fixed as
+// FIXED_NOW would, so the provided times are exact.
+func TestMockPipeline(t *testing.T) {
+	t.Setenv("SPREADSHEET_ID", "mock")
+	t.Setenv("SPREADSHEET_RANGE", "Mock Sheet!A1")
+	clock.SetFixed(time.Date(2025, 3, 14, 15, 0, 0, 0, time.UTC))
+	t.Cleanup(func() { clock.SetFixed(time.Time{}) })
+
+	ctx := context.Background()
+	tornClient := torn.NewMockClient(fixtureDir, "MockFaction", "")
+	sheetsClient, err := sheets.NewMockClient(fixtureDir + "/sheet.json")
+	if err != nil {
+		t.Fatalf("Failed to create mock sheet: %v", err)
+	}
+	providerList := providers.LoadMockProviders(fixtureDir)
+	if len(providerList) != 1 || providerList[0].Name != "Carol" {
+		t.Fatalf("Expected mock provider Carol, got %+v", providerList)
+	}
+
+	suppliedItems, err := processing.GetSuppliedItems(ctx, tornClient)
+	if err != nil {
+		t.Fatalf("Failed to get supplied items: %v", err)
+	}
+	existingData, err := sheets.ReadExistingSheetData(ctx, sheetsClient)
+	if err != nil {
+		t.Fatalf("Failed to read mock sheet: %v", err)
+	}
+	rows, items := processing.ProcessSuppliedItems(ctx, tornClient, suppliedItems, sheets.BuildExistingMap(existingData))
+	if err := sheets.UpdateSheet(ctx, sheetsClient, rows, items, len(suppliedItems), nil); err != nil {
+		t.Fatalf("Failed to update mock sheet: %v", err)
+	}
+
+	processing.ProcessProvidedItems(ctx, tornClient, sheetsClient, sheets.MergeWrittenRows(existingData, rows), providerList, nil, events.NopSink{})
+
+	finalData, err := sheetsClient.ReadSheet(ctx, "mock", "Mock Sheet!A1:Z1000")
+	if err != nil {
+		t.Fatalf("Failed to read mock sheet: %v", err)
+	}
+
+	expected := []struct {
+		status, provider, dateTime, item, user string
+	}{
+		{"Status", "Provider", "Datetime", "Item", "User"},
+		{"Provided", "Carol", "14:55:00 - 14/03/25", "Jemmy", "Dana"},
+		{"Provided", "Carol", "14:50:00 - 14/03/25", "Binoculars", "Alice"},
+		{"Needed", "", "", "Bolt Cutters", "Bob"},
+	}
+	if len(finalData) != len(expected) {
+		t.Fatalf("Expected %d rows, got %d: %v", len(expected), len(finalData), finalData)
+	}
+	for i, want := range expected {
+		row := finalData[i]
+		got := fmt.Sprintf("%v|%v|%v|%v|%v", row[0], row[1], row[3], row[4], row[5])
+		if got != fmt.Sprintf("%s|%s|%s|%s|%s", want.status, want.provider, want.dateTime, want.item, want.user) {
+			t.Errorf("Row %d: got %s, want %+v", i+1, got, want)
+		}
+	}
+}
@@ -15,7 +15,7 @@ func TestGetItem(t *testing.T) {
 	}
 
 	factionApiKey := os.Getenv("TORN_FACTION_API_KEY")
-	client := torn.NewClient(apiKey, factionApiKey)
+	client := torn.NewClient(apiKey, factionApiKey, "torn-oc-items/test", nil)
 
 	ctx := context.Background()
 	item, err := client.GetItem(ctx, "1258")